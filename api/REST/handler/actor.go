@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/pkg"
 	"fmt"
 	"net/http"
+	"strconv"
 )
 
 var prefixActor = "/api/actor/"
@@ -16,44 +18,58 @@ func (h *Handler) actor(w http.ResponseWriter, r *http.Request) {
 	case "GET":
 		h.getActor(w, r)
 	case "PUT":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionActorWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.putActor(w, r)
 	case "PATCH":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionActorWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.patchActor(w, r)
 	case "DELETE":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionActorDelete); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.deleteActor(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 }
 
 func (h *Handler) actors(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
-		h.getActors(w)
+		h.getActors(w, r)
 	case "POST":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionActorWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
-		h.createActor(w, r)
+		h.idempotent(h.createActor)(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) actorBatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionActorWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.idempotent(h.batchActors)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 }
 
@@ -64,10 +80,13 @@ func (h *Handler) actors(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-None-Match header string false "ETags the client has"
 // @Success      200  {object}  presenter.ActorResponse
-// @Failure      400  {object}  string
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the actor"
+// @Success      304
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /actor/{id} [get]
 func (h *Handler) getActor(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixActor)
@@ -75,9 +94,12 @@ func (h *Handler) getActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	actor, err := h.services.GetActor(id)
+	actor, err := h.services.GetActor(r.Context(), id)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if pkg.NotModified(w, r, actor.Version) {
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
@@ -85,7 +107,6 @@ func (h *Handler) getActor(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
 }
 
-
 // Get actors
 // @Summary      Get actors
 // @Description  Get actors
@@ -93,13 +114,13 @@ func (h *Handler) getActor(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  []presenter.ActorResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /actor [get]
-func (h *Handler) getActors(w http.ResponseWriter) {
-	actors, err := h.services.GetActors()
+func (h *Handler) getActors(w http.ResponseWriter, r *http.Request) {
+	actors, err := h.services.GetActors(r.Context())
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusInternalServerError)
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
@@ -107,28 +128,50 @@ func (h *Handler) getActors(w http.ResponseWriter) {
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
 }
 
+// Create actor only for ADMIN
+// @Summary      Create actor
+// @Description  Create actor
+// @Tags         actors
+// @Accept       json
+// @Produce      json
+// @Param 		 Idempotency-Key header string false "key to retry the request with without creating a duplicate"
+// @Param 		 request body presenter.ActorRequest true "actor"
+// @Success      201  {object}  presenter.CreatedResponse
+// @Header       201  {string}  Location "/api/actor/{id}"
+// @Header       201  {string}  Idempotent-Replayed "true for the stored response to an earlier request with the key"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /actor [post]
 func (h *Handler) createActor(w http.ResponseWriter, r *http.Request) {
 	var request presenter.ActorRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	defaultActor(&request)
+	if err := requireActor(request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	var id int
-	id, err = h.services.CreateActor(request)
+	id, err = h.services.CreateActor(r.Context(), request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	fmt.Fprintf(w, "%d", id)
+	w.Header().Set("Location", "/api/actor/"+strconv.Itoa(id))
+	pkg.WriteJSON(w, http.StatusCreated, presenter.CreatedResponse{Id: id})
 }
 
-
 // Put actor by id only for ADMIN
 // @Summary      Put actor by id
 // @Description  Put actor by id
@@ -136,10 +179,15 @@ func (h *Handler) createActor(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id path int true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.ActorRequest true "actor"
 // @Success      200  {object}  presenter.ActorResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the actor"
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /actor/{id} [put]
 func (h *Handler) putActor(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixActor)
@@ -147,20 +195,32 @@ func (h *Handler) putActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
 	var request presenter.ActorRequest
-	err = json.NewDecoder(r.Body).Decode(&request)
+	err = pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := requireActor(request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	actor, err := h.services.PutActor(id, request)
+	actor, err := h.services.PutActor(r.Context(), id, version, request)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("ETag", pkg.ETag(actor.Version))
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(actor)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
@@ -168,15 +228,22 @@ func (h *Handler) putActor(w http.ResponseWriter, r *http.Request) {
 
 // Patch actor by id only for ADMIN
 // @Summary      Patch actor by id
-// @Description  Patch actor by id
+// @Description  Patch actor by id with the fields to change, a JSON Merge Patch or a JSON Patch
 // @Tags         actors
-// @Accept       json
+// @Accept       json,application/merge-patch+json,application/json-patch+json
 // @Produce      json
 // @Param 		 id path int true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.ActorRequest true "actor"
 // @Success      200  {object}  presenter.ActorResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the actor"
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      415  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /actor/{id} [patch]
 func (h *Handler) patchActor(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixActor)
@@ -184,25 +251,103 @@ func (h *Handler) patchActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
+	patchType, ok := acceptPatch(w, r)
+	if !ok {
+		return
+	}
+	if patchType != "application/json" {
+		h.patchActorDocument(w, r, id, version, patchType)
+		return
+	}
+
 	var request presenter.ActorRequest
-	err = json.NewDecoder(r.Body).Decode(&request)
+	err = pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	actor, err := h.services.PatchActor(id, request)
+	actor, err := h.services.PatchActor(r.Context(), id, version, request)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("ETag", pkg.ETag(actor.Version))
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(actor)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
 }
 
+// patchActorDocument applies a merge patch or JSON patch to the actor and puts
+// the result, validated like a PUT. Removing the films clears them
+func (h *Handler) patchActorDocument(w http.ResponseWriter, r *http.Request, id, version int, patchType string) {
+	current, err := h.services.GetActor(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if version != 0 && version != current.Version {
+		pkg.HandleError(w, r, &entity.PreconditionFailedError{Entity: "actor"}, http.StatusPreconditionFailed)
+		return
+	}
+	if current.FilmsId == nil {
+		current.FilmsId = []int{}
+	}
+
+	var request presenter.ActorRequest
+	if err := patchDocument(r, patchType, current, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	defaultActor(&request)
+	if err := requireActor(request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	// conditional on the version patched, so changes made meanwhile are not lost
+	actor, err := h.services.PutActor(r.Context(), id, current.Version, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("ETag", pkg.ETag(actor.Version))
+	pkg.WriteJSON(w, http.StatusOK, actor)
+}
+
+// defaultActor fills the films a create may leave out, a PUT without them
+// keeps the films of the actor
+func defaultActor(request *presenter.ActorRequest) {
+	if request.FilmsId == nil {
+		request.FilmsId = &[]int{}
+	}
+}
+
+// requireActor checks that a request has every field a create or PUT sets
+func requireActor(request presenter.ActorRequest) error {
+	var missing []string
+	if request.Name == nil {
+		missing = append(missing, "name")
+	}
+	if request.Sex == nil {
+		missing = append(missing, "sex")
+	}
+	if request.Birthday == nil {
+		missing = append(missing, "birthday")
+	}
+	return pkg.RequiredFields(missing...)
+}
+
 // Delete actor by id only for ADMIN
 // @Summary      Delete actor by id
 // @Description  Delete actor by id
@@ -210,9 +355,12 @@ func (h *Handler) patchActor(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Success      200  {object}  string
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /actor/{id} [delete]
 func (h *Handler) deleteActor(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixActor)
@@ -220,9 +368,85 @@ func (h *Handler) deleteActor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.services.DeleteActor(id)
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
+	err = h.services.DeleteActor(r.Context(), id, version)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Batch of actor operations only for ADMIN
+// @Summary      Create, update and delete actors
+// @Description  Apply up to 100 create, update and delete operations. An atomic batch, the default, applies all of them
+// @Description  or none and answers with the problem of the operation that failed. A partial batch applies each
+// @Description  operation on its own and answers with the status, id and errors of each
+// @Tags         actors
+// @Accept       json
+// @Produce      json
+// @Param 		 Idempotency-Key header string false "key to retry the request with without applying it twice"
+// @Param 		 request body presenter.ActorBatchRequest true "operations"
+// @Success      200  {object}  presenter.BatchResponse
+// @Success      207  {object}  presenter.BatchResponse
+// @Header       200,207  {string}  Idempotent-Replayed "true for the stored response to an earlier request with the key"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      404  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /actor/batch [post]
+func (h *Handler) batchActors(w http.ResponseWriter, r *http.Request) {
+	var request presenter.ActorBatchRequest
+	if err := pkg.DecodeJSON(r, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	atomic, err := batchMode(request.Mode, len(request.Operations))
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
+
+	ops := make([]string, len(request.Operations))
+	invalid := make([]error, len(request.Operations))
+	for i, operation := range request.Operations {
+		ops[i] = operation.Op
+		if operation.Op == presenter.BatchDelete {
+			if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionActorDelete); err != nil {
+				pkg.WriteProblem(w, r, http.StatusForbidden, "")
+				return
+			}
+		}
+		invalid[i] = validateActorOperation(&request.Operations[i])
+	}
+
+	writeBatch(w, r, atomic, ops, invalid, func(valid []int) ([]entity.BatchResult, error) {
+		operations := make([]presenter.ActorBatchOperation, len(valid))
+		for j, i := range valid {
+			operations[j] = request.Operations[i]
+		}
+		return h.services.BatchActors(r.Context(), atomic, operations)
+	})
+}
+
+// validateActorOperation checks an operation like the request it stands for
+func validateActorOperation(operation *presenter.ActorBatchOperation) error {
+	if err := validateBatchOperation(operation.Op, operation.Id, operation.Version); err != nil {
+		return err
+	}
+	switch operation.Op {
+	case presenter.BatchCreate:
+		defaultActor(&operation.Actor)
+	case presenter.BatchDelete:
+		return nil
+	}
+	return prefixFields(requireActor(operation.Actor), "actor.")
 }
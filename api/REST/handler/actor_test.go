@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/service"
 	mock_service "filmLibraryVk/internal/service/mocks"
 	"filmLibraryVk/pkg"
@@ -32,7 +33,7 @@ func TestHandler_getActors(t *testing.T) {
 			headerName:  "Authorization",
 			headerValue: "Bearer USER",
 			mockBehavior: func(r *mock_service.MockActor) {
-				r.EXPECT().GetActors().Return([]presenter.ActorResponse{
+				r.EXPECT().GetActors(gomock.Any()).Return([]presenter.ActorResponse{
 					{Id: 1, Sex: "male", Birthday: "2021-10-12", Name: "username", FilmsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
@@ -43,7 +44,7 @@ func TestHandler_getActors(t *testing.T) {
 			headerName:  "Authorization",
 			headerValue: "Bearer ADMIN",
 			mockBehavior: func(r *mock_service.MockActor) {
-				r.EXPECT().GetActors().Return([]presenter.ActorResponse{
+				r.EXPECT().GetActors(gomock.Any()).Return([]presenter.ActorResponse{
 					{Id: 1, Sex: "male", Birthday: "2021-10-12", Name: "username", FilmsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
@@ -53,7 +54,7 @@ func TestHandler_getActors(t *testing.T) {
 			name:                 "Unauthorized",
 			mockBehavior:         func(r *mock_service.MockActor) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -61,7 +62,7 @@ func TestHandler_getActors(t *testing.T) {
 			headerValue:          "Bearer USE",
 			mockBehavior:         func(r *mock_service.MockActor) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -109,7 +110,7 @@ func TestHandler_getActor(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockActor, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetActor(idd).Return(presenter.ActorResponse{
+				r.EXPECT().GetActor(gomock.Any(), idd).Return(presenter.ActorResponse{
 					Id: 1, Sex: "male", Birthday: "2021-10-12", Name: "username", FilmsId: []int{1, 2}}, nil)
 			},
 			expectedStatusCode:   200,
@@ -122,7 +123,7 @@ func TestHandler_getActor(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockActor, id string) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/actor/1s\"}\n",
 		},
 		{
 			name:        "Ok admin",
@@ -131,7 +132,7 @@ func TestHandler_getActor(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockActor, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetActor(idd).Return(presenter.ActorResponse{
+				r.EXPECT().GetActor(gomock.Any(), idd).Return(presenter.ActorResponse{
 					Id: 1, Sex: "male", Birthday: "2021-10-12", Name: "username", FilmsId: []int{1, 2}}, nil)
 			},
 			expectedStatusCode:   200,
@@ -142,7 +143,7 @@ func TestHandler_getActor(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -151,7 +152,7 @@ func TestHandler_getActor(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -219,10 +220,66 @@ func TestHandler_postActor(t *testing.T) {
 				FilmsId:  filmsId,
 			},
 			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest) {
-				r.EXPECT().CreateActor(actor).Return(1, nil)
+				r.EXPECT().CreateActor(gomock.Any(), actor).Return(1, nil)
 			},
 			expectedStatusCode:   201,
-			expectedResponseBody: "1",
+			expectedResponseBody: "{\"id\":1}\n",
+		},
+		{
+			name:        "Without films",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"name": "name", "sex": "sex", "birthday": "2021-10-12"}`,
+			inputActor: presenter.ActorRequest{
+				Name:     name,
+				Sex:      sex,
+				Birthday: birthday,
+				FilmsId:  &[]int{},
+			},
+			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest) {
+				r.EXPECT().CreateActor(gomock.Any(), actor).Return(1, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: "{\"id\":1}\n",
+		},
+		{
+			name:        "Sex normalized",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"name": "name", "sex": " Male ", "birthday": "2021-10-12", "filmsId": [1, 2]}`,
+			inputActor: presenter.ActorRequest{
+				Name:     name,
+				Sex:      &[]string{"male"}[0],
+				Birthday: birthday,
+				FilmsId:  filmsId,
+			},
+			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest) {
+				r.EXPECT().CreateActor(gomock.Any(), actor).Return(1, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: "{\"id\":1}\n",
+		},
+		{
+			name:        "Unknown sex",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"name": "name", "sex": "sex", "birthday": "2021-10-12", "filmsId": [1, 2]}`,
+			inputActor: presenter.ActorRequest{
+				Name:     name,
+				Sex:      sex,
+				Birthday: birthday,
+				FilmsId:  filmsId,
+			},
+			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest) {
+				r.EXPECT().CreateActor(gomock.Any(), actor).Return(0, &entity.ValidationError{
+					Message:    "Invalid request body",
+					Fields:     []entity.FieldError{{Field: "sex", Message: "must be a known sex"}},
+					Constraint: "actor_sex_fkey"})
+			},
+			expectedStatusCode: 422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422," +
+				"\"detail\":\"Invalid request body\",\"instance\":\"/api/actor\"," +
+				"\"errors\":[{\"field\":\"sex\",\"message\":\"must be a known sex\"}],\"constraint\":\"actor_sex_fkey\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -230,13 +287,13 @@ func TestHandler_postActor(t *testing.T) {
 			headerValue:          "Bearer USER",
 			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -308,7 +365,7 @@ func TestHandler_putActor(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PutActor(idd, actor).Return(presenter.ActorResponse{
+				r.EXPECT().PutActor(gomock.Any(), idd, 0, actor).Return(presenter.ActorResponse{
 					Id:       1,
 					Name:     "name",
 					Sex:      "sex",
@@ -326,7 +383,7 @@ func TestHandler_putActor(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/actor/1s\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -335,14 +392,14 @@ func TestHandler_putActor(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -409,7 +466,7 @@ func TestHandler_patchActor(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PatchActor(idd, actor).Return(presenter.ActorResponse{
+				r.EXPECT().PatchActor(gomock.Any(), idd, 0, actor).Return(presenter.ActorResponse{
 					Id:       1,
 					Name:     "name",
 					Sex:      "sex",
@@ -427,7 +484,7 @@ func TestHandler_patchActor(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/actor/1s\"}\n",
 		},
 		{
 			name:        "Entity not found",
@@ -442,10 +499,10 @@ func TestHandler_patchActor(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PatchActor(idd, actor).Return(presenter.ActorResponse{}, errors.New("entity not found"))
+				r.EXPECT().PatchActor(gomock.Any(), idd, 0, actor).Return(presenter.ActorResponse{}, errors.New("entity not found"))
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: "entity not found\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/actor/-1\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -454,14 +511,14 @@ func TestHandler_patchActor(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string, actor presenter.ActorRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -510,7 +567,7 @@ func TestHandler_deleteActor(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockActor, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().DeleteActor(idd)
+				r.EXPECT().DeleteActor(gomock.Any(), idd, 0)
 			},
 			expectedStatusCode: 200,
 		},
@@ -521,7 +578,7 @@ func TestHandler_deleteActor(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockActor, id string) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/actor/1s\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -530,14 +587,14 @@ func TestHandler_deleteActor(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockActor, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/actor/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -606,7 +663,7 @@ func TestHandler_actors_invalid_method(t *testing.T) {
 			},
 			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/actor\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -680,7 +737,7 @@ func TestHandler_actor_invalid_method(t *testing.T) {
 			},
 			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
 			name:        "Ok admin",
@@ -689,7 +746,7 @@ func TestHandler_actor_invalid_method(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetActor(idd).Return(presenter.ActorResponse{
+				r.EXPECT().GetActor(gomock.Any(), idd).Return(presenter.ActorResponse{
 					Id: 1, Sex: "male", Birthday: "2021-10-12", Name: "username", FilmsId: []int{1, 2}}, nil)
 			},
 			expectedStatusCode:   200,
@@ -710,7 +767,7 @@ func TestHandler_actor_invalid_method(t *testing.T) {
 			},
 			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
 			name:        "PATCH",
@@ -724,19 +781,19 @@ func TestHandler_actor_invalid_method(t *testing.T) {
 				Birthday: birthday,
 				FilmsId:  filmsId,
 			},
-			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {},
+			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor/1\"}\n",
 		},
 		{
-			name:        "DELETE",
-			headerName:  "Authorization",
-			headerValue: "Bearer ADMIN",
-			id:          "1",
-			method: "DELETE",
-			mockBehavior: func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {},
-			expectedStatusCode: 403,
-			expectedResponseBody: "Forbidden\n",
+			name:                 "DELETE",
+			headerName:           "Authorization",
+			headerValue:          "Bearer ADMIN",
+			id:                   "1",
+			method:               "DELETE",
+			mockBehavior:         func(r *mock_service.MockActor, actor presenter.ActorRequest, id string) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/actor/1\"}\n",
 		},
 	}
 	for _, test := range tests {
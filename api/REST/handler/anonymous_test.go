@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_anonymousRead(t *testing.T) {
+	type mockBehavior func(a *mock_service.MockAnonymous, f *mock_service.MockFilm)
+
+	tests := []struct {
+		name                 string
+		method               string
+		disabled             bool
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok",
+			method: "GET",
+			mockBehavior: func(a *mock_service.MockAnonymous, f *mock_service.MockFilm) {
+				a.EXPECT().AuthenticateAnonymous(gomock.Any()).Return(&pkg.Claims{
+					Role: 5, Permissions: []string{entity.PermissionFilmRead}}, nil)
+				f.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[]\n",
+		},
+		{
+			name:   "Role without permission",
+			method: "GET",
+			mockBehavior: func(a *mock_service.MockAnonymous, f *mock_service.MockFilm) {
+				a.EXPECT().AuthenticateAnonymous(gomock.Any()).Return(&pkg.Claims{
+					Role: 5, Permissions: []string{entity.PermissionActorRead}}, nil)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name:   "Role does not exist",
+			method: "GET",
+			mockBehavior: func(a *mock_service.MockAnonymous, f *mock_service.MockFilm) {
+				a.EXPECT().AuthenticateAnonymous(gomock.Any()).Return(nil, errors.New("entity not found"))
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name:                 "Write needs token",
+			method:               "POST",
+			mockBehavior:         func(a *mock_service.MockAnonymous, f *mock_service.MockFilm) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name:                 "Disabled",
+			method:               "GET",
+			disabled:             true,
+			mockBehavior:         func(a *mock_service.MockAnonymous, f *mock_service.MockFilm) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			anonymous := mock_service.NewMockAnonymous(c)
+			films := mock_service.NewMockFilm(c)
+			test.mockBehavior(anonymous, films)
+
+			if !test.disabled {
+				pkg.SetAnonymousAccess(anonymous, pkg.NewRateLimiter(10, time.Minute))
+				t.Cleanup(func() { pkg.SetAnonymousAccess(nil, nil) })
+			}
+
+			services := &service.Service{Anonymous: anonymous, Film: films}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film", pkg.AnonymousReadPermission(entity.PermissionFilmRead, handler.films))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/film", nil)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_anonymousRateLimit(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	anonymous := mock_service.NewMockAnonymous(c)
+	films := mock_service.NewMockFilm(c)
+	anonymous.EXPECT().AuthenticateAnonymous(gomock.Any()).Return(&pkg.Claims{
+		Role: 5, Permissions: []string{entity.PermissionFilmRead}}, nil).Times(2)
+	films.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{}, nil).Times(2)
+
+	pkg.SetAnonymousAccess(anonymous, pkg.NewRateLimiter(2, time.Minute))
+	t.Cleanup(func() { pkg.SetAnonymousAccess(nil, nil) })
+
+	handler := Handler{&service.Service{Anonymous: anonymous, Film: films}}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/film", pkg.AnonymousReadPermission(entity.PermissionFilmRead, handler.films))
+
+	for _, expected := range []int{200, 200, 429} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/api/film", nil)
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, w.Code, expected)
+		if expected == 429 {
+			assert.Equal(t, w.Header().Get("Retry-After"), "60")
+		}
+	}
+
+	// another client has its own limit
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/film", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+	anonymous.EXPECT().AuthenticateAnonymous(gomock.Any()).Return(&pkg.Claims{
+		Role: 5, Permissions: []string{entity.PermissionFilmRead}}, nil)
+	films.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{}, nil)
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, w.Code, 200)
+}
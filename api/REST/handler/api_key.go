@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+)
+
+var prefixApiKey = "/api/api-key/"
+
+func (h *Handler) apiKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getApiKeys(w, r)
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionApiKeyWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		pkg.DenyImpersonation(h.createApiKey)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) apiKey(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "DELETE":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionApiKeyWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		pkg.DenyImpersonation(h.revokeApiKey)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Get api keys
+// @Summary      Get api keys
+// @Description  Get api keys. The key itself is only shown on creation
+// @Tags         api keys
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  []presenter.ApiKeyResponse
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /api-key [get]
+func (h *Handler) getApiKeys(w http.ResponseWriter, r *http.Request) {
+	apiKeys, err := h.services.GetApiKeys(r.Context())
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(apiKeys)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Create api key only with apikey:write permission
+// @Summary      Create api key
+// @Description  Create an api key for the X-API-Key header. Scopes can not exceed the permissions of the caller
+// @Tags         api keys
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.ApiKeyRequest true "api key"
+// @Success      201  {object}  presenter.ApiKeyResponse
+// @Header       201  {string}  Location "/api/api-key/{id}"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /api-key [post]
+func (h *Handler) createApiKey(w http.ResponseWriter, r *http.Request) {
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.ApiKeyRequest
+	if err := pkg.DecodeJSON(r, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	apiKey, err := h.services.CreateApiKey(r.Context(), claims.Id, claims.Permissions, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Location", "/api/api-key/"+strconv.Itoa(apiKey.Id))
+	pkg.WriteJSON(w, http.StatusCreated, apiKey)
+}
+
+// Revoke api key by id only with apikey:write permission
+// @Summary      Revoke api key by id
+// @Description  Revoke api key by id. Revoked keys stay listed
+// @Tags         api keys
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /api-key/{id} [delete]
+func (h *Handler) revokeApiKey(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixApiKey)
+	if err != nil {
+		return
+	}
+
+	err = h.services.RevokeApiKey(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
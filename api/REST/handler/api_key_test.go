@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getApiKeys(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockApiKey)
+
+	createdBy := 1
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockApiKey) {
+				r.EXPECT().GetApiKeys(gomock.Any()).Return([]presenter.ApiKeyResponse{{
+					Id: 1, Name: "ci", Prefix: "flk_abcdef", Scopes: []string{entity.PermissionFilmRead},
+					CreatedBy: &createdBy, CreatedAt: created, LastUsedAt: &created}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "[{\"id\":1,\"name\":\"ci\",\"prefix\":\"flk_abcdef\",\"scopes\":[\"film:read\"]," +
+				"\"createdBy\":1,\"createdAt\":\"2024-03-01T12:00:00Z\",\"expiresAt\":null," +
+				"\"lastUsedAt\":\"2024-03-01T12:00:00Z\",\"revokedAt\":null}]\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockApiKey) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/api-key\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockApiKey(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{ApiKey: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/api-key", pkg.MockJWTAuthPermission(entity.PermissionApiKeyRead, handler.apiKeys))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/api-key", nil)
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_createApiKey(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockApiKey, request presenter.ApiKeyRequest)
+
+	createdBy := 1
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		inputBody            string
+		inputRequest         presenter.ApiKeyRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok admin",
+			headerValue:  "Bearer ADMIN",
+			inputBody:    `{"name": "ci", "scopes": ["film:read"]}`,
+			inputRequest: presenter.ApiKeyRequest{Name: "ci", Scopes: []string{entity.PermissionFilmRead}},
+			mockBehavior: func(r *mock_service.MockApiKey, request presenter.ApiKeyRequest) {
+				r.EXPECT().CreateApiKey(gomock.Any(), 1, []string(nil), request).Return(presenter.ApiKeyResponse{
+					Id: 2, Name: "ci", Key: "flk_abcdefsecret", Prefix: "flk_abcdef",
+					Scopes: []string{entity.PermissionFilmRead}, CreatedBy: &createdBy, CreatedAt: created}, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResponseBody: "{\"id\":2,\"name\":\"ci\",\"key\":\"flk_abcdefsecret\",\"prefix\":\"flk_abcdef\"," +
+				"\"scopes\":[\"film:read\"],\"createdBy\":1,\"createdAt\":\"2024-03-01T12:00:00Z\"," +
+				"\"expiresAt\":null,\"lastUsedAt\":null,\"revokedAt\":null}\n",
+		},
+		{
+			name:         "Scope not held by caller",
+			headerValue:  "Bearer ADMIN",
+			inputBody:    `{"name": "ci", "scopes": ["user:write"]}`,
+			inputRequest: presenter.ApiKeyRequest{Name: "ci", Scopes: []string{entity.PermissionUserWrite}},
+			mockBehavior: func(r *mock_service.MockApiKey, request presenter.ApiKeyRequest) {
+				r.EXPECT().CreateApiKey(gomock.Any(), 1, []string(nil), request).Return(presenter.ApiKeyResponse{},
+					errors.New("Can not grant permission user:write"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Can not grant permission user:write\",\"instance\":\"/api/api-key\"}\n",
+		},
+		{
+			name:                 "No scopes",
+			headerValue:          "Bearer ADMIN",
+			inputBody:            `{"name": "ci", "scopes": []}`,
+			mockBehavior:         func(r *mock_service.MockApiKey, request presenter.ApiKeyRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/api-key\",\"errors\":[{\"field\":\"scopes\",\"message\":\"must not be empty\"}]}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockApiKey, request presenter.ApiKeyRequest) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/api-key\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockApiKey(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{ApiKey: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/api-key", pkg.MockJWTAuthPermission(entity.PermissionApiKeyWrite, handler.createApiKey))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/api-key",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_revokeApiKey(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockApiKey)
+
+	tests := []struct {
+		name                 string
+		method               string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok admin",
+			method: "DELETE",
+			path:   "/api/api-key/2",
+			mockBehavior: func(r *mock_service.MockApiKey) {
+				r.EXPECT().RevokeApiKey(gomock.Any(), 2).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "Not found",
+			method: "DELETE",
+			path:   "/api/api-key/9",
+			mockBehavior: func(r *mock_service.MockApiKey) {
+				r.EXPECT().RevokeApiKey(gomock.Any(), 9).Return(errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/api-key/9\"}\n",
+		},
+		{
+			name:                 "Method not allowed",
+			method:               "PUT",
+			path:                 "/api/api-key/2",
+			mockBehavior:         func(r *mock_service.MockApiKey) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/api-key/2\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockApiKey(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{ApiKey: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			if test.method == "DELETE" {
+				mux.Handle("/api/api-key/", pkg.MockJWTAuthPermission(entity.PermissionApiKeyWrite, handler.revokeApiKey))
+			} else {
+				mux.Handle("/api/api-key/", pkg.MockJWTAuthPermission(entity.PermissionApiKeyRead, handler.apiKey))
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_apiKeyAuth(t *testing.T) {
+	type mockBehavior func(k *mock_service.MockApiKey, f *mock_service.MockFilm)
+
+	tests := []struct {
+		name                 string
+		key                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok with scope",
+			key:  "flk_valid",
+			mockBehavior: func(k *mock_service.MockApiKey, f *mock_service.MockFilm) {
+				k.EXPECT().AuthenticateApiKey(gomock.Any(), "flk_valid").Return(&pkg.Claims{
+					ApiKeyId: 3, Permissions: []string{entity.PermissionFilmRead}}, nil)
+				f.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[]\n",
+		},
+		{
+			name: "Missing scope",
+			key:  "flk_actors",
+			mockBehavior: func(k *mock_service.MockApiKey, f *mock_service.MockFilm) {
+				k.EXPECT().AuthenticateApiKey(gomock.Any(), "flk_actors").Return(&pkg.Claims{
+					ApiKeyId: 4, Permissions: []string{entity.PermissionActorRead}}, nil)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name: "Revoked key",
+			key:  "flk_revoked",
+			mockBehavior: func(k *mock_service.MockApiKey, f *mock_service.MockFilm) {
+				k.EXPECT().AuthenticateApiKey(gomock.Any(), "flk_revoked").Return(nil, service.ErrInvalidApiKey)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid API key\",\"instance\":\"/api/film\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			keys := mock_service.NewMockApiKey(c)
+			films := mock_service.NewMockFilm(c)
+			test.mockBehavior(keys, films)
+
+			pkg.SetApiKeyAuthenticator(keys)
+			t.Cleanup(func() { pkg.SetApiKeyAuthenticator(nil) })
+
+			services := &service.Service{ApiKey: keys, Film: films}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film", pkg.JWTAuthPermission(entity.PermissionFilmRead, handler.films))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/film", nil)
+			req.Header.Add(pkg.ApiKeyHeader, test.key)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -1,15 +1,38 @@
 package handler
 
 import (
-	"encoding/json"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/service"
 	"filmLibraryVk/pkg"
-	"fmt"
 	"github.com/go-playground/validator/v10"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 )
 
+func client(r *http.Request) presenter.Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return presenter.Client{UserAgent: r.UserAgent(), Ip: ip}
+}
+
+// writeThrottled answers a throttled login or reauthentication with 429 and
+// reports whether err was one
+func writeThrottled(w http.ResponseWriter, r *http.Request, err error) bool {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	pkg.HandleError(w, r, err, http.StatusTooManyRequests)
+	return true
+}
+
 // Register an account
 // @Summary      Register an account
 // @Description  Register an account
@@ -17,39 +40,48 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param request body presenter.Register true "register"
-// @Success      201  {object}  string
-// @Failure      400  {object}  string
+// @Success      201  {object}  presenter.TokenResponse
+// @Success      202  {object}  presenter.MessageResponse "awaiting approval or email verification"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
 // @Router       /auth/register [post]
 func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 		return
 	}
 	var register presenter.Register
-	err := json.NewDecoder(r.Body).Decode(&register)
+	err := pkg.DecodeJSON(r, &register)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	validate := validator.New()
 
 	if err := validate.Struct(register); err != nil {
-		pkg.HandleError(w, errors.New("Invalid request body. Username length must be >= 2, " +
-			"password length must be [8, 16]"), http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
 		return
 	}
 
-	jwt, err := h.services.Register(register)
+	tokens, err := h.services.Register(r.Context(), register, client(r))
 
+	if errors.Is(err, service.ErrRegistrationPending) || errors.Is(err, service.ErrEmailVerificationSent) {
+		pkg.WriteJSON(w, http.StatusAccepted, presenter.MessageResponse{Message: err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrRegistrationClosed) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	fmt.Fprintf(w, "jwt: %s", jwt)
+	pkg.WriteJSON(w, http.StatusCreated, tokens)
 }
 
 // Authenticate to account
@@ -59,36 +91,259 @@ func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 request body presenter.Login true "login"
-// @Success      200  {object}  string
-// @Failure      400  {object}  string
+// @Success      200  {object}  presenter.TokenResponse
+// @Success      202  {object}  presenter.TwoFactorChallenge "second factor required, continue via /auth/2fa"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
 // @Router       /auth/authenticate [post]
 func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 		return
 	}
 	var login presenter.Login
-	err := json.NewDecoder(r.Body).Decode(&login)
+	err := pkg.DecodeJSON(r, &login)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	validate := validator.New()
 
 	if err := validate.Struct(login); err != nil {
-		pkg.HandleError(w, errors.New("Invalid request body. Username length must be >= 2, " +
-			"password length must be [8, 16]"), http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.Login(r.Context(), login, client(r))
+
+	if writeThrottled(w, r, err) {
+		return
+	}
+	var twoFactor *service.TwoFactorRequiredError
+	if errors.As(err, &twoFactor) {
+		pkg.WriteJSON(w, http.StatusAccepted, twoFactor.Challenge)
+		return
+	}
+	var suspended *service.SuspendedError
+	if errors.As(err, &suspended) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrRegistrationPending) || errors.Is(err, service.ErrEmailNotVerified) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	jwt, err := h.services.Login(login)
+	pkg.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// Refresh access token
+// @Summary      Refresh access token
+// @Description  Exchange a refresh token for a new access and refresh token pair. The used refresh token is revoked
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.RefreshRequest true "refresh token"
+// @Success      200  {object}  presenter.TokenResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /auth/refresh [post]
+func (h *Handler) refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var request presenter.RefreshRequest
+	err := pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	fmt.Fprintf(w, "jwt: %s", jwt)
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.Refresh(r.Context(), request, client(r))
+
+	var suspended *service.SuspendedError
+	if errors.As(err, &suspended) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	pkg.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// Logout
+// @Summary      Logout
+// @Description  Revoke the session of the access token used for the request and the given refresh token
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.RefreshRequest false "refresh token"
+// @Success      200  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Router       /auth/logout [post]
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.RefreshRequest
+	if r.ContentLength != 0 {
+		if err := pkg.DecodeJSON(r, &request); err != nil {
+			pkg.HandleError(w, r, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.services.Logout(r.Context(), claims.Id, claims.SessionId, claims.ID, claims.ExpiresAt.Time, request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// Request password reset
+// @Summary      Request password reset
+// @Description  Mail a single-use password reset token. Responds the same whether the email is registered or not
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.ForgotPassword true "email"
+// @Success      202  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /auth/forgot-password [post]
+func (h *Handler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var request presenter.ForgotPassword
+	err := pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.ForgotPassword(r.Context(), request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Reset password
+// @Summary      Reset password
+// @Description  Set a new password with a token from the password reset email. All sessions are revoked
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.ResetPassword true "reset token and new password"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /auth/reset-password [post]
+func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var request presenter.ResetPassword
+	err := pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.ResetPassword(r.Context(), request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Verify email
+// @Summary      Verify email
+// @Description  Confirm email with a token from the verification email
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.VerifyEmail true "verification token"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /auth/verify-email [post]
+func (h *Handler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var request presenter.VerifyEmail
+	err := pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.VerifyEmail(r.Context(), request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
 }
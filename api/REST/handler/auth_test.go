@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/service"
 	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
 	"github.com/go-playground/assert/v2"
 	"github.com/golang/mock/gomock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_register(t *testing.T) {
@@ -32,10 +35,101 @@ func TestHandler_register(t *testing.T) {
 				Password: "password",
 			},
 			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
-				r.EXPECT().Register(user).Return("test_token", nil)
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{
+					AccessToken: "test_token", RefreshToken: "refresh_token", TokenType: "Bearer", ExpiresIn: 900}, nil)
 			},
 			expectedStatusCode:   201,
-			expectedResponseBody: "jwt: test_token",
+			expectedResponseBody: "{\"accessToken\":\"test_token\",\"refreshToken\":\"refresh_token\",\"tokenType\":\"Bearer\",\"expiresIn\":900}\n",
+		},
+		{
+			name:      "Ok with invite code",
+			inputBody: `{"username": "username", "password": "password", "inviteCode": "code"}`,
+			inputUser: presenter.Register{
+				Username:   "username",
+				Password:   "password",
+				InviteCode: "code",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{
+					AccessToken: "test_token", RefreshToken: "refresh_token", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: "{\"accessToken\":\"test_token\",\"refreshToken\":\"refresh_token\",\"tokenType\":\"Bearer\",\"expiresIn\":900}\n",
+		},
+		{
+			name:      "Invalid invite code",
+			inputBody: `{"username": "username", "password": "password", "inviteCode": "wrong"}`,
+			inputUser: presenter.Register{
+				Username:   "username",
+				Password:   "password",
+				InviteCode: "wrong",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, errors.New("Invalid invite code"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid invite code\",\"instance\":\"/api/auth/register\"}\n",
+		},
+		{
+			name:      "Username taken",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Register{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{},
+					&entity.ConflictError{Message: "User with such username already exists"})
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Conflict\",\"status\":409,\"detail\":\"User with such username already exists\",\"instance\":\"/api/auth/register\"}\n",
+		},
+		{
+			name:      "Registration closed",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Register{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrRegistrationClosed)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Registration is closed\",\"instance\":\"/api/auth/register\"}\n",
+		},
+		{
+			name:      "Registration awaiting approval",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Register{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrRegistrationPending)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: "{\"message\":\"Registration is awaiting approval\"}\n",
+		},
+		{
+			name:      "Verification email sent",
+			inputBody: `{"username": "username", "password": "password", "email": "user@example.com"}`,
+			inputUser: presenter.Register{
+				Username: "username",
+				Password: "password",
+				Email:    "user@example.com",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrEmailVerificationSent)
+			},
+			expectedStatusCode:   202,
+			expectedResponseBody: "{\"message\":\"Check your email to verify the account\"}\n",
+		},
+		{
+			name:                 "Invalid email",
+			inputBody:            `{"username": "username", "password": "password", "email": "not-an-email"}`,
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Register) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/register\",\"errors\":[{\"field\":\"email\",\"message\":\"must be a valid email\"}]}\n",
 		},
 		{
 			name:      "Min length username fail register",
@@ -44,32 +138,36 @@ func TestHandler_register(t *testing.T) {
 				Username: "1",
 				Password: "password",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {},
-			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid request body. Username length must be >= 2, password length must be [8, 16]\n",
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Register) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/register\",\"errors\":[{\"field\":\"username\",\"message\":\"must be at least 2 characters\"}]}\n",
 		},
 		{
-			name:      "Min length password fail register",
+			name:      "Password policy fail register",
 			inputBody: `{"username": "username", "password": "passwor"}`,
 			inputUser: presenter.Register{
-				Username: "1",
+				Username: "username",
 				Password: "passwor",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {},
-
-			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid request body. Username length must be >= 2, password length must be [8, 16]\n",
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {
+				r.EXPECT().Register(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, &service.PasswordPolicyError{
+					Violations: []string{"must be at least 8 characters long", "is known from data breaches"}})
+			},
+			expectedStatusCode: 422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422," +
+				"\"detail\":\"Password does not meet the policy: must be at least 8 characters long, is known from data breaches\"," +
+				"\"instance\":\"/api/auth/register\"}\n",
 		},
 		{
 			name:      "Max length password fail register",
-			inputBody: `{"username": "username", "password": "passwordpasswordpassword"}`,
+			inputBody: `{"username": "username", "password": "ppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppp"}`,
 			inputUser: presenter.Register{
-				Username: "1",
-				Password: "passwordpasswordpassword",
+				Username: "username",
+				Password: "ppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppp",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {},
-			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid request body. Username length must be >= 2, password length must be [8, 16]\n",
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Register) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/register\",\"errors\":[{\"field\":\"password\",\"message\":\"must be at most 128 characters\"}]}\n",
 		},
 	}
 	for _, test := range tests {
@@ -88,7 +186,7 @@ func TestHandler_register(t *testing.T) {
 			mux.Handle("/api/auth/register", http.HandlerFunc(handler.register))
 
 			w := httptest.NewRecorder()
-			req:= httptest.NewRequest("POST", "/api/auth/register",
+			req := httptest.NewRequest("POST", "/api/auth/register",
 				bytes.NewBufferString(test.inputBody))
 
 			mux.ServeHTTP(w, req)
@@ -117,9 +215,9 @@ func TestHandler_register_invalid_method(t *testing.T) {
 				Username: "username",
 				Password: "password",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Register) {},
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Register) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/auth/register\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -138,7 +236,7 @@ func TestHandler_register_invalid_method(t *testing.T) {
 			mux.Handle("/api/auth/register", http.HandlerFunc(handler.register))
 
 			w := httptest.NewRecorder()
-			req:= httptest.NewRequest("PUT", "/api/auth/register",
+			req := httptest.NewRequest("PUT", "/api/auth/register",
 				bytes.NewBufferString(test.inputBody))
 
 			mux.ServeHTTP(w, req)
@@ -168,10 +266,11 @@ func TestHandler_authenticate(t *testing.T) {
 				Password: "password",
 			},
 			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {
-				r.EXPECT().Login(user).Return("test_token", nil)
+				r.EXPECT().Login(gomock.Any(), user, presenter.Client{UserAgent: "test-agent", Ip: "192.0.2.1"}).Return(presenter.TokenResponse{
+					AccessToken: "test_token", RefreshToken: "refresh_token", TokenType: "Bearer", ExpiresIn: 900}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "jwt: test_token",
+			expectedResponseBody: "{\"accessToken\":\"test_token\",\"refreshToken\":\"refresh_token\",\"tokenType\":\"Bearer\",\"expiresIn\":900}\n",
 		},
 		{
 			name:      "Invalid credentials",
@@ -181,10 +280,67 @@ func TestHandler_authenticate(t *testing.T) {
 				Password: "password",
 			},
 			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {
-				r.EXPECT().Login(user).Return("", errors.New("Invalid login or password"))
+				r.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, errors.New("Invalid login or password"))
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid login or password\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid login or password\",\"instance\":\"/api/auth/authenticate\"}\n",
+		},
+		{
+			name:      "Awaiting approval",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Login{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {
+				r.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrRegistrationPending)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Registration is awaiting approval\",\"instance\":\"/api/auth/authenticate\"}\n",
+		},
+		{
+			name:      "Second factor required",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Login{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {
+				r.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{},
+					&service.TwoFactorRequiredError{Challenge: presenter.TwoFactorChallenge{
+						MfaToken: "challenge", EnrollmentRequired: true, ExpiresIn: 300}})
+			},
+			expectedStatusCode: 202,
+			expectedResponseBody: "{\"mfaToken\":\"challenge\",\"enrollmentRequired\":true," +
+				"\"expiresIn\":300}\n",
+		},
+		{
+			name:      "Email not verified",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Login{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {
+				r.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrEmailNotVerified)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Email is not verified\",\"instance\":\"/api/auth/authenticate\"}\n",
+		},
+		{
+			name:      "Suspended",
+			inputBody: `{"username": "username", "password": "password"}`,
+			inputUser: presenter.Login{
+				Username: "username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {
+				until := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+				r.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return(presenter.TokenResponse{},
+					&service.SuspendedError{Status: entity.UserStatusSuspended, Reason: "spam", Until: &until})
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Account is suspended until 2024-01-02T03:04:05Z: spam\",\"instance\":\"/api/auth/authenticate\"}\n",
 		},
 		{
 			name:      "Min length username fail login",
@@ -193,32 +349,32 @@ func TestHandler_authenticate(t *testing.T) {
 				Username: "1",
 				Password: "password",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {},
-			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid request body. Username length must be >= 2, password length must be [8, 16]\n",
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Login) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/authenticate\",\"errors\":[{\"field\":\"username\",\"message\":\"must be at least 2 characters\"}]}\n",
 		},
 		{
-			name:      "Min length password fail login",
-			inputBody: `{"username": "username", "password": "passwor"}`,
+			name:      "Empty password fail login",
+			inputBody: `{"username": "username", "password": ""}`,
 			inputUser: presenter.Login{
-				Username: "1",
-				Password: "passwor",
+				Username: "username",
+				Password: "",
 			},
 			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {},
 
-			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid request body. Username length must be >= 2, password length must be [8, 16]\n",
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/authenticate\",\"errors\":[{\"field\":\"password\",\"message\":\"is required\"}]}\n",
 		},
 		{
 			name:      "Max length password fail login",
-			inputBody: `{"username": "username", "password": "passwordpasswordpassword"}`,
+			inputBody: `{"username": "username", "password": "ppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppp"}`,
 			inputUser: presenter.Login{
-				Username: "1",
-				Password: "passwordpasswordpassword",
+				Username: "username",
+				Password: "ppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppp",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {},
-			expectedStatusCode:   400,
-			expectedResponseBody: "Invalid request body. Username length must be >= 2, password length must be [8, 16]\n",
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Login) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/authenticate\",\"errors\":[{\"field\":\"password\",\"message\":\"must be at most 128 characters\"}]}\n",
 		},
 	}
 	for _, test := range tests {
@@ -237,8 +393,9 @@ func TestHandler_authenticate(t *testing.T) {
 			mux.Handle("/api/auth/authenticate", http.HandlerFunc(handler.authenticate))
 
 			w := httptest.NewRecorder()
-			req:= httptest.NewRequest("POST", "/api/auth/authenticate",
+			req := httptest.NewRequest("POST", "/api/auth/authenticate",
 				bytes.NewBufferString(test.inputBody))
+			req.Header.Add("User-Agent", "test-agent")
 
 			mux.ServeHTTP(w, req)
 
@@ -266,9 +423,9 @@ func TestHandler_authenticate_invalid_method(t *testing.T) {
 				Username: "username",
 				Password: "password",
 			},
-			mockBehavior: func(r *mock_service.MockUser, user presenter.Login) {},
+			mockBehavior:         func(r *mock_service.MockUser, user presenter.Login) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/auth/authenticate\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -287,13 +444,419 @@ func TestHandler_authenticate_invalid_method(t *testing.T) {
 			mux.Handle("/api/auth/authenticate", http.HandlerFunc(handler.authenticate))
 
 			w := httptest.NewRecorder()
-			req:= httptest.NewRequest("PUT", "/api/auth/authenticate",
+			req := httptest.NewRequest("PUT", "/api/auth/authenticate",
+				bytes.NewBufferString(test.inputBody))
+
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_refresh(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.RefreshRequest)
+
+	tests := []struct {
+		name                 string
+		method               string
+		inputBody            string
+		inputRequest         presenter.RefreshRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			method:       "POST",
+			inputBody:    `{"refreshToken": "refresh_token"}`,
+			inputRequest: presenter.RefreshRequest{RefreshToken: "refresh_token"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.RefreshRequest) {
+				r.EXPECT().Refresh(gomock.Any(), request, gomock.Any()).Return(presenter.TokenResponse{
+					AccessToken: "new_token", RefreshToken: "new_refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"accessToken\":\"new_token\",\"refreshToken\":\"new_refresh\",\"tokenType\":\"Bearer\",\"expiresIn\":900}\n",
+		},
+		{
+			name:         "Revoked refresh token",
+			method:       "POST",
+			inputBody:    `{"refreshToken": "used_token"}`,
+			inputRequest: presenter.RefreshRequest{RefreshToken: "used_token"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.RefreshRequest) {
+				r.EXPECT().Refresh(gomock.Any(), request, gomock.Any()).Return(presenter.TokenResponse{}, errors.New("Invalid refresh token"))
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid refresh token\",\"instance\":\"/api/auth/refresh\"}\n",
+		},
+		{
+			name:                 "Missing refresh token",
+			method:               "POST",
+			inputBody:            `{}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.RefreshRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/refresh\",\"errors\":[{\"field\":\"refreshToken\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Method Not Allowed",
+			method:               "GET",
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.RefreshRequest) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/auth/refresh\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/refresh", http.HandlerFunc(handler.refresh))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/auth/refresh",
+				bytes.NewBufferString(test.inputBody))
+
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_logout(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.RefreshRequest)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		inputBody            string
+		inputRequest         presenter.RefreshRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok with refresh token",
+			headerName:   "Authorization",
+			headerValue:  "Bearer USER",
+			inputBody:    `{"refreshToken": "refresh_token"}`,
+			inputRequest: presenter.RefreshRequest{RefreshToken: "refresh_token"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.RefreshRequest) {
+				r.EXPECT().Logout(gomock.Any(), 2, 2, "mock", gomock.Any(), request).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:        "Ok without body",
+			headerName:  "Authorization",
+			headerValue: "Bearer USER",
+			mockBehavior: func(r *mock_service.MockUser, request presenter.RefreshRequest) {
+				r.EXPECT().Logout(gomock.Any(), 2, 2, "mock", gomock.Any(), request).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:                 "Unauthorized",
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.RefreshRequest) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/auth/logout\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/logout", pkg.MockJWTAuthUser(handler.logout))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/auth/logout",
 				bytes.NewBufferString(test.inputBody))
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_authenticate_throttled(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	login := presenter.Login{Username: "username", Password: "password"}
+
+	repo := mock_service.NewMockUser(c)
+	repo.EXPECT().Login(gomock.Any(), login, gomock.Any()).Return(presenter.TokenResponse{},
+		&service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+
+	services := &service.Service{User: repo}
+	handler := Handler{services}
+
+	mux := http.NewServeMux()
+
+	mux.Handle("/api/auth/authenticate", http.HandlerFunc(handler.authenticate))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/auth/authenticate",
+		bytes.NewBufferString(`{"username": "username", "password": "password"}`))
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, w.Code, 429)
+	assert.Equal(t, w.Header().Get("Retry-After"), "2")
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/problem+json")
+	assert.Equal(t, w.Body.String(), "{\"type\":\"about:blank\",\"title\":\"Too Many Requests\",\"status\":429,"+
+		"\"detail\":\"Too many failed login attempts, try again later\",\"instance\":\"/api/auth/authenticate\"}\n")
+}
+
+func TestHandler_authenticate_twoFactor(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
 
+	login := presenter.Login{Username: "username", Password: "password"}
+
+	repo := mock_service.NewMockUser(c)
+	repo.EXPECT().Login(gomock.Any(), login, gomock.Any()).Return(presenter.TokenResponse{},
+		&service.TwoFactorRequiredError{Challenge: presenter.TwoFactorChallenge{MfaToken: "challenge", ExpiresIn: 300}})
+
+	services := &service.Service{User: repo}
+	handler := Handler{services}
+
+	mux := http.NewServeMux()
+
+	mux.Handle("/api/auth/authenticate", http.HandlerFunc(handler.authenticate))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/auth/authenticate",
+		bytes.NewBufferString(`{"username": "username", "password": "password"}`))
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, w.Code, 202)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, w.Body.String(), "{\"mfaToken\":\"challenge\",\"enrollmentRequired\":false,\"expiresIn\":300}\n")
+}
+
+func TestHandler_forgotPassword(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.ForgotPassword)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		inputRequest         presenter.ForgotPassword
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			inputBody:    `{"email": "user@example.com"}`,
+			inputRequest: presenter.ForgotPassword{Email: "user@example.com"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.ForgotPassword) {
+				r.EXPECT().ForgotPassword(gomock.Any(), request).Return(nil)
+			},
+			expectedStatusCode: 202,
+		},
+		{
+			name:                 "Invalid email",
+			inputBody:            `{"email": "user"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.ForgotPassword) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/forgot-password\",\"errors\":[{\"field\":\"email\",\"message\":\"must be a valid email\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/forgot-password", http.HandlerFunc(handler.forgotPassword))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/auth/forgot-password",
+				bytes.NewBufferString(test.inputBody))
 			mux.ServeHTTP(w, req)
 
 			assert.Equal(t, w.Code, test.expectedStatusCode)
 			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestHandler_resetPassword(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.ResetPassword)
+
+	tests := []struct {
+		name                 string
+		method               string
+		inputBody            string
+		inputRequest         presenter.ResetPassword
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			method:       "POST",
+			inputBody:    `{"token": "reset", "newPassword": "password"}`,
+			inputRequest: presenter.ResetPassword{Token: "reset", NewPassword: "password"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.ResetPassword) {
+				r.EXPECT().ResetPassword(gomock.Any(), request).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:         "Used token",
+			method:       "POST",
+			inputBody:    `{"token": "reset", "newPassword": "password"}`,
+			inputRequest: presenter.ResetPassword{Token: "reset", NewPassword: "password"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.ResetPassword) {
+				r.EXPECT().ResetPassword(gomock.Any(), request).Return(errors.New("Invalid or expired token"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid or expired token\",\"instance\":\"/api/auth/reset-password\"}\n",
+		},
+		{
+			name:         "Short password",
+			method:       "POST",
+			inputBody:    `{"token": "reset", "newPassword": "pass"}`,
+			inputRequest: presenter.ResetPassword{Token: "reset", NewPassword: "pass"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.ResetPassword) {
+				r.EXPECT().ResetPassword(gomock.Any(), request).Return(&service.PasswordPolicyError{
+					Violations: []string{"must be at least 8 characters long"}})
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Password does not meet the policy: must be at least 8 characters long\",\"instance\":\"/api/auth/reset-password\"}\n",
+		},
+		{
+			name:                 "Missing token",
+			method:               "POST",
+			inputBody:            `{"newPassword": "password"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.ResetPassword) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/reset-password\",\"errors\":[{\"field\":\"token\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Method Not Allowed",
+			method:               "GET",
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.ResetPassword) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/auth/reset-password\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/reset-password", http.HandlerFunc(handler.resetPassword))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/auth/reset-password",
+				bytes.NewBufferString(test.inputBody))
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_verifyEmail(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.VerifyEmail)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		inputRequest         presenter.VerifyEmail
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			inputBody:    `{"token": "verify"}`,
+			inputRequest: presenter.VerifyEmail{Token: "verify"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.VerifyEmail) {
+				r.EXPECT().VerifyEmail(gomock.Any(), request).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:         "Expired token",
+			inputBody:    `{"token": "verify"}`,
+			inputRequest: presenter.VerifyEmail{Token: "verify"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.VerifyEmail) {
+				r.EXPECT().VerifyEmail(gomock.Any(), request).Return(errors.New("Invalid or expired token"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid or expired token\",\"instance\":\"/api/auth/verify-email\"}\n",
+		},
+		{
+			name:                 "Missing token",
+			inputBody:            `{}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.VerifyEmail) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/verify-email\",\"errors\":[{\"field\":\"token\",\"message\":\"is required\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/verify-email", http.HandlerFunc(handler.verifyEmail))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/auth/verify-email",
+				bytes.NewBufferString(test.inputBody))
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const maxBatchOperations = 100
+
+// batchMode tells if a batch is atomic and checks its size
+func batchMode(mode string, operations int) (bool, error) {
+	if mode != "" && mode != presenter.BatchAtomic && mode != presenter.BatchPartial {
+		return false, pkg.InvalidField("mode", "must be one of atomic partial")
+	}
+	if operations == 0 {
+		return false, pkg.InvalidField("operations", "must not be empty")
+	}
+	if operations > maxBatchOperations {
+		return false, pkg.InvalidField("operations", "must have at most "+strconv.Itoa(maxBatchOperations)+" items")
+	}
+	return mode != presenter.BatchPartial, nil
+}
+
+// validateBatchOperation checks the op of an operation and the id and version
+// of an update or delete
+func validateBatchOperation(op string, id, version int) error {
+	switch op {
+	case presenter.BatchCreate:
+		return nil
+	case presenter.BatchUpdate, presenter.BatchDelete:
+	default:
+		return pkg.InvalidField("op", "must be one of create update delete")
+	}
+	if id <= 0 {
+		return pkg.InvalidField("id", "must be at least 1")
+	}
+	if version < 0 {
+		return pkg.InvalidField("version", "must be at least 1")
+	}
+	if version == 0 && pkg.IfMatchRequired() {
+		return pkg.InvalidField("version", "is required")
+	}
+	return nil
+}
+
+// prefixFields names the fields of a validation error relative to the request
+func prefixFields(err error, prefix string) error {
+	var validation *entity.ValidationError
+	if !errors.As(err, &validation) {
+		return err
+	}
+	prefixed := *validation
+	prefixed.Fields = make([]entity.FieldError, len(validation.Fields))
+	for i, field := range validation.Fields {
+		prefixed.Fields[i] = entity.FieldError{Field: prefix + field.Field, Message: field.Message}
+	}
+	return &prefixed
+}
+
+// writeBatch runs the operations of a batch that passed validation, invalid
+// holds the validation error of each operation. An atomic batch is run only if
+// all operations are valid and is answered with the problem of the operation
+// that failed, if any. Otherwise the result of every operation is answered
+// with 207, invalid operations are not run
+func writeBatch(w http.ResponseWriter, r *http.Request, atomic bool, ops []string, invalid []error,
+	run func(valid []int) ([]entity.BatchResult, error)) {
+	var (
+		valid  []int
+		fields []entity.FieldError
+	)
+	for i, err := range invalid {
+		if err == nil {
+			valid = append(valid, i)
+			continue
+		}
+		var validation *entity.ValidationError
+		if errors.As(prefixFields(err, "operations["+strconv.Itoa(i)+"]."), &validation) {
+			fields = append(fields, validation.Fields...)
+		}
+	}
+
+	if atomic {
+		if len(fields) != 0 {
+			pkg.HandleError(w, r, &entity.ValidationError{Message: "Invalid request", Fields: fields}, http.StatusBadRequest)
+			return
+		}
+		results, err := run(valid)
+		if err != nil {
+			for i, result := range results {
+				if result.Err != nil {
+					err = operationError(i, result.Err)
+					break
+				}
+			}
+			pkg.HandleError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		response := presenter.BatchResponse{Results: make([]presenter.BatchResult, len(ops))}
+		for i, result := range results {
+			response.Results[i] = batchResult(ops[i], result)
+		}
+		pkg.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+
+	response := presenter.BatchResponse{Results: make([]presenter.BatchResult, len(ops))}
+	for i, err := range invalid {
+		if err != nil {
+			response.Results[i] = batchResult(ops[i], entity.BatchResult{Err: err})
+		}
+	}
+	if len(valid) != 0 {
+		results, err := run(valid)
+		if err != nil {
+			pkg.HandleError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		for j, result := range results {
+			response.Results[valid[j]] = batchResult(ops[valid[j]], result)
+		}
+	}
+	pkg.WriteJSON(w, http.StatusMultiStatus, response)
+}
+
+// operationError tells which operation of an atomic batch failed
+func operationError(i int, err error) error {
+	var validation *entity.ValidationError
+	if errors.As(err, &validation) {
+		prefixed := prefixFields(validation, "operations["+strconv.Itoa(i)+"].").(*entity.ValidationError)
+		prefixed.Message = fmt.Sprintf("Operation %d failed: %s", i, validation.Message)
+		return prefixed
+	}
+	return fmt.Errorf("Operation %d failed: %w", i, err)
+}
+
+// batchResult has the status an operation would have got as a single request
+func batchResult(op string, result entity.BatchResult) presenter.BatchResult {
+	response := presenter.BatchResult{Op: op, Id: result.Id, Version: result.Version}
+	if result.Err == nil {
+		switch op {
+		case presenter.BatchCreate:
+			response.Status = http.StatusCreated
+		case presenter.BatchUpdate:
+			response.Status = http.StatusOK
+		case presenter.BatchDelete:
+			response.Status = http.StatusNoContent
+		}
+		return response
+	}
+
+	// as pkg.HandleError, other than domain errors are internal and not detailed
+	response.Status = pkg.ErrorStatus(result.Err, http.StatusInternalServerError)
+	if response.Status == http.StatusInternalServerError {
+		log.Printf("Error in batch %s operation: %s", op, result.Err.Error())
+		return response
+	}
+	response.Detail = result.Err.Error()
+	var validation *entity.ValidationError
+	if errors.As(result.Err, &validation) {
+		response.Detail = validation.Message
+		response.Errors = validation.Fields
+	}
+	return response
+}
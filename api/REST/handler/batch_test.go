@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_batchFilms(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockFilm)
+
+	create := `{"op": "create", "film": {"name": "name", "description": "description", "releaseDate": "2021-10-12", "rating": 5}}`
+	update := `{"op": "update", "id": 2, "version": 3, "film": {"name": "name", "description": "description", "releaseDate": "2021-10-12", "rating": 5}}`
+	remove := `{"op": "delete", "id": 3}`
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Atomic",
+			inputBody: `{"operations": [` + create + `, ` + update + `]}`,
+			mockBehavior: func(s *mock_service.MockFilm) {
+				s.EXPECT().BatchFilms(gomock.Any(), true, gomock.Len(2)).Return([]entity.BatchResult{
+					{Id: 1}, {Id: 2, Version: 4},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"results\":[{\"op\":\"create\",\"id\":1,\"status\":201},{\"op\":\"update\",\"id\":2,\"version\":4,\"status\":200}]}\n",
+		},
+		{
+			name:                 "Delete without permission",
+			inputBody:            `{"operations": [` + create + `, ` + remove + `]}`,
+			mockBehavior:         func(s *mock_service.MockFilm) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/batch\"}\n",
+		},
+		{
+			name:                 "Atomic with invalid operations",
+			inputBody:            `{"mode": "atomic", "operations": [` + create + `, {"op": "update", "id": 2, "film": {"name": ""}}, {"op": "update", "version": 1}]}`,
+			mockBehavior:         func(s *mock_service.MockFilm) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/batch\",\"errors\":[{\"field\":\"operations[1].film.releaseDate\",\"message\":\"is required\"},{\"field\":\"operations[1].film.rating\",\"message\":\"is required\"},{\"field\":\"operations[2].id\",\"message\":\"must be at least 1\"}]}\n",
+		},
+		{
+			name:      "Atomic with failed operation",
+			inputBody: `{"operations": [` + create + `, ` + update + `]}`,
+			mockBehavior: func(s *mock_service.MockFilm) {
+				s.EXPECT().BatchFilms(gomock.Any(), true, gomock.Len(2)).Return([]entity.BatchResult{
+					{Id: 1}, {Id: 2, Err: &entity.PreconditionFailedError{Entity: "film"}},
+				}, &entity.PreconditionFailedError{Entity: "film"})
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Precondition Failed\",\"status\":412,\"detail\":\"Operation 1 failed: film was modified since it was read\",\"instance\":\"/api/film/batch\"}\n",
+		},
+		{
+			name:      "Partial",
+			inputBody: `{"mode": "partial", "operations": [` + create + `, {"op": "create", "film": {"name": "name", "description": "description", "releaseDate": "2021-10-12", "rating": 11}}, ` + update + `]}`,
+			mockBehavior: func(s *mock_service.MockFilm) {
+				s.EXPECT().BatchFilms(gomock.Any(), false, gomock.Len(2)).Return([]entity.BatchResult{
+					{Id: 1}, {Id: 2, Err: &entity.NotFoundError{Entity: "film"}},
+				}, nil)
+			},
+			expectedStatusCode:   207,
+			expectedResponseBody: "{\"results\":[{\"op\":\"create\",\"id\":1,\"status\":201},{\"op\":\"create\",\"status\":422,\"detail\":\"Invalid request\",\"errors\":[{\"field\":\"film.rating\",\"message\":\"must be at most 10\"}]},{\"op\":\"update\",\"id\":2,\"status\":404,\"detail\":\"film not found\"}]}\n",
+		},
+		{
+			name:      "Partial with internal error",
+			inputBody: `{"mode": "partial", "operations": [` + create + `, ` + update + `]}`,
+			mockBehavior: func(s *mock_service.MockFilm) {
+				s.EXPECT().BatchFilms(gomock.Any(), false, gomock.Len(2)).Return([]entity.BatchResult{
+					{Id: 1}, {Id: 2, Err: errors.New("pq: relation \"film\" does not exist")},
+				}, nil)
+			},
+			expectedStatusCode:   207,
+			expectedResponseBody: "{\"results\":[{\"op\":\"create\",\"id\":1,\"status\":201},{\"op\":\"update\",\"id\":2,\"status\":500}]}\n",
+		},
+		{
+			name:      "Atomic with internal error",
+			inputBody: `{"operations": [` + create + `]}`,
+			mockBehavior: func(s *mock_service.MockFilm) {
+				err := errors.New("pq: relation \"film\" does not exist")
+				s.EXPECT().BatchFilms(gomock.Any(), true, gomock.Len(1)).Return([]entity.BatchResult{{Err: err}}, err)
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Internal Server Error\",\"status\":500,\"instance\":\"/api/film/batch\"}\n",
+		},
+		{
+			name:                 "Unknown mode",
+			inputBody:            `{"mode": "all", "operations": [` + create + `]}`,
+			mockBehavior:         func(s *mock_service.MockFilm) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/batch\",\"errors\":[{\"field\":\"mode\",\"message\":\"must be one of atomic partial\"}]}\n",
+		},
+		{
+			name:                 "Without operations",
+			inputBody:            `{"operations": []}`,
+			mockBehavior:         func(s *mock_service.MockFilm) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/batch\",\"errors\":[{\"field\":\"operations\",\"message\":\"must not be empty\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			film := mock_service.NewMockFilm(c)
+			test.mockBehavior(film)
+
+			services := &service.Service{Film: film}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film/batch", pkg.MockJWTAuthAdmin(handler.batchFilms))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/film/batch", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_batchActors(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockActor)
+
+	name := "name"
+	sex := "male"
+	birthday, _ := time.Parse("2006-01-02", "2001-10-12")
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Partial",
+			inputBody: `{"mode": "partial", "operations": [{"op": "create", "actor": {"name": "name", "sex": "male", "birthday": "2001-10-12"}}, {"op": "update", "id": 2, "actor": {"name": "name"}}, {"op": "rename", "id": 3}]}`,
+			mockBehavior: func(s *mock_service.MockActor) {
+				s.EXPECT().BatchActors(gomock.Any(), false, []presenter.ActorBatchOperation{{Op: "create", Actor: presenter.ActorRequest{
+					Name: &name, Sex: &sex, Birthday: &birthday, FilmsId: &[]int{},
+				}}}).Return([]entity.BatchResult{{Id: 1}}, nil)
+			},
+			expectedStatusCode:   207,
+			expectedResponseBody: "{\"results\":[{\"op\":\"create\",\"id\":1,\"status\":201},{\"op\":\"update\",\"status\":422,\"detail\":\"Invalid request\",\"errors\":[{\"field\":\"actor.sex\",\"message\":\"is required\"},{\"field\":\"actor.birthday\",\"message\":\"is required\"}]},{\"op\":\"rename\",\"status\":422,\"detail\":\"Invalid request\",\"errors\":[{\"field\":\"op\",\"message\":\"must be one of create update delete\"}]}]}\n",
+		},
+		{
+			name:      "Atomic",
+			inputBody: `{"operations": [{"op": "update", "id": 2, "version": 1, "actor": {"name": "name", "sex": "male", "birthday": "2001-10-12"}}]}`,
+			mockBehavior: func(s *mock_service.MockActor) {
+				s.EXPECT().BatchActors(gomock.Any(), true, []presenter.ActorBatchOperation{{Op: "update", Id: 2, Version: 1, Actor: presenter.ActorRequest{
+					Name: &name, Sex: &sex, Birthday: &birthday,
+				}}}).Return([]entity.BatchResult{{Id: 2, Version: 2}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"results\":[{\"op\":\"update\",\"id\":2,\"version\":2,\"status\":200}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			actor := mock_service.NewMockActor(c)
+			test.mockBehavior(actor)
+
+			services := &service.Service{Actor: actor}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/actor/batch", pkg.MockJWTAuthAdmin(handler.batchActors))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/actor/batch", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
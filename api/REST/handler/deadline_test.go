@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_deadline(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockFilm)
+
+	// blocks like a slow query until the request is cancelled
+	slowGetFilms := func(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name                 string
+		method               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok",
+			method: "GET",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[]\n",
+		},
+		{
+			name:   "Read deadline",
+			method: "GET",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilms(gomock.Any(), "").DoAndReturn(slowGetFilms)
+			},
+			expectedStatusCode:   503,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Service Unavailable\",\"status\":503,\"detail\":\"Request timed out\",\"instance\":\"/api/film\"}",
+		},
+		{
+			name:   "Write deadline is longer",
+			method: "POST",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilms(gomock.Any(), "").DoAndReturn(
+					func(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error) {
+						deadline, _ := ctx.Deadline()
+						if time.Until(deadline) < time.Second {
+							return nil, context.DeadlineExceeded
+						}
+						return []presenter.FilmResponse{}, nil
+					})
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[]\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockFilm(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{Film: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film", pkg.MockJWTAuthUser(handler.getFilms))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/film", nil)
+			req.Header.Add("Authorization", "Bearer USER")
+			pkg.Deadline(pkg.DeadlineConfig{Read: 50 * time.Millisecond, Write: 5 * time.Second}, mux).ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"bytes"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_etag(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockFilm)
+
+	film := presenter.FilmResponse{
+		Id:          1,
+		Name:        "name",
+		Description: "description",
+		ReleaseDate: "2021-10-12",
+		Rating:      5,
+		ActorsId:    []int{1, 2},
+		Version:     3,
+	}
+	filmBody := "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n"
+
+	tests := []struct {
+		name                 string
+		method               string
+		header               string
+		headerValue          string
+		inputBody            string
+		requireIfMatch       bool
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:   "Get sends ETag",
+			method: "GET",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         "\"3\"",
+			expectedResponseBody: filmBody,
+		},
+		{
+			name:        "Get not modified",
+			method:      "GET",
+			header:      "If-None-Match",
+			headerValue: "\"2\", W/\"3\"",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode: 304,
+			expectedETag:       "\"3\"",
+		},
+		{
+			name:        "Get modified",
+			method:      "GET",
+			header:      "If-None-Match",
+			headerValue: "\"2\"",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         "\"3\"",
+			expectedResponseBody: filmBody,
+		},
+		{
+			name:        "Patch if match",
+			method:      "PATCH",
+			header:      "If-Match",
+			headerValue: "\"2\"",
+			inputBody:   `{"name": "name", "rating": 5}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().PatchFilm(gomock.Any(), 1, 2, gomock.Any()).Return(film, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         "\"3\"",
+			expectedResponseBody: filmBody,
+		},
+		{
+			name:        "Patch weak tag never matches",
+			method:      "PATCH",
+			header:      "If-Match",
+			headerValue: "W/\"2\"",
+			inputBody:   `{"name": "name", "rating": 5}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().PatchFilm(gomock.Any(), 1, -1, gomock.Any()).
+					Return(presenter.FilmResponse{}, &entity.PreconditionFailedError{Entity: "film"})
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Precondition Failed\",\"status\":412,\"detail\":\"film was modified since it was read\",\"instance\":\"/api/film/1\"}\n",
+		},
+		{
+			name:        "Delete if match any",
+			method:      "DELETE",
+			header:      "If-Match",
+			headerValue: "*",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().DeleteFilm(gomock.Any(), 1, 0)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:        "Delete modified",
+			method:      "DELETE",
+			header:      "If-Match",
+			headerValue: "\"2\"",
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().DeleteFilm(gomock.Any(), 1, 2).Return(&entity.PreconditionFailedError{Entity: "film"})
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Precondition Failed\",\"status\":412,\"detail\":\"film was modified since it was read\",\"instance\":\"/api/film/1\"}\n",
+		},
+		{
+			name:                 "If-Match required",
+			method:               "DELETE",
+			requireIfMatch:       true,
+			mockBehavior:         func(r *mock_service.MockFilm) {},
+			expectedStatusCode:   428,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Precondition Required\",\"status\":428,\"detail\":\"If-Match header is required\",\"instance\":\"/api/film/1\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockFilm(c)
+			test.mockBehavior(repo)
+
+			pkg.RequireIfMatch(test.requireIfMatch)
+			defer pkg.RequireIfMatch(false)
+
+			services := &service.Service{Film: repo}
+			handler := Handler{services}
+
+			handlers := map[string]http.HandlerFunc{
+				"GET":    handler.getFilm,
+				"PATCH":  handler.patchFilm,
+				"DELETE": handler.deleteFilm,
+			}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film/", pkg.MockJWTAuthAdmin(handlers[test.method]))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/film/1", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			if test.header != "" {
+				req.Header.Add(test.header, test.headerValue)
+			}
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), test.expectedETag)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
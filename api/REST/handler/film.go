@@ -3,12 +3,13 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/pkg"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"net/http"
+	"strconv"
 )
 
 var prefixFilm = "/api/film/"
@@ -19,28 +20,28 @@ func (h *Handler) film(w http.ResponseWriter, r *http.Request) {
 	case "GET":
 		h.getFilm(w, r)
 	case "PUT":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionFilmWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.putFilm(w, r)
 	case "PATCH":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionFilmWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.patchFilm(w, r)
 	case "DELETE":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionFilmDelete); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.deleteFilm(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 
 }
@@ -50,24 +51,37 @@ func (h *Handler) films(w http.ResponseWriter, r *http.Request) {
 	case "GET":
 		h.getFilms(w, r)
 	case "POST":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionFilmWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
-		h.createFilm(w, r)
+		h.idempotent(h.createFilm)(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 }
 
-
 func (h *Handler) filmSearch(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
 		h.searchFilms(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) filmBatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionFilmWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.idempotent(h.batchFilms)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 }
 
@@ -78,9 +92,12 @@ func (h *Handler) filmSearch(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id path int true "id"
+// @Param 		 If-None-Match header string false "ETags the client has"
 // @Success      200  {object}  presenter.FilmResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the film"
+// @Success      304
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /film/{id} [get]
 func (h *Handler) getFilm(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixFilm)
@@ -88,9 +105,12 @@ func (h *Handler) getFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	film, err := h.services.GetFilm(id)
+	film, err := h.services.GetFilm(r.Context(), id)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if pkg.NotModified(w, r, film.Version) {
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
@@ -105,13 +125,13 @@ func (h *Handler) getFilm(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  []presenter.FilmResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /film [get]
 func (h *Handler) getFilms(w http.ResponseWriter, r *http.Request) {
-	films, err := h.services.GetFilms(r.URL.Query().Get("sortBy"))
+	films, err := h.services.GetFilms(r.Context(), r.URL.Query().Get("sortBy"))
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusInternalServerError)
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
@@ -125,38 +145,49 @@ func (h *Handler) getFilms(w http.ResponseWriter, r *http.Request) {
 // @Tags         films
 // @Accept       json
 // @Produce      json
-// @Param 		 id   path 	int 	true "id"
+// @Param 		 Idempotency-Key header string false "key to retry the request with without creating a duplicate"
 // @Param 		 request body presenter.FilmRequest true "film"
-// @Success      200  {object}  presenter.FilmResponse
-// @Failure      401  {object}  string
-// @Failure      403 {object}  string
+// @Success      201  {object}  presenter.CreatedResponse
+// @Header       201  {string}  Location "/api/film/{id}"
+// @Header       201  {string}  Idempotent-Replayed "true for the stored response to an earlier request with the key"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
 // @Router       /film [post]
 func (h *Handler) createFilm(w http.ResponseWriter, r *http.Request) {
 	var request presenter.FilmRequest
-	err := json.NewDecoder(r.Body).Decode(&request)
+	err := pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	defaultFilm(&request)
+	if err := requireFilm(request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	validate := validator.New()
 
 	if err := validate.Struct(request); err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
 		return
 	}
 
 	var id int
-	id, err = h.services.CreateFilm(request)
+	id, err = h.services.CreateFilm(r.Context(), request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	fmt.Fprintf(w, "%d", id)
+	w.Header().Set("Location", "/api/film/"+strconv.Itoa(id))
+	pkg.WriteJSON(w, http.StatusCreated, presenter.CreatedResponse{Id: id})
 }
 
 // Put film by id only for ADMIN
@@ -166,10 +197,15 @@ func (h *Handler) createFilm(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.FilmRequest true "film"
 // @Success      200  {object}  presenter.FilmResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the film"
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /film/{id} [put]
 func (h *Handler) putFilm(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixFilm)
@@ -177,27 +213,40 @@ func (h *Handler) putFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
 	var request presenter.FilmRequest
-	err = json.NewDecoder(r.Body).Decode(&request)
+	err = pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	defaultFilm(&request)
+	if err := requireFilm(request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	validate := validator.New()
 
 	if err := validate.Struct(request); err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
 		return
 	}
 
-	actor, err := h.services.PutFilm(id, request)
+	actor, err := h.services.PutFilm(r.Context(), id, version, request)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("ETag", pkg.ETag(actor.Version))
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(actor)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
@@ -205,15 +254,22 @@ func (h *Handler) putFilm(w http.ResponseWriter, r *http.Request) {
 
 // Patch film by id only for ADMIN
 // @Summary      Patch film by id
-// @Description  Patch film by id
+// @Description  Patch film by id with the fields to change, a JSON Merge Patch or a JSON Patch
 // @Tags         films
-// @Accept       json
+// @Accept       json,application/merge-patch+json,application/json-patch+json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.FilmRequest true "film"
 // @Success      200  {object}  presenter.FilmResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the film"
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      415  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /film/{id} [patch]
 func (h *Handler) patchFilm(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixFilm)
@@ -221,38 +277,124 @@ func (h *Handler) patchFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
+	patchType, ok := acceptPatch(w, r)
+	if !ok {
+		return
+	}
+	if patchType != "application/json" {
+		h.patchFilmDocument(w, r, id, version, patchType)
+		return
+	}
+
 	var request presenter.FilmRequest
-	err = json.NewDecoder(r.Body).Decode(&request)
+	err = pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	if request.Name != nil && len(*request.Name) < 1 || len(*request.Name) > 150 {
-		pkg.HandleError(w, errors.New("name length must be in [1; 150]"), http.StatusBadRequest)
+	if request.Name != nil && (len(*request.Name) < 1 || len(*request.Name) > 150) {
+		pkg.HandleError(w, r, pkg.InvalidField("name", "length must be in [1; 150]"), http.StatusBadRequest)
 		return
 	}
 	if request.Description != nil && len(*request.Description) > 1000 {
-		pkg.HandleError(w, errors.New("description length must be in [0; 1000]"), http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidField("description", "length must be in [0; 1000]"), http.StatusBadRequest)
 		return
 	}
-	if request.Rating != nil && *request.Rating < 0 || *request.Rating > 10 {
-		pkg.HandleError(w, errors.New("rating must be in [0; 10]"), http.StatusBadRequest)
+	if request.Rating != nil && (*request.Rating < 0 || *request.Rating > 10) {
+		pkg.HandleError(w, r, pkg.InvalidField("rating", "must be in [0; 10]"), http.StatusBadRequest)
 		return
 	}
 
-	film, err := h.services.PatchFilm(id, request)
+	film, err := h.services.PatchFilm(r.Context(), id, version, request)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("ETag", pkg.ETag(film.Version))
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(film)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
 }
 
+// patchFilmDocument applies a merge patch or JSON patch to the film and puts
+// the result, validated like a PUT. Removing the description or the actors
+// clears them
+func (h *Handler) patchFilmDocument(w http.ResponseWriter, r *http.Request, id, version int, patchType string) {
+	current, err := h.services.GetFilm(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if version != 0 && version != current.Version {
+		pkg.HandleError(w, r, &entity.PreconditionFailedError{Entity: "film"}, http.StatusPreconditionFailed)
+		return
+	}
+	if current.ActorsId == nil {
+		current.ActorsId = []int{}
+	}
+
+	var request presenter.FilmRequest
+	if err := patchDocument(r, patchType, current, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	defaultFilm(&request)
+	if err := requireFilm(request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if err := validator.New().Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	// conditional on the version patched, so changes made meanwhile are not lost
+	film, err := h.services.PutFilm(r.Context(), id, current.Version, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("ETag", pkg.ETag(film.Version))
+	pkg.WriteJSON(w, http.StatusOK, film)
+}
+
+// defaultFilm fills the optional fields a create or PUT may leave out, such a
+// film has no description and no actors
+func defaultFilm(request *presenter.FilmRequest) {
+	if request.Description == nil {
+		request.Description = new(string)
+	}
+	if request.ActorsId == nil {
+		request.ActorsId = &[]int{}
+	}
+}
+
+// requireFilm checks that a request has every field a create or PUT sets
+func requireFilm(request presenter.FilmRequest) error {
+	var missing []string
+	if request.Name == nil {
+		missing = append(missing, "name")
+	}
+	if request.ReleaseDate == nil {
+		missing = append(missing, "releaseDate")
+	}
+	if request.Rating == nil {
+		missing = append(missing, "rating")
+	}
+	return pkg.RequiredFields(missing...)
+}
+
 // Delete film by id only for ADMIN
 // @Summary      Delete film by id
 // @Description  Delete film by id
@@ -260,9 +402,12 @@ func (h *Handler) patchFilm(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Success      200  {object}  string
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /film/{id} [delete]
 func (h *Handler) deleteFilm(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixFilm)
@@ -270,9 +415,15 @@ func (h *Handler) deleteFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.services.DeleteFilm(id)
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
+	err = h.services.DeleteFilm(r.Context(), id, version)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 }
@@ -285,22 +436,97 @@ func (h *Handler) deleteFilm(w http.ResponseWriter, r *http.Request) {
 // @Produce      json
 // @Param 		 field   query 	string 	true "field"
 // @Success      200  {object}  []presenter.FilmResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /film/search [get]
 func (h *Handler) searchFilms(w http.ResponseWriter, r *http.Request) {
 	var films []presenter.FilmResponse
 	var err error
 	if r.URL.Query().Get("name") != "" {
-		films, err = h.services.SearchFilmsBy("name", r.URL.Query().Get("name"))
+		films, err = h.services.SearchFilmsBy(r.Context(), "name", r.URL.Query().Get("name"))
 	} else if r.URL.Query().Get("actor") != "" {
-		films, err = h.services.SearchFilmsBy("actor", r.URL.Query().Get("actor"))
+		films, err = h.services.SearchFilmsBy(r.Context(), "actor", r.URL.Query().Get("actor"))
 	}
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusInternalServerError)
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(films)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
 }
+
+// Batch of film operations only for ADMIN
+// @Summary      Create, update and delete films
+// @Description  Apply up to 100 create, update and delete operations. An atomic batch, the default, applies all of them
+// @Description  or none and answers with the problem of the operation that failed. A partial batch applies each
+// @Description  operation on its own and answers with the status, id and errors of each
+// @Tags         films
+// @Accept       json
+// @Produce      json
+// @Param 		 Idempotency-Key header string false "key to retry the request with without applying it twice"
+// @Param 		 request body presenter.FilmBatchRequest true "operations"
+// @Success      200  {object}  presenter.BatchResponse
+// @Success      207  {object}  presenter.BatchResponse
+// @Header       200,207  {string}  Idempotent-Replayed "true for the stored response to an earlier request with the key"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      404  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /film/batch [post]
+func (h *Handler) batchFilms(w http.ResponseWriter, r *http.Request) {
+	var request presenter.FilmBatchRequest
+	if err := pkg.DecodeJSON(r, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	atomic, err := batchMode(request.Mode, len(request.Operations))
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]string, len(request.Operations))
+	invalid := make([]error, len(request.Operations))
+	for i, operation := range request.Operations {
+		ops[i] = operation.Op
+		if operation.Op == presenter.BatchDelete {
+			if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionFilmDelete); err != nil {
+				pkg.WriteProblem(w, r, http.StatusForbidden, "")
+				return
+			}
+		}
+		invalid[i] = validateFilmOperation(&request.Operations[i])
+	}
+
+	writeBatch(w, r, atomic, ops, invalid, func(valid []int) ([]entity.BatchResult, error) {
+		operations := make([]presenter.FilmBatchOperation, len(valid))
+		for j, i := range valid {
+			operations[j] = request.Operations[i]
+		}
+		return h.services.BatchFilms(r.Context(), atomic, operations)
+	})
+}
+
+// validateFilmOperation checks an operation like the request it stands for,
+// the film of an update is put
+func validateFilmOperation(operation *presenter.FilmBatchOperation) error {
+	if err := validateBatchOperation(operation.Op, operation.Id, operation.Version); err != nil {
+		return err
+	}
+	if operation.Op == presenter.BatchDelete {
+		return nil
+	}
+	defaultFilm(&operation.Film)
+	if err := requireFilm(operation.Film); err != nil {
+		return prefixFields(err, "film.")
+	}
+	if err := validator.New().Struct(operation.Film); err != nil {
+		return prefixFields(pkg.InvalidRequest(err), "film.")
+	}
+	return nil
+}
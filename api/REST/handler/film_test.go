@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/service"
 	mock_service "filmLibraryVk/internal/service/mocks"
 	"filmLibraryVk/pkg"
@@ -32,30 +33,30 @@ func TestHandler_getFilms(t *testing.T) {
 			headerName:  "Authorization",
 			headerValue: "Bearer USER",
 			mockBehavior: func(r *mock_service.MockFilm) {
-				r.EXPECT().GetFilms("").Return([]presenter.FilmResponse{
+				r.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{
 					{Id: 1, Name: "name", Description: "description",
 						ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}]\n",
+			expectedResponseBody: "[{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}]\n",
 		},
 		{
 			name:        "Ok admin",
 			headerName:  "Authorization",
 			headerValue: "Bearer ADMIN",
 			mockBehavior: func(r *mock_service.MockFilm) {
-				r.EXPECT().GetFilms("").Return([]presenter.FilmResponse{
+				r.EXPECT().GetFilms(gomock.Any(), "").Return([]presenter.FilmResponse{
 					{Id: 1, Name: "name", Description: "description",
 						ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}]\n",
+			expectedResponseBody: "[{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}]\n",
 		},
 		{
 			name:                 "Unauthorized",
 			mockBehavior:         func(r *mock_service.MockFilm) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -63,7 +64,7 @@ func TestHandler_getFilms(t *testing.T) {
 			headerValue:          "Bearer USE",
 			mockBehavior:         func(r *mock_service.MockFilm) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -111,12 +112,12 @@ func TestHandler_getFilm(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockFilm, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetFilm(idd).Return(presenter.FilmResponse{
+				r.EXPECT().GetFilm(gomock.Any(), idd).Return(presenter.FilmResponse{
 					Id: 1, Name: "name", Description: "description",
 					ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}\n",
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n",
 		},
 		{
 			name:                 "Invalid id",
@@ -125,7 +126,30 @@ func TestHandler_getFilm(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockFilm, id string) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/film/1s\"}\n",
+		},
+		{
+			name:        "Not found",
+			headerName:  "Authorization",
+			headerValue: "Bearer USER",
+			id:          "3",
+			mockBehavior: func(r *mock_service.MockFilm, id string) {
+				r.EXPECT().GetFilm(gomock.Any(), 3).Return(presenter.FilmResponse{}, &entity.NotFoundError{Entity: "film"})
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"film not found\",\"instance\":\"/api/film/3\"}\n",
+		},
+		{
+			name:        "Database unavailable",
+			headerName:  "Authorization",
+			headerValue: "Bearer USER",
+			id:          "1",
+			mockBehavior: func(r *mock_service.MockFilm, id string) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(presenter.FilmResponse{},
+					&entity.UnavailableError{Err: errors.New("dial tcp: connection refused")})
+			},
+			expectedStatusCode:   503,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Service Unavailable\",\"status\":503,\"detail\":\"Service is temporarily unavailable\",\"instance\":\"/api/film/1\"}\n",
 		},
 		{
 			name:        "Ok admin",
@@ -134,19 +158,19 @@ func TestHandler_getFilm(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockFilm, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetFilm(idd).Return(presenter.FilmResponse{
+				r.EXPECT().GetFilm(gomock.Any(), idd).Return(presenter.FilmResponse{
 					Id: 1, Name: "name", Description: "description",
 					ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}\n",
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/1\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -155,7 +179,7 @@ func TestHandler_getFilm(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -213,6 +237,7 @@ func TestHandler_postFilm(t *testing.T) {
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
+		expectedLocation     string
 	}{
 		{
 			name:        "Ok admin",
@@ -228,10 +253,111 @@ func TestHandler_postFilm(t *testing.T) {
 				ActorsId:    actorsId,
 			},
 			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest) {
-				r.EXPECT().CreateFilm(film).Return(1, nil)
+				r.EXPECT().CreateFilm(gomock.Any(), film).Return(1, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: "{\"id\":1}\n",
+			expectedLocation:     "/api/film/1",
+		},
+		{
+			name:        "Without description and actors",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"name": "name", "releaseDate": "2021-10-12", "rating": 5}`,
+			inputFilm: presenter.FilmRequest{
+				Name:        name,
+				Description: new(string),
+				ReleaseDate: releaseDate,
+				Rating:      rating,
+				ActorsId:    &[]int{},
+			},
+			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest) {
+				r.EXPECT().CreateFilm(gomock.Any(), film).Return(1, nil)
 			},
 			expectedStatusCode:   201,
-			expectedResponseBody: "1",
+			expectedResponseBody: "{\"id\":1}\n",
+			expectedLocation:     "/api/film/1",
+		},
+		{
+			name:        "Unknown actor",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody: `{"name": "name", "description": "description",
+						"releaseDate": "2021-10-12", "rating": 5, "actorsId": [1, 2]}`,
+			inputFilm: presenter.FilmRequest{
+				Name:        name,
+				Description: description,
+				ReleaseDate: releaseDate,
+				Rating:      rating,
+				ActorsId:    actorsId,
+			},
+			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest) {
+				r.EXPECT().CreateFilm(gomock.Any(), film).Return(0, &entity.ValidationError{
+					Message: "Actor with id 2 does not exist",
+					Fields:  []entity.FieldError{{Field: "actorsId", Message: "does not exist"}}})
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Actor with id 2 does not exist\",\"instance\":\"/api/film\",\"errors\":[{\"field\":\"actorsId\",\"message\":\"does not exist\"}]}\n",
+		},
+		{
+			name:        "Actor linked twice",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody: `{"name": "name", "description": "description",
+						"releaseDate": "2021-10-12", "rating": 5, "actorsId": [1, 2]}`,
+			inputFilm: presenter.FilmRequest{
+				Name:        name,
+				Description: description,
+				ReleaseDate: releaseDate,
+				Rating:      rating,
+				ActorsId:    actorsId,
+			},
+			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest) {
+				r.EXPECT().CreateFilm(gomock.Any(), film).Return(0, &entity.ConflictError{
+					Message:    "Actor is already linked to the film",
+					Constraint: "actor_film_actor_id_film_id_key"})
+			},
+			expectedStatusCode: 409,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Conflict\",\"status\":409," +
+				"\"detail\":\"Actor is already linked to the film\",\"instance\":\"/api/film\"," +
+				"\"constraint\":\"actor_film_actor_id_film_id_key\"}\n",
+		},
+		{
+			name:        "Unknown field",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody: `{"name": "name", "description": "description",
+						"releaseDate": "2021-10-12", "rating": 5, "actorIds": [1, 2]}`,
+			mockBehavior:       func(r *mock_service.MockFilm, film presenter.FilmRequest) {},
+			expectedStatusCode: 422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422," +
+				"\"detail\":\"Invalid request\",\"instance\":\"/api/film\"," +
+				"\"errors\":[{\"field\":\"actorIds\",\"message\":\"is not a known field\"}]}\n",
+		},
+		{
+			name:        "Without release date",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody: `{"name": "name", "description": "description",
+						"rating": 5, "actorsId": [1, 2]}`,
+			mockBehavior:       func(r *mock_service.MockFilm, film presenter.FilmRequest) {},
+			expectedStatusCode: 422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422," +
+				"\"detail\":\"Invalid request\",\"instance\":\"/api/film\"," +
+				"\"errors\":[{\"field\":\"releaseDate\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:        "Invalid release date",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody: `{"name": "name", "description": "description",
+						"releaseDate": "12.10.2021", "rating": "5", "actorsId": [1, 2]}`,
+			mockBehavior:       func(r *mock_service.MockFilm, film presenter.FilmRequest) {},
+			expectedStatusCode: 422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422," +
+				"\"detail\":\"Invalid request\",\"instance\":\"/api/film\"," +
+				"\"errors\":[{\"field\":\"releaseDate\",\"message\":\"must be a date like 2006-01-02\"}," +
+				"{\"field\":\"rating\",\"message\":\"must be an integer\"}]}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -239,13 +365,13 @@ func TestHandler_postFilm(t *testing.T) {
 			headerValue:          "Bearer USER",
 			mockBehavior:         func(r *mock_service.MockFilm, film presenter.FilmRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			mockBehavior:         func(r *mock_service.MockFilm, film presenter.FilmRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -271,6 +397,7 @@ func TestHandler_postFilm(t *testing.T) {
 
 			assert.Equal(t, w.Code, test.expectedStatusCode)
 			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+			assert.Equal(t, w.Header().Get("Location"), test.expectedLocation)
 		})
 	}
 }
@@ -322,7 +449,7 @@ func TestHandler_putFilm(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockFilm, id string, actor presenter.FilmRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PutFilm(idd, actor).Return(presenter.FilmResponse{
+				r.EXPECT().PutFilm(gomock.Any(), idd, 0, actor).Return(presenter.FilmResponse{
 					Id:          1,
 					Name:        "name",
 					Description: "description",
@@ -332,7 +459,7 @@ func TestHandler_putFilm(t *testing.T) {
 				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}\n",
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n",
 		},
 		{
 			name:                 "Invalid id",
@@ -341,7 +468,7 @@ func TestHandler_putFilm(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockFilm, id string, actor presenter.FilmRequest) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/film/1s\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -350,14 +477,14 @@ func TestHandler_putFilm(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string, actor presenter.FilmRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string, actor presenter.FilmRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -425,7 +552,7 @@ func TestHandler_patchFilm(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockFilm, id string, film presenter.FilmRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PatchFilm(idd, film).Return(presenter.FilmResponse{
+				r.EXPECT().PatchFilm(gomock.Any(), idd, 0, film).Return(presenter.FilmResponse{
 					Name:        "name",
 					Description: "description",
 					ReleaseDate: "2021-10-12",
@@ -434,7 +561,30 @@ func TestHandler_patchFilm(t *testing.T) {
 				}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "{\"id\":0,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}\n",
+			expectedResponseBody: "{\"id\":0,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n",
+		},
+		{
+			name:        "Ok without rating",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			id:          "1",
+			inputBody:   `{"name": "name", "releaseDate": "2021-10-12"}`,
+			inputFilm: presenter.FilmRequest{
+				Name:        name,
+				ReleaseDate: releaseDate,
+			},
+			mockBehavior: func(r *mock_service.MockFilm, id string, film presenter.FilmRequest) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().PatchFilm(gomock.Any(), idd, 0, film).Return(presenter.FilmResponse{
+					Name:        "name",
+					Description: "description",
+					ReleaseDate: "2021-10-12",
+					Rating:      5,
+					ActorsId:    []int{1, 2},
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":0,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n",
 		},
 		{
 			name:                 "Invalid id",
@@ -443,7 +593,7 @@ func TestHandler_patchFilm(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockFilm, id string, film presenter.FilmRequest) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/film/1s\"}\n",
 		},
 		{
 			name:        "Entity not found",
@@ -458,10 +608,10 @@ func TestHandler_patchFilm(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockFilm, id string, film presenter.FilmRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PatchFilm(idd, film).Return(presenter.FilmResponse{}, errors.New("entity not found"))
+				r.EXPECT().PatchFilm(gomock.Any(), idd, 0, film).Return(presenter.FilmResponse{}, errors.New("entity not found"))
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: "entity not found\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/film/-1\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -470,14 +620,14 @@ func TestHandler_patchFilm(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string, film presenter.FilmRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string, film presenter.FilmRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -526,7 +676,7 @@ func TestHandler_deleteFilm(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockFilm, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().DeleteFilm(idd)
+				r.EXPECT().DeleteFilm(gomock.Any(), idd, 0)
 			},
 			expectedStatusCode: 200,
 		},
@@ -537,7 +687,7 @@ func TestHandler_deleteFilm(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockFilm, id string) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/film/1s\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -546,14 +696,14 @@ func TestHandler_deleteFilm(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockFilm, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -627,7 +777,7 @@ func TestHandler_films_invalid_method(t *testing.T) {
 			},
 			mockBehavior:         func(r *mock_service.MockFilm, film presenter.FilmRequest) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/film\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -700,7 +850,7 @@ func TestHandler_filmMethods(t *testing.T) {
 			inputFilm:            presenter.FilmRequest{},
 			mockBehavior:         func(r *mock_service.MockFilm, film presenter.FilmRequest, id string) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/film/\"}\n",
 		},
 		{
 			name:        "GET",
@@ -710,12 +860,12 @@ func TestHandler_filmMethods(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetFilm(idd).Return(presenter.FilmResponse{
+				r.EXPECT().GetFilm(gomock.Any(), idd).Return(presenter.FilmResponse{
 					Id: 1, Name: "name", Description: "description",
 					ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}\n",
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}\n",
 		},
 		{
 			name:        "PUT",
@@ -732,9 +882,9 @@ func TestHandler_filmMethods(t *testing.T) {
 				Rating:      rating,
 				ActorsId:    actorsId,
 			},
-			mockBehavior: func(r *mock_service.MockFilm, actor presenter.FilmRequest, id string) {},
+			mockBehavior:         func(r *mock_service.MockFilm, actor presenter.FilmRequest, id string) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/1\"}\n",
 		},
 		{
 			name:        "PATCH",
@@ -748,20 +898,19 @@ func TestHandler_filmMethods(t *testing.T) {
 				Rating:      rating,
 				ReleaseDate: releaseDate,
 			},
-			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest, id string) {},
-			expectedStatusCode:  403,
-			expectedResponseBody: "Forbidden\n",
+			mockBehavior:         func(r *mock_service.MockFilm, film presenter.FilmRequest, id string) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/1\"}\n",
 		},
 		{
-			name:        "DELETE",
-			headerName:  "Authorization",
-			headerValue: "Bearer ADMIN",
-			method:      "DELETE",
-			id:          "1",
-			mockBehavior: func(r *mock_service.MockFilm, film presenter.FilmRequest, id string) {},
-			expectedStatusCode: 403,
-			expectedResponseBody: "Forbidden\n",
-
+			name:                 "DELETE",
+			headerName:           "Authorization",
+			headerValue:          "Bearer ADMIN",
+			method:               "DELETE",
+			id:                   "1",
+			mockBehavior:         func(r *mock_service.MockFilm, film presenter.FilmRequest, id string) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/film/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -811,12 +960,12 @@ func TestHandler_searchFilms(t *testing.T) {
 			field:       "name",
 			value:       "1",
 			mockBehavior: func(r *mock_service.MockFilm) {
-				r.EXPECT().SearchFilmsBy("name", "1").Return([]presenter.FilmResponse{
+				r.EXPECT().SearchFilmsBy(gomock.Any(), "name", "1").Return([]presenter.FilmResponse{
 					{Id: 1, Name: "1", Description: "description",
 						ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}]\n",
+			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}]\n",
 		},
 		{
 			name:        "Search by name for admin",
@@ -825,12 +974,12 @@ func TestHandler_searchFilms(t *testing.T) {
 			field:       "name",
 			value:       "1",
 			mockBehavior: func(r *mock_service.MockFilm) {
-				r.EXPECT().SearchFilmsBy("name", "1").Return([]presenter.FilmResponse{
+				r.EXPECT().SearchFilmsBy(gomock.Any(), "name", "1").Return([]presenter.FilmResponse{
 					{Id: 1, Name: "1", Description: "description",
 						ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}]\n",
+			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}]\n",
 		},
 		{
 			name:        "Search by actor for user",
@@ -839,12 +988,12 @@ func TestHandler_searchFilms(t *testing.T) {
 			field:       "actor",
 			value:       "actorName",
 			mockBehavior: func(r *mock_service.MockFilm) {
-				r.EXPECT().SearchFilmsBy("actor", "actorName").Return([]presenter.FilmResponse{
+				r.EXPECT().SearchFilmsBy(gomock.Any(), "actor", "actorName").Return([]presenter.FilmResponse{
 					{Id: 1, Name: "1", Description: "description",
 						ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}]\n",
+			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}]\n",
 		},
 		{
 			name:        "Search by actor for admin",
@@ -853,18 +1002,18 @@ func TestHandler_searchFilms(t *testing.T) {
 			field:       "actor",
 			value:       "actorName",
 			mockBehavior: func(r *mock_service.MockFilm) {
-				r.EXPECT().SearchFilmsBy("actor", "actorName").Return([]presenter.FilmResponse{
+				r.EXPECT().SearchFilmsBy(gomock.Any(), "actor", "actorName").Return([]presenter.FilmResponse{
 					{Id: 1, Name: "1", Description: "description",
 						ReleaseDate: "2021-10-12", Rating: 5, ActorsId: []int{1, 2}}}, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"ReleaseDate\":\"2021-10-12\",\"Rating\":5,\"actorsId\":[1,2]}]\n",
+			expectedResponseBody: "[{\"id\":1,\"name\":\"1\",\"description\":\"description\",\"releaseDate\":\"2021-10-12\",\"rating\":5,\"actorsId\":[1,2]}]\n",
 		},
 		{
 			name:                 "Unauthorized",
 			mockBehavior:         func(r *mock_service.MockFilm) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/search\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -872,7 +1021,7 @@ func TestHandler_searchFilms(t *testing.T) {
 			headerValue:          "Bearer USE",
 			mockBehavior:         func(r *mock_service.MockFilm) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/film/search\"}\n",
 		},
 	}
 	for _, test := range tests {
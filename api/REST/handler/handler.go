@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/service"
 	"filmLibraryVk/pkg"
 	"github.com/swaggo/http-swagger/v2"
@@ -20,18 +21,50 @@ func NewHandler(services *service.Service) *Handler {
 func (h *Handler) InitRoutes() http.Handler {
 	mux := initSwagger()
 
-	mux.Handle("/api/actor", pkg.JWTAuthUser(h.actors))
-	mux.Handle("/api/actor/", pkg.JWTAuthUser(h.actor))
+	mux.Handle("/api/actor", pkg.AnonymousReadPermission(entity.PermissionActorRead, h.actors))
+	mux.Handle("/api/actor/", pkg.AnonymousReadPermission(entity.PermissionActorRead, h.actor))
+	mux.Handle("/api/actor/batch", pkg.AnonymousReadPermission(entity.PermissionActorRead, h.actorBatch))
 
-	mux.Handle("/api/film", pkg.JWTAuthUser(h.films))
-	mux.Handle("/api/film/", pkg.JWTAuthUser(h.film))
-	mux.Handle("/api/film/search", pkg.JWTAuthUser(h.filmSearch))
+	mux.Handle("/api/film", pkg.AnonymousReadPermission(entity.PermissionFilmRead, h.films))
+	mux.Handle("/api/film/", pkg.AnonymousReadPermission(entity.PermissionFilmRead, h.film))
+	mux.Handle("/api/film/batch", pkg.AnonymousReadPermission(entity.PermissionFilmRead, h.filmBatch))
+	mux.Handle("/api/film/search", pkg.AnonymousReadPermission(entity.PermissionFilmRead, h.filmSearch))
+
+	mux.Handle("/.well-known/jwks.json", http.HandlerFunc(h.jwks))
 
 	mux.Handle("/api/auth/register", http.HandlerFunc(h.register))
 	mux.Handle("/api/auth/authenticate", http.HandlerFunc(h.authenticate))
+	mux.Handle("/api/auth/refresh", http.HandlerFunc(h.refresh))
+	mux.Handle("/api/auth/logout", pkg.JWTAuthUser(h.logout))
+	mux.Handle("/api/auth/forgot-password", http.HandlerFunc(h.forgotPassword))
+	mux.Handle("/api/auth/reset-password", http.HandlerFunc(h.resetPassword))
+	mux.Handle("/api/auth/verify-email", http.HandlerFunc(h.verifyEmail))
+	mux.Handle("/api/auth/2fa", http.HandlerFunc(h.authTwoFactor))
+	mux.Handle("/api/auth/2fa/enroll", http.HandlerFunc(h.authTwoFactorEnroll))
+	mux.Handle("/api/auth/oidc/login", http.HandlerFunc(h.oidcLogin))
+	mux.Handle("/api/auth/oidc/callback", http.HandlerFunc(h.oidcCallback))
+
+	mux.Handle("/api/user", pkg.JWTAuthPermission(entity.PermissionUserRead, h.users))
+	mux.Handle("/api/user/", pkg.JWTAuthPermission(entity.PermissionUserRead, h.user))
+
+	mux.Handle("/api/invite", pkg.JWTAuthPermission(entity.PermissionUserRead, h.invites))
+	mux.Handle("/api/invite/", pkg.JWTAuthPermission(entity.PermissionUserRead, h.invite))
+	mux.Handle("/api/api-key", pkg.JWTAuthPermission(entity.PermissionApiKeyRead, h.apiKeys))
+	mux.Handle("/api/api-key/", pkg.JWTAuthPermission(entity.PermissionApiKeyRead, h.apiKey))
+
+	mux.Handle("/api/registration", pkg.JWTAuthPermission(entity.PermissionUserRead, h.registrations))
+	mux.Handle("/api/registration/", pkg.JWTAuthPermission(entity.PermissionUserRead, h.registration))
+
+	mux.Handle("/api/me", pkg.JWTAuthUser(h.me))
+	mux.Handle("/api/me/password", pkg.JWTAuthUser(h.mePassword))
+	mux.Handle("/api/me/sessions", pkg.JWTAuthUser(h.meSessions))
+	mux.Handle("/api/me/sessions/", pkg.JWTAuthUser(h.meSession))
+	mux.Handle("/api/me/2fa", pkg.JWTAuthUser(h.meTwoFactor))
+	mux.Handle("/api/me/2fa/confirm", pkg.JWTAuthUser(pkg.DenyImpersonation(h.meTwoFactorConfirm)))
+	mux.Handle("/api/me/2fa/recovery-codes", pkg.JWTAuthUser(pkg.DenyImpersonation(h.meRecoveryCodes)))
 
-	mux.Handle("/api/user", pkg.JWTAuthUser(h.users))
-	mux.Handle("/api/user/", pkg.JWTAuthUser(h.user))
+	mux.Handle("/api/role", pkg.JWTAuthPermission(entity.PermissionRoleRead, h.roles))
+	mux.Handle("/api/role/", pkg.JWTAuthPermission(entity.PermissionRoleRead, h.role))
 
 	//c := cors.New(cors.Options{
 	//	AllowedOrigins:   []string{"*"},
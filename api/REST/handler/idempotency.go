@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// idempotent runs next once per Idempotency-Key of the caller. Retries with
+// the same key and body get the stored response, marked Idempotent-Replayed,
+// instead of creating the entity again. Responses with a server error are not
+// stored, so the request can be retried
+func (h *Handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := pkg.IdempotencyKey(r)
+		if err != nil {
+			pkg.HandleError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		if key == "" {
+			next(w, r)
+			return
+		}
+		owner, err := idempotencyOwner(r)
+		if err != nil {
+			pkg.HandleError(w, r, err, http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			pkg.HandleError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := h.services.BeginIdempotent(r.Context(), owner, key, requestFingerprint(r, body))
+		if err != nil {
+			pkg.HandleError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		if stored != nil {
+			replay(w, *stored)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		// the request may have timed out, the key is settled anyway
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status >= http.StatusInternalServerError {
+			if err := h.services.ReleaseIdempotent(ctx, owner, key); err != nil {
+				log.Printf("Can not release idempotency key: %s", err.Error())
+			}
+			return
+		}
+		err = h.services.CompleteIdempotent(ctx, owner, key, entity.IdempotentRequest{
+			Status:      recorder.status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Location:    recorder.Header().Get("Location"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("Can not store idempotent response: %s", err.Error())
+		}
+	}
+}
+
+// idempotencyOwner scopes keys to the user or api key making the request
+func idempotencyOwner(r *http.Request) (string, error) {
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		return "", err
+	}
+	if claims.ApiKeyId != 0 {
+		return "api-key:" + strconv.Itoa(claims.ApiKeyId), nil
+	}
+	return "user:" + strconv.Itoa(claims.Id), nil
+}
+
+// requestFingerprint tells apart different requests sent with the same key
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, response entity.IdempotentRequest) {
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+	if response.Location != "" {
+		w.Header().Set("Location", response.Location)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+// responseRecorder passes a response through while keeping its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
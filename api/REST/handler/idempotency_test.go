@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_idempotent(t *testing.T) {
+	type mockBehavior func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency)
+
+	body := `{"name": "name", "description": "description", "releaseDate": "2021-10-12", "rating": 5, "actorsId": [1, 2]}`
+	created := "{\"id\":1}\n"
+
+	tests := []struct {
+		name                 string
+		key                  string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedLocation     string
+		expectedReplayed     string
+	}{
+		{
+			name: "Without key",
+			mockBehavior: func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {
+				film.EXPECT().CreateFilm(gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: created,
+			expectedLocation:     "/api/film/1",
+		},
+		{
+			name: "First request",
+			key:  "key",
+			mockBehavior: func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {
+				idempotency.EXPECT().BeginIdempotent(gomock.Any(), "user:1", "key", gomock.Any()).Return(nil, nil)
+				film.EXPECT().CreateFilm(gomock.Any(), gomock.Any()).Return(1, nil)
+				idempotency.EXPECT().CompleteIdempotent(gomock.Any(), "user:1", "key", entity.IdempotentRequest{
+					Status:      201,
+					ContentType: "application/json",
+					Location:    "/api/film/1",
+					Body:        []byte(created),
+				})
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: created,
+			expectedLocation:     "/api/film/1",
+		},
+		{
+			name: "Retry",
+			key:  "key",
+			mockBehavior: func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {
+				idempotency.EXPECT().BeginIdempotent(gomock.Any(), "user:1", "key", gomock.Any()).Return(&entity.IdempotentRequest{
+					Status:      201,
+					ContentType: "application/json",
+					Location:    "/api/film/1",
+					Body:        []byte(created),
+				}, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: created,
+			expectedLocation:     "/api/film/1",
+			expectedReplayed:     "true",
+		},
+		{
+			name: "Key reused with another body",
+			key:  "key",
+			mockBehavior: func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {
+				idempotency.EXPECT().BeginIdempotent(gomock.Any(), "user:1", "key", gomock.Any()).
+					Return(nil, &entity.ValidationError{Message: "Idempotency-Key was already used for a different request"})
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Idempotency-Key was already used for a different request\",\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name: "Still processing",
+			key:  "key",
+			mockBehavior: func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {
+				idempotency.EXPECT().BeginIdempotent(gomock.Any(), "user:1", "key", gomock.Any()).
+					Return(nil, &entity.ConflictError{Message: "A request with this Idempotency-Key is still being processed"})
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Conflict\",\"status\":409,\"detail\":\"A request with this Idempotency-Key is still being processed\",\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name: "Server error releases key",
+			key:  "key",
+			mockBehavior: func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {
+				idempotency.EXPECT().BeginIdempotent(gomock.Any(), "user:1", "key", gomock.Any()).Return(nil, nil)
+				film.EXPECT().CreateFilm(gomock.Any(), gomock.Any()).
+					Return(0, &entity.UnavailableError{Err: errors.New("connection refused")})
+				idempotency.EXPECT().ReleaseIdempotent(gomock.Any(), "user:1", "key")
+			},
+			expectedStatusCode:   503,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Service Unavailable\",\"status\":503,\"detail\":\"Service is temporarily unavailable\",\"instance\":\"/api/film\"}\n",
+		},
+		{
+			name:                 "Invalid key",
+			key:                  strings.Repeat("k", 256),
+			mockBehavior:         func(film *mock_service.MockFilm, idempotency *mock_service.MockIdempotency) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid Idempotency-Key\",\"instance\":\"/api/film\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			film := mock_service.NewMockFilm(c)
+			idempotency := mock_service.NewMockIdempotency(c)
+			test.mockBehavior(film, idempotency)
+
+			services := &service.Service{Film: film, Idempotency: idempotency}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film", pkg.MockJWTAuthAdmin(handler.idempotent(handler.createFilm)))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/film", bytes.NewBufferString(body))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			if test.key != "" {
+				req.Header.Add("Idempotency-Key", test.key)
+			}
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+			assert.Equal(t, w.Header().Get("Location"), test.expectedLocation)
+			assert.Equal(t, w.Header().Get("Idempotent-Replayed"), test.expectedReplayed)
+		})
+	}
+}
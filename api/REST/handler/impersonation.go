@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (h *Handler) userImpersonate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserImpersonate); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		pkg.DenyImpersonation(h.impersonateUser)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Impersonate user only with user:impersonate permission
+// @Summary      Impersonate user
+// @Description  Get a short-lived access token acting as the user, for support. Responses to requests made
+// @Description  with it carry the X-Impersonated-By header and every request is logged. Changing credentials,
+// @Description  sessions, two factor authentication and api keys is not allowed with it
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Param 		 request body presenter.ImpersonationRequest true "reason"
+// @Success      201  {object}  presenter.ImpersonationResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /user/{id}/impersonate [post]
+func (h *Handler) impersonateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefixUser), "/impersonate"))
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+	if claims.Id == 0 {
+		pkg.HandleError(w, r, errors.New("Impersonation requires a user token"), http.StatusForbidden)
+		return
+	}
+
+	var request presenter.ImpersonationRequest
+	if err := pkg.DecodeJSON(r, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	impersonation, err := h.services.Impersonate(r.Context(), claims.Id, claims.SessionId, claims.Permissions, id, request)
+	var suspended *service.SuspendedError
+	if errors.As(err, &suspended) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrImpersonationForbidden) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	pkg.WriteJSON(w, http.StatusCreated, impersonation)
+}
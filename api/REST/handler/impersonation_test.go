@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_impersonateUser(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockImpersonation, request presenter.ImpersonationRequest)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		path                 string
+		inputBody            string
+		inputRequest         presenter.ImpersonationRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok admin",
+			headerValue:  "Bearer ADMIN",
+			path:         "/api/user/5/impersonate",
+			inputBody:    `{"reason": "ticket 4711"}`,
+			inputRequest: presenter.ImpersonationRequest{Reason: "ticket 4711"},
+			mockBehavior: func(r *mock_service.MockImpersonation, request presenter.ImpersonationRequest) {
+				r.EXPECT().Impersonate(gomock.Any(), 1, 1, []string(nil), 5, request).Return(presenter.ImpersonationResponse{
+					Id: 3, AccessToken: "token", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: "{\"id\":3,\"accessToken\":\"token\",\"tokenType\":\"Bearer\",\"expiresIn\":900}\n",
+		},
+		{
+			name:         "Subject has more permissions",
+			headerValue:  "Bearer ADMIN",
+			path:         "/api/user/6/impersonate",
+			inputBody:    `{"reason": "ticket 4712"}`,
+			inputRequest: presenter.ImpersonationRequest{Reason: "ticket 4712"},
+			mockBehavior: func(r *mock_service.MockImpersonation, request presenter.ImpersonationRequest) {
+				r.EXPECT().Impersonate(gomock.Any(), 1, 1, []string(nil), 6, request).Return(presenter.ImpersonationResponse{},
+					service.ErrImpersonationForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Can not impersonate a user with permissions you do not have\",\"instance\":\"/api/user/6/impersonate\"}\n",
+		},
+		{
+			name:         "Not found",
+			headerValue:  "Bearer ADMIN",
+			path:         "/api/user/9/impersonate",
+			inputBody:    `{"reason": "ticket 4713"}`,
+			inputRequest: presenter.ImpersonationRequest{Reason: "ticket 4713"},
+			mockBehavior: func(r *mock_service.MockImpersonation, request presenter.ImpersonationRequest) {
+				r.EXPECT().Impersonate(gomock.Any(), 1, 1, []string(nil), 9, request).Return(presenter.ImpersonationResponse{},
+					errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/user/9/impersonate\"}\n",
+		},
+		{
+			name:                 "No reason",
+			headerValue:          "Bearer ADMIN",
+			path:                 "/api/user/5/impersonate",
+			inputBody:            `{}`,
+			mockBehavior:         func(r *mock_service.MockImpersonation, request presenter.ImpersonationRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/user/5/impersonate\",\"errors\":[{\"field\":\"reason\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			path:                 "/api/user/5/impersonate",
+			inputBody:            `{"reason": "ticket 4711"}`,
+			mockBehavior:         func(r *mock_service.MockImpersonation, request presenter.ImpersonationRequest) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/5/impersonate\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockImpersonation(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{Impersonation: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserImpersonate,
+				pkg.DenyImpersonation(handler.impersonateUser)))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_impersonatedRequests(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		method               string
+		path                 string
+		headerValue          string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedHeader       string
+		expectedResponseBody string
+	}{
+		{
+			name:        "Profile is visible",
+			method:      "GET",
+			path:        "/api/me",
+			headerValue: "Bearer IMPERSONATED",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUserById(gomock.Any(), 2).Return(presenter.UserResponse{
+					Id: 2, Username: "user", Role: "USER"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedHeader:       "1",
+			expectedResponseBody: "{\"id\":2,\"username\":\"user\",\"role\":\"USER\"}\n",
+		},
+		{
+			name:                 "Password change is denied",
+			method:               "PUT",
+			path:                 "/api/me/password",
+			headerValue:          "Bearer IMPERSONATED",
+			inputBody:            `{"currentPassword": "user", "newPassword": "newPassword1"}`,
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedHeader:       "1",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Not allowed while impersonating a user\",\"instance\":\"/api/me/password\"}\n",
+		},
+		{
+			name:                 "Account deletion is denied",
+			method:               "DELETE",
+			path:                 "/api/me",
+			headerValue:          "Bearer IMPERSONATED",
+			inputBody:            `{"password": "user"}`,
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedHeader:       "1",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Not allowed while impersonating a user\",\"instance\":\"/api/me\"}\n",
+		},
+		{
+			name:                 "Two factor enrollment is denied",
+			method:               "POST",
+			path:                 "/api/me/2fa",
+			headerValue:          "Bearer IMPERSONATED",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedHeader:       "1",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Not allowed while impersonating a user\",\"instance\":\"/api/me/2fa\"}\n",
+		},
+		{
+			name:        "Own token is not marked",
+			method:      "GET",
+			path:        "/api/me",
+			headerValue: "Bearer USER",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUserById(gomock.Any(), 2).Return(presenter.UserResponse{
+					Id: 2, Username: "user", Role: "USER"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":2,\"username\":\"user\",\"role\":\"USER\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me", pkg.MockJWTAuthUser(handler.me))
+			mux.Handle("/api/me/password", pkg.MockJWTAuthUser(handler.mePassword))
+			mux.Handle("/api/me/2fa", pkg.MockJWTAuthUser(handler.meTwoFactor))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get(pkg.ImpersonationHeader), test.expectedHeader)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
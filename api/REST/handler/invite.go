@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+)
+
+var prefixInvite = "/api/invite/"
+
+func (h *Handler) invites(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getInvites(w, r)
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.createInvite(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) invite(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "DELETE":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.deleteInvite(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Get invites
+// @Summary      Get invites
+// @Description  Get registration invites. Invite codes are only shown on creation
+// @Tags         invites
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  []presenter.InviteResponse
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /invite [get]
+func (h *Handler) getInvites(w http.ResponseWriter, r *http.Request) {
+	invites, err := h.services.GetInvites(r.Context())
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(invites)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Create invite only with user:write permission
+// @Summary      Create invite
+// @Description  Create a single-use registration invite. Default lifetime is taken from config
+// @Tags         invites
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.InviteRequest false "invite"
+// @Success      201  {object}  presenter.InviteResponse
+// @Header       201  {string}  Location "/api/invite/{id}"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /invite [post]
+func (h *Handler) createInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.InviteRequest
+	if r.ContentLength != 0 {
+		if err := pkg.DecodeJSON(r, &request); err != nil {
+			pkg.HandleError(w, r, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	invite, err := h.services.CreateInvite(r.Context(), id, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Location", "/api/invite/"+strconv.Itoa(invite.Id))
+	pkg.WriteJSON(w, http.StatusCreated, invite)
+}
+
+// Delete invite by id only with user:write permission
+// @Summary      Delete invite by id
+// @Description  Delete invite by id
+// @Tags         invites
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /invite/{id} [delete]
+func (h *Handler) deleteInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixInvite)
+	if err != nil {
+		return
+	}
+
+	err = h.services.DeleteInvite(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
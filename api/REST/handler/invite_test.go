@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getInvites(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockInvite)
+
+	createdBy, usedBy := 1, 3
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockInvite) {
+				r.EXPECT().GetInvites(gomock.Any()).Return([]presenter.InviteResponse{{
+					Id: 1, CreatedBy: &createdBy, CreatedAt: created, ExpiresAt: expires, UsedBy: &usedBy, UsedAt: &created}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "[{\"id\":1,\"createdBy\":1,\"createdAt\":\"2024-03-01T12:00:00Z\"," +
+				"\"expiresAt\":\"2024-03-08T12:00:00Z\",\"usedBy\":3,\"usedAt\":\"2024-03-01T12:00:00Z\"}]\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockInvite) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/invite\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockInvite(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{Invite: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/invite", pkg.MockJWTAuthPermission(entity.PermissionUserRead, handler.invites))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/invite", nil)
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_createInvite(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockInvite, request presenter.InviteRequest)
+
+	createdBy := 1
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		inputBody            string
+		inputRequest         presenter.InviteRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok admin",
+			headerValue:  "Bearer ADMIN",
+			inputBody:    `{"expiresIn": 86400}`,
+			inputRequest: presenter.InviteRequest{ExpiresIn: 86400},
+			mockBehavior: func(r *mock_service.MockInvite, request presenter.InviteRequest) {
+				r.EXPECT().CreateInvite(gomock.Any(), 1, request).Return(presenter.InviteResponse{
+					Id: 4, Code: "secret", CreatedBy: &createdBy, CreatedAt: created, ExpiresAt: expires}, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResponseBody: "{\"id\":4,\"code\":\"secret\",\"createdBy\":1,\"createdAt\":\"2024-03-01T12:00:00Z\"," +
+				"\"expiresAt\":\"2024-03-02T12:00:00Z\",\"usedBy\":null,\"usedAt\":null}\n",
+		},
+		{
+			name:        "Ok without body",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockInvite, request presenter.InviteRequest) {
+				r.EXPECT().CreateInvite(gomock.Any(), 1, request).Return(presenter.InviteResponse{
+					Id: 5, Code: "secret", CreatedBy: &createdBy, CreatedAt: created, ExpiresAt: expires}, nil)
+			},
+			expectedStatusCode: 201,
+			expectedResponseBody: "{\"id\":5,\"code\":\"secret\",\"createdBy\":1,\"createdAt\":\"2024-03-01T12:00:00Z\"," +
+				"\"expiresAt\":\"2024-03-02T12:00:00Z\",\"usedBy\":null,\"usedAt\":null}\n",
+		},
+		{
+			name:                 "Negative lifetime",
+			headerValue:          "Bearer ADMIN",
+			inputBody:            `{"expiresIn": -1}`,
+			mockBehavior:         func(r *mock_service.MockInvite, request presenter.InviteRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/invite\",\"errors\":[{\"field\":\"expiresIn\",\"message\":\"must be at least 0\"}]}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockInvite, request presenter.InviteRequest) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/invite\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockInvite(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{Invite: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/invite", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.createInvite))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/invite",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_deleteInvite(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockInvite)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok admin",
+			path: "/api/invite/4",
+			mockBehavior: func(r *mock_service.MockInvite) {
+				r.EXPECT().DeleteInvite(gomock.Any(), 4).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name: "Not found",
+			path: "/api/invite/9",
+			mockBehavior: func(r *mock_service.MockInvite) {
+				r.EXPECT().DeleteInvite(gomock.Any(), 9).Return(errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/invite/9\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockInvite(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{Invite: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/invite/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.deleteInvite))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", test.path, nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"net/http"
+)
+
+// Public keys for verifying issued JWT tokens. Served outside of /api,
+// so it is not part of the swagger specification
+func (h *Handler) jwks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(pkg.JWKS())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
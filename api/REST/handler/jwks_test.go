@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setTestKeys(t *testing.T, signingKid string) []pkg.SigningKey {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []pkg.SigningKey{
+		{Kid: "ed-key", PrivateKey: edPrivate, PublicKey: edPublic},
+		{Kid: "rsa-key", PrivateKey: rsaPrivate, PublicKey: &rsaPrivate.PublicKey},
+	}
+	if err := pkg.SetKeys(signingKid, keys); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestHandler_jwks(t *testing.T) {
+	keys := setTestKeys(t, "ed-key")
+	edPublic := keys[0].PublicKey.(ed25519.PublicKey)
+	rsaPublic := keys[1].PublicKey.(*rsa.PublicKey)
+
+	handler := Handler{&service.Service{}}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	http.HandlerFunc(handler.jwks).ServeHTTP(w, req)
+
+	var set pkg.JSONWebKeySet
+	if err := json.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, w.Code, 200)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, len(set.Keys), 2)
+	assert.Equal(t, set.Keys[0], pkg.JSONWebKey{Kty: "OKP", Kid: "ed-key", Use: "sig", Alg: "EdDSA",
+		Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edPublic)})
+	assert.Equal(t, set.Keys[1].Kty, "RSA")
+	assert.Equal(t, set.Keys[1].Alg, "RS256")
+	assert.Equal(t, set.Keys[1].N, base64.RawURLEncoding.EncodeToString(rsaPublic.N.Bytes()))
+	assert.Equal(t, set.Keys[1].E, "AQAB")
+}
+
+func TestHandler_signedToken(t *testing.T) {
+	tests := []struct {
+		name               string
+		signingKid         string
+		rotatedKeys        func(keys []pkg.SigningKey) []pkg.SigningKey
+		expectedStatusCode int
+	}{
+		{
+			name:               "EdDSA",
+			signingKid:         "ed-key",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "RS256",
+			signingKid:         "rsa-key",
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Old key kept after rotation",
+			signingKid:         "ed-key",
+			rotatedKeys:        func(keys []pkg.SigningKey) []pkg.SigningKey { return keys },
+			expectedStatusCode: 200,
+		},
+		{
+			name:               "Old key removed after rotation",
+			signingKid:         "ed-key",
+			rotatedKeys:        func(keys []pkg.SigningKey) []pkg.SigningKey { return keys[1:] },
+			expectedStatusCode: 401,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			pkg.SetTokenConfig(pkg.TokenConfig{AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour})
+			keys := setTestKeys(t, test.signingKid)
+			token, err := pkg.GenerateJWT(entity.User{Id: 2, RoleId: 2}, 1, []string{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.rotatedKeys != nil {
+				if err := pkg.SetKeys("rsa-key", test.rotatedKeys(keys)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			repo := mock_service.NewMockUser(c)
+			if test.expectedStatusCode == 200 {
+				repo.EXPECT().GetUserById(gomock.Any(), 2).Return(presenter.UserResponse{Id: 2, Username: "user", Role: "USER"}, nil)
+			}
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/me", nil)
+			req.Header.Add("Authorization", "Bearer "+token)
+			pkg.JWTAuthUser(handler.me).ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+)
+
+func (h *Handler) me(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getMe(w, r)
+	case "PATCH":
+		pkg.DenyImpersonation(h.patchMe)(w, r)
+	case "DELETE":
+		pkg.DenyImpersonation(h.deleteMe)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) mePassword(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "PUT":
+		pkg.DenyImpersonation(h.putMePassword)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Get own profile
+// @Summary      Get own profile
+// @Description  Get profile of the authenticated user
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  presenter.UserResponse
+// @Failure      401  {object}  pkg.Problem
+// @Router       /me [get]
+func (h *Handler) getMe(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.services.GetUserById(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(user)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Change own username
+// @Summary      Change own username
+// @Description  Change username of the authenticated user. Requires current password
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.UsernameChange true "username change"
+// @Success      200  {object}  presenter.UserResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /me [patch]
+func (h *Handler) patchMe(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.UsernameChange
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.services.ChangeUsername(r.Context(), id, request, client(r))
+	if writeThrottled(w, r, err) {
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(user)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Change own password
+// @Summary      Change own password
+// @Description  Change password of the authenticated user. Requires current password
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.PasswordChange true "password change"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /me/password [put]
+func (h *Handler) putMePassword(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.PasswordChange
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.ChangePassword(r.Context(), id, request, client(r))
+	if writeThrottled(w, r, err) {
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Delete own account
+// @Summary      Delete own account
+// @Description  Delete account of the authenticated user. Requires current password
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.AccountDeletion true "account deletion"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /me [delete]
+func (h *Handler) deleteMe(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.AccountDeletion
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.DeleteAccount(r.Context(), id, request, client(r))
+	if writeThrottled(w, r, err) {
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
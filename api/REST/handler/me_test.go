@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getMe(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok user",
+			headerName:  "Authorization",
+			headerValue: "Bearer USER",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUserById(gomock.Any(), 2).Return(presenter.UserResponse{
+					Id: 2, Username: "user", Role: "USER"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":2,\"username\":\"user\",\"role\":\"USER\"}\n",
+		},
+		{
+			name:                 "Unauthorized",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/me\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me", pkg.MockJWTAuthUser(handler.me))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/me", nil)
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_patchMe(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.UsernameChange)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		inputRequest         presenter.UsernameChange
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			inputBody: `{"username": "newname", "currentPassword": "useruser"}`,
+			inputRequest: presenter.UsernameChange{
+				Username:        "newname",
+				CurrentPassword: "useruser",
+			},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.UsernameChange) {
+				r.EXPECT().ChangeUsername(gomock.Any(), 2, request, gomock.Any()).Return(presenter.UserResponse{
+					Id: 2, Username: "newname", Role: "USER"}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":2,\"username\":\"newname\",\"role\":\"USER\"}\n",
+		},
+		{
+			name:      "Wrong current password",
+			inputBody: `{"username": "newname", "currentPassword": "wrong"}`,
+			inputRequest: presenter.UsernameChange{
+				Username:        "newname",
+				CurrentPassword: "wrong",
+			},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.UsernameChange) {
+				r.EXPECT().ChangeUsername(gomock.Any(), 2, request, gomock.Any()).Return(presenter.UserResponse{},
+					errors.New("Invalid current password"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid current password\",\"instance\":\"/api/me\"}\n",
+		},
+		{
+			name:                 "Missing current password",
+			inputBody:            `{"username": "newname"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.UsernameChange) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/me\",\"errors\":[{\"field\":\"currentPassword\",\"message\":\"is required\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me", pkg.MockJWTAuthUser(handler.me))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/api/me",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer USER")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_putMePassword(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.PasswordChange)
+
+	tests := []struct {
+		name                 string
+		method               string
+		inputBody            string
+		inputRequest         presenter.PasswordChange
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Ok",
+			method:    "PUT",
+			inputBody: `{"currentPassword": "useruser", "newPassword": "password"}`,
+			inputRequest: presenter.PasswordChange{
+				CurrentPassword: "useruser",
+				NewPassword:     "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.PasswordChange) {
+				r.EXPECT().ChangePassword(gomock.Any(), 2, request, gomock.Any()).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:      "Throttled",
+			method:    "PUT",
+			inputBody: `{"currentPassword": "wrongwrong", "newPassword": "password"}`,
+			inputRequest: presenter.PasswordChange{
+				CurrentPassword: "wrongwrong",
+				NewPassword:     "password",
+			},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.PasswordChange) {
+				r.EXPECT().ChangePassword(gomock.Any(), 2, request, gomock.Any()).Return(
+					&service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Too Many Requests\",\"status\":429,\"detail\":\"Too many failed login attempts, try again later\",\"instance\":\"/api/me/password\"}\n",
+		},
+		{
+			name:      "Short new password",
+			method:    "PUT",
+			inputBody: `{"currentPassword": "useruser", "newPassword": "pass"}`,
+			inputRequest: presenter.PasswordChange{
+				CurrentPassword: "useruser",
+				NewPassword:     "pass",
+			},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.PasswordChange) {
+				r.EXPECT().ChangePassword(gomock.Any(), 2, request, gomock.Any()).Return(&service.PasswordPolicyError{
+					Violations: []string{"must be at least 8 characters long", "must contain a digit"}})
+			},
+			expectedStatusCode: 422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422," +
+				"\"detail\":\"Password does not meet the policy: must be at least 8 characters long, must contain a digit\"," +
+				"\"instance\":\"/api/me/password\"}\n",
+		},
+		{
+			name:                 "Too long new password",
+			method:               "PUT",
+			inputBody:            `{"currentPassword": "useruser", "newPassword": "ppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppppp"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.PasswordChange) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/me/password\",\"errors\":[{\"field\":\"newPassword\",\"message\":\"must be at most 128 characters\"}]}\n",
+		},
+		{
+			name:                 "Method Not Allowed",
+			method:               "GET",
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.PasswordChange) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/me/password\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me/password", pkg.MockJWTAuthUser(handler.mePassword))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/me/password",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer USER")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_deleteMe(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.AccountDeletion)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		inputRequest         presenter.AccountDeletion
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			inputBody:    `{"currentPassword": "useruser"}`,
+			inputRequest: presenter.AccountDeletion{CurrentPassword: "useruser"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.AccountDeletion) {
+				r.EXPECT().DeleteAccount(gomock.Any(), 2, request, gomock.Any()).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:         "Wrong current password",
+			inputBody:    `{"currentPassword": "wrong"}`,
+			inputRequest: presenter.AccountDeletion{CurrentPassword: "wrong"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.AccountDeletion) {
+				r.EXPECT().DeleteAccount(gomock.Any(), 2, request, gomock.Any()).Return(errors.New("Invalid current password"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid current password\",\"instance\":\"/api/me\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me", pkg.MockJWTAuthUser(handler.me))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/me",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer USER")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/service"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+)
+
+// Start single sign-on login
+// @Summary      Start single sign-on login
+// @Description  Redirect to the OpenID Connect provider. The provider redirects back to /auth/oidc/callback
+// @Tags         accounts
+// @Success      302  {object}  string
+// @Failure      404  {object}  pkg.Problem
+// @Router       /auth/oidc/login [get]
+func (h *Handler) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	url, err := h.services.StartOIDCLogin(r.Context())
+	if errors.Is(err, service.ErrOIDCDisabled) {
+		pkg.HandleError(w, r, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, errors.New("Identity provider is unavailable"), http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// Finish single sign-on login
+// @Summary      Finish single sign-on login
+// @Description  Redirect target of the OpenID Connect provider. A user is created on the first login
+// @Tags         accounts
+// @Produce      json
+// @Param 		 code  query 	string 	false "authorization code"
+// @Param 		 state query 	string 	false "state"
+// @Param 		 error query 	string 	false "error returned by the provider"
+// @Success      200  {object}  presenter.TokenResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      404  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /auth/oidc/callback [get]
+func (h *Handler) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		pkg.HandleError(w, r, errors.New("Identity provider returned "+providerError), http.StatusUnauthorized)
+		return
+	}
+
+	callback := presenter.OIDCCallback{Code: query.Get("code"), State: query.Get("state")}
+
+	validate := validator.New()
+
+	if err := validate.Struct(callback); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.LoginOIDC(r.Context(), callback, client(r))
+
+	if errors.Is(err, service.ErrOIDCDisabled) {
+		pkg.HandleError(w, r, err, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrOIDCLoginFailed) {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+	var suspended *service.SuspendedError
+	if errors.As(err, &suspended) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrRegistrationPending) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	pkg.WriteJSON(w, http.StatusOK, tokens)
+}
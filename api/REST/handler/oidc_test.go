@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_oidcLogin(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		method               string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok",
+			method: "GET",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().StartOIDCLogin(gomock.Any()).Return("https://sso.example.com/authorize?state=abc", nil)
+			},
+			expectedStatusCode:   302,
+			expectedLocation:     "https://sso.example.com/authorize?state=abc",
+			expectedResponseBody: "<a href=\"https://sso.example.com/authorize?state=abc\">Found</a>.\n\n",
+		},
+		{
+			name:   "Disabled",
+			method: "GET",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().StartOIDCLogin(gomock.Any()).Return("", service.ErrOIDCDisabled)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"Single sign-on is not enabled\",\"instance\":\"/api/auth/oidc/login\"}\n",
+		},
+		{
+			name:   "Provider unavailable",
+			method: "GET",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().StartOIDCLogin(gomock.Any()).Return("", errors.New("can not discover oidc provider"))
+			},
+			expectedStatusCode:   502,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Gateway\",\"status\":502,\"detail\":\"Identity provider is unavailable\",\"instance\":\"/api/auth/oidc/login\"}\n",
+		},
+		{
+			name:                 "Method not allowed",
+			method:               "POST",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/auth/oidc/login\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.HandleFunc("/api/auth/oidc/login", handler.oidcLogin)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/auth/oidc/login", nil)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Location"), test.expectedLocation)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_oidcCallback(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, callback presenter.OIDCCallback)
+
+	tests := []struct {
+		name                 string
+		query                string
+		inputCallback        presenter.OIDCCallback
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:          "Ok",
+			query:         "?code=c0de&state=st4te",
+			inputCallback: presenter.OIDCCallback{Code: "c0de", State: "st4te"},
+			mockBehavior: func(r *mock_service.MockUser, callback presenter.OIDCCallback) {
+				r.EXPECT().LoginOIDC(gomock.Any(), callback, gomock.Any()).Return(presenter.TokenResponse{
+					AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "{\"accessToken\":\"access\",\"refreshToken\":\"refresh\",\"tokenType\":\"Bearer\"," +
+				"\"expiresIn\":900}\n",
+		},
+		{
+			name:          "Invalid state or token",
+			query:         "?code=c0de&state=forged",
+			inputCallback: presenter.OIDCCallback{Code: "c0de", State: "forged"},
+			mockBehavior: func(r *mock_service.MockUser, callback presenter.OIDCCallback) {
+				r.EXPECT().LoginOIDC(gomock.Any(), callback, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrOIDCLoginFailed)
+			},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Single sign-on login failed\",\"instance\":\"/api/auth/oidc/callback\"}\n",
+		},
+		{
+			name:          "Disabled",
+			query:         "?code=c0de&state=st4te",
+			inputCallback: presenter.OIDCCallback{Code: "c0de", State: "st4te"},
+			mockBehavior: func(r *mock_service.MockUser, callback presenter.OIDCCallback) {
+				r.EXPECT().LoginOIDC(gomock.Any(), callback, gomock.Any()).Return(presenter.TokenResponse{}, service.ErrOIDCDisabled)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"detail\":\"Single sign-on is not enabled\",\"instance\":\"/api/auth/oidc/callback\"}\n",
+		},
+		{
+			name:                 "Provider error",
+			query:                "?error=access_denied&state=st4te",
+			mockBehavior:         func(r *mock_service.MockUser, callback presenter.OIDCCallback) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Identity provider returned access_denied\",\"instance\":\"/api/auth/oidc/callback\"}\n",
+		},
+		{
+			name:                 "Missing code",
+			query:                "?state=st4te",
+			mockBehavior:         func(r *mock_service.MockUser, callback presenter.OIDCCallback) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/oidc/callback\",\"errors\":[{\"field\":\"code\",\"message\":\"is required\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputCallback)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.HandleFunc("/api/auth/oidc/callback", handler.oidcCallback)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/auth/oidc/callback"+test.query, nil)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"filmLibraryVk/pkg"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// acceptPatch returns the media type of a PATCH body: application/json with the
+// fields to change, or a merge patch or JSON patch of the resource. Other
+// media types are answered with 415
+func acceptPatch(w http.ResponseWriter, r *http.Request) (string, bool) {
+	patchType := pkg.PatchType(r)
+	switch patchType {
+	case "application/json", pkg.MergePatch, pkg.JSONPatch:
+		return patchType, true
+	}
+	w.Header().Set("Accept-Patch", pkg.AcceptPatch)
+	pkg.WriteProblem(w, r, http.StatusUnsupportedMediaType, "PATCH accepts "+pkg.AcceptPatch)
+	return "", false
+}
+
+// patchDocument applies the merge patch or JSON patch in the body of r to
+// current, the resource as answered to GET, and decodes the result into
+// request. Members of the resource request has no field for, like its id,
+// can not be patched
+func patchDocument(r *http.Request, patchType string, current, request any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	patched, err := pkg.ApplyPatch(patchType, doc, patch)
+	if err != nil {
+		return err
+	}
+
+	var before, after, writable map[string]json.RawMessage
+	json.Unmarshal(doc, &before)
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return pkg.InvalidRequest(err)
+	}
+	fields, _ := json.Marshal(request)
+	json.Unmarshal(fields, &writable)
+	for name, value := range before {
+		if _, ok := writable[name]; ok {
+			continue
+		}
+		if !equalJSON(value, after[name]) {
+			return pkg.InvalidField(name, "can not be changed")
+		}
+		delete(after, name)
+	}
+
+	patched, err = json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	return pkg.UnmarshalJSON(patched, request)
+}
+
+func equalJSON(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"bytes"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_patchFilmDocument(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockFilm)
+
+	film := presenter.FilmResponse{
+		Id:          1,
+		Name:        "name",
+		Description: "description",
+		ReleaseDate: "2021-10-12",
+		Rating:      5,
+		ActorsId:    []int{1, 2},
+		Version:     3,
+	}
+	name, empty, rating := "name", "", 7
+	releaseDate, _ := time.Parse("2006-01-02", "2021-10-12")
+	patched := presenter.FilmRequest{
+		Name:        &name,
+		Description: &empty,
+		ReleaseDate: &releaseDate,
+		Rating:      &rating,
+		ActorsId:    &[]int{1, 2, 3},
+	}
+
+	tests := []struct {
+		name                 string
+		contentType          string
+		ifMatch              string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedETag         string
+		expectedResponseBody string
+	}{
+		{
+			name:        "Merge patch",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"description": null, "rating": 7, "actorsId": [1, 2, 3]}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+				r.EXPECT().PutFilm(gomock.Any(), 1, 3, patched).Return(presenter.FilmResponse{
+					Id: 1, Name: "name", ReleaseDate: "2021-10-12", Rating: 7, ActorsId: []int{1, 2, 3}, Version: 4,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         "\"4\"",
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"\",\"releaseDate\":\"2021-10-12\",\"rating\":7,\"actorsId\":[1,2,3]}\n",
+		},
+		{
+			name:        "JSON patch",
+			contentType: "application/json-patch+json; charset=utf-8",
+			ifMatch:     "\"3\"",
+			inputBody: `[{"op": "test", "path": "/rating", "value": 5},
+						{"op": "replace", "path": "/rating", "value": 7},
+						{"op": "replace", "path": "/description", "value": null},
+						{"op": "add", "path": "/actorsId/-", "value": 3}]`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+				r.EXPECT().PutFilm(gomock.Any(), 1, 3, patched).Return(presenter.FilmResponse{
+					Id: 1, Name: "name", ReleaseDate: "2021-10-12", Rating: 7, ActorsId: []int{1, 2, 3}, Version: 4,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedETag:         "\"4\"",
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"description\":\"\",\"releaseDate\":\"2021-10-12\",\"rating\":7,\"actorsId\":[1,2,3]}\n",
+		},
+		{
+			name:        "Failed test",
+			contentType: "application/json-patch+json",
+			inputBody:   `[{"op": "test", "path": "/rating", "value": 6}]`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Conflict\",\"status\":409,\"detail\":\"Can not apply patch operation 0: test of /rating failed\",\"instance\":\"/api/film/1\"}\n",
+		},
+		{
+			name:        "Malformed patch",
+			contentType: "application/json-patch+json",
+			inputBody:   `[{"op": "increment", "path": "/rating"}]`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"invalid patch: unknown operation \\\"increment\\\"\",\"instance\":\"/api/film/1\"}\n",
+		},
+		{
+			name:        "Removed name",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"name": null}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/1\",\"errors\":[{\"field\":\"name\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:        "Invalid result",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"rating": 11}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/1\",\"errors\":[{\"field\":\"rating\",\"message\":\"must be at most 10\"}]}\n",
+		},
+		{
+			name:        "Changed id",
+			contentType: "application/merge-patch+json",
+			inputBody:   `{"id": 2}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/1\",\"errors\":[{\"field\":\"id\",\"message\":\"can not be changed\"}]}\n",
+		},
+		{
+			name:        "Added unknown field",
+			contentType: "application/json-patch+json",
+			inputBody:   `[{"op": "add", "path": "/actorIds", "value": [3]}]`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/film/1\",\"errors\":[{\"field\":\"actorIds\",\"message\":\"is not a known field\"}]}\n",
+		},
+		{
+			name:        "Modified since read",
+			contentType: "application/merge-patch+json",
+			ifMatch:     "\"2\"",
+			inputBody:   `{"rating": 7}`,
+			mockBehavior: func(r *mock_service.MockFilm) {
+				r.EXPECT().GetFilm(gomock.Any(), 1).Return(film, nil)
+			},
+			expectedStatusCode:   412,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Precondition Failed\",\"status\":412,\"detail\":\"film was modified since it was read\",\"instance\":\"/api/film/1\"}\n",
+		},
+		{
+			name:                 "Unsupported media type",
+			contentType:          "text/plain",
+			inputBody:            `rating=7`,
+			mockBehavior:         func(r *mock_service.MockFilm) {},
+			expectedStatusCode:   415,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unsupported Media Type\",\"status\":415,\"detail\":\"PATCH accepts application/json, application/merge-patch+json, application/json-patch+json\",\"instance\":\"/api/film/1\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockFilm(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{Film: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film/", pkg.MockJWTAuthAdmin(handler.patchFilm))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/api/film/1", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			req.Header.Add("Content-Type", test.contentType)
+			if test.ifMatch != "" {
+				req.Header.Add("If-Match", test.ifMatch)
+			}
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("ETag"), test.expectedETag)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_patchActorDocument(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockActor)
+
+	actor := presenter.ActorResponse{
+		Id:       1,
+		Name:     "name",
+		Sex:      "female",
+		Birthday: "2001-10-12",
+		FilmsId:  []int{1},
+		Version:  2,
+	}
+	name, sex := "name", "female"
+	birthday, _ := time.Parse("2006-01-02", "2001-10-12")
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Removed films",
+			inputBody: `{"filmsId": null}`,
+			mockBehavior: func(r *mock_service.MockActor) {
+				r.EXPECT().GetActor(gomock.Any(), 1).Return(actor, nil)
+				r.EXPECT().PutActor(gomock.Any(), 1, 2, presenter.ActorRequest{
+					Name: &name, Sex: &sex, Birthday: &birthday, FilmsId: &[]int{},
+				}).Return(presenter.ActorResponse{
+					Id: 1, Name: "name", Sex: "female", Birthday: "2001-10-12", FilmsId: []int{}, Version: 3,
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":1,\"name\":\"name\",\"sex\":\"female\",\"birthday\":\"2001-10-12\",\"filmsId\":[]}\n",
+		},
+		{
+			name:      "Removed sex and birthday",
+			inputBody: `{"sex": null, "birthday": null}`,
+			mockBehavior: func(r *mock_service.MockActor) {
+				r.EXPECT().GetActor(gomock.Any(), 1).Return(actor, nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/actor/1\",\"errors\":[{\"field\":\"sex\",\"message\":\"is required\"},{\"field\":\"birthday\",\"message\":\"is required\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockActor(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{Actor: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/actor/", pkg.MockJWTAuthAdmin(handler.patchActor))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/api/actor/1", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			req.Header.Add("Content-Type", "application/merge-patch+json")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_patchUserDocument(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	user := presenter.UserResponse{Id: 1, Username: "username", Role: "USER", Version: 5}
+	username, role, password := "username", "ADMIN", "password"
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Keeps password",
+			inputBody: `[{"op": "replace", "path": "/role", "value": "ADMIN"}]`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUserById(gomock.Any(), 1).Return(user, nil)
+				r.EXPECT().PatchUser(gomock.Any(), 1, 5, presenter.UserRequest{
+					Username: &username, Role: &role,
+				}).Return(presenter.UserResponse{Id: 1, Username: "username", Role: "ADMIN", Version: 6}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":1,\"username\":\"username\",\"role\":\"ADMIN\"}\n",
+		},
+		{
+			name: "Adds password",
+			inputBody: `[{"op": "replace", "path": "/role", "value": "ADMIN"},
+						{"op": "add", "path": "/password", "value": "password"}]`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUserById(gomock.Any(), 1).Return(user, nil)
+				r.EXPECT().PatchUser(gomock.Any(), 1, 5, presenter.UserRequest{
+					Username: &username, Password: &password, Role: &role,
+				}).Return(presenter.UserResponse{Id: 1, Username: "username", Role: "ADMIN", Version: 6}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":1,\"username\":\"username\",\"role\":\"ADMIN\"}\n",
+		},
+		{
+			name:      "Invalid username",
+			inputBody: `[{"op": "replace", "path": "/username", "value": "u"}]`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUserById(gomock.Any(), 1).Return(user, nil)
+			},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/user/1\",\"errors\":[{\"field\":\"username\",\"message\":\"must be at least 2 characters\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthAdmin(handler.patchUser))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/api/user/1", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			req.Header.Add("Content-Type", "application/json-patch+json")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
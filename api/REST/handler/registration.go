@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"net/http"
+)
+
+var prefixRegistration = "/api/registration/"
+
+func (h *Handler) registrations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getRegistrations(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) registration(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.approveRegistration(w, r)
+	case "DELETE":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.rejectRegistration(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Get pending registrations
+// @Summary      Get pending registrations
+// @Description  Get users awaiting approval
+// @Tags         registrations
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  []presenter.UserResponse
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /registration [get]
+func (h *Handler) getRegistrations(w http.ResponseWriter, r *http.Request) {
+	users, err := h.services.GetPendingUsers(r.Context())
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(users)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Approve registration only with user:write permission
+// @Summary      Approve registration
+// @Description  Activate a user awaiting approval
+// @Tags         registrations
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "user id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /registration/{id} [post]
+func (h *Handler) approveRegistration(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixRegistration)
+	if err != nil {
+		return
+	}
+
+	err = h.services.ApproveUser(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Reject registration only with user:write permission
+// @Summary      Reject registration
+// @Description  Delete a user awaiting approval
+// @Tags         registrations
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "user id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /registration/{id} [delete]
+func (h *Handler) rejectRegistration(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixRegistration)
+	if err != nil {
+		return
+	}
+
+	err = h.services.RejectUser(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_getRegistrations(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetPendingUsers(gomock.Any()).Return([]presenter.UserResponse{{
+					Id: 3, Username: "newcomer", Role: "USER", Status: "pending"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[{\"id\":3,\"username\":\"newcomer\",\"role\":\"USER\",\"status\":\"pending\"}]\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/registration\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/registration", pkg.MockJWTAuthPermission(entity.PermissionUserRead, handler.registrations))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/registration", nil)
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_decideRegistration(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		method               string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Approve",
+			method: "POST",
+			path:   "/api/registration/3",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ApproveUser(gomock.Any(), 3).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "Approve not pending",
+			method: "POST",
+			path:   "/api/registration/1",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ApproveUser(gomock.Any(), 1).Return(errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/registration/1\"}\n",
+		},
+		{
+			name:   "Reject",
+			method: "DELETE",
+			path:   "/api/registration/3",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RejectUser(gomock.Any(), 3).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			if test.method == "POST" {
+				mux.Handle("/api/registration/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.approveRegistration))
+			} else {
+				mux.Handle("/api/registration/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.rejectRegistration))
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler_requestId(t *testing.T) {
+	tests := []struct {
+		name              string
+		requestId         string
+		expectedRequestId string
+	}{
+		{
+			name:              "Client id",
+			requestId:         "client-id",
+			expectedRequestId: "client-id",
+		},
+		{
+			name: "Generated id",
+		},
+		{
+			name:      "Invalid client id is replaced",
+			requestId: "client id",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			services := &service.Service{Film: mock_service.NewMockFilm(c)}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/film/", pkg.MockJWTAuthUser(handler.film))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/film/1s", nil)
+			req.Header.Add("Authorization", "Bearer USER")
+			if test.requestId != "" {
+				req.Header.Add("X-Request-Id", test.requestId)
+			}
+			pkg.RequestId(mux).ServeHTTP(w, req)
+
+			var problem pkg.Problem
+			json.NewDecoder(w.Body).Decode(&problem)
+
+			assert.Equal(t, w.Code, 400)
+			assert.Equal(t, w.Header().Get("Content-Type"), "application/problem+json")
+			assert.Equal(t, problem.RequestId, w.Header().Get("X-Request-Id"))
+			assert.Equal(t, problem.Instance, "/api/film/1s")
+			if test.expectedRequestId != "" {
+				assert.Equal(t, problem.RequestId, test.expectedRequestId)
+			} else {
+				assert.NotEqual(t, problem.RequestId, "")
+				assert.NotEqual(t, problem.RequestId, test.requestId)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+)
+
+var prefixRole = "/api/role/"
+
+func (h *Handler) roles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getRoles(w, r)
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionRoleWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.createRole(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) role(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getRole(w, r)
+	case "PUT":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionRoleWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.putRole(w, r)
+	case "DELETE":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionRoleWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.deleteRole(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Get roles
+// @Summary      Get roles
+// @Description  Get roles with their permissions
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  []presenter.RoleResponse
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /role [get]
+func (h *Handler) getRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.services.GetRoles(r.Context())
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(roles)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Get role by id
+// @Summary      Get role by id
+// @Description  Get role by id with its permissions
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "id"
+// @Success      200  {object}  presenter.RoleResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /role/{id} [get]
+func (h *Handler) getRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixRole)
+	if err != nil {
+		return
+	}
+
+	role, err := h.services.GetRole(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(role)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Create role only with role:write permission
+// @Summary      Create role
+// @Description  Create role with a set of permissions
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.RoleRequest true "role"
+// @Success      201  {object}  presenter.CreatedResponse
+// @Header       201  {string}  Location "/api/role/{id}"
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /role [post]
+func (h *Handler) createRole(w http.ResponseWriter, r *http.Request) {
+	var request presenter.RoleRequest
+	err := pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	var id int
+	id, err = h.services.CreateRole(r.Context(), request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Location", "/api/role/"+strconv.Itoa(id))
+	pkg.WriteJSON(w, http.StatusCreated, presenter.CreatedResponse{Id: id})
+}
+
+// Put role by id only with role:write permission
+// @Summary      Put role by id
+// @Description  Rename role and replace its permissions
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "id"
+// @Param 		 request body presenter.RoleRequest true "role"
+// @Success      200  {object}  presenter.RoleResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /role/{id} [put]
+func (h *Handler) putRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixRole)
+	if err != nil {
+		return
+	}
+
+	var request presenter.RoleRequest
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.services.PutRole(r.Context(), id, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(role)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Delete role by id only with role:write permission
+// @Summary      Delete role by id
+// @Description  Delete role by id. Roles assigned to users can not be deleted
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param 		 id path int true "id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /role/{id} [delete]
+func (h *Handler) deleteRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetPathId(w, r, prefixRole)
+	if err != nil {
+		return
+	}
+
+	err = h.services.DeleteRole(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
@@ -0,0 +1,423 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestHandler_getRoles(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockRole)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockRole) {
+				r.EXPECT().GetRoles(gomock.Any()).Return([]presenter.RoleResponse{
+					{Id: 3, Role: "EDITOR", Permissions: []string{"film:read", "film:write"}}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[{\"id\":3,\"role\":\"EDITOR\",\"permissions\":[\"film:read\",\"film:write\"]}]\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerName:           "Authorization",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockRole) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/role\"}\n",
+		},
+		{
+			name:                 "Unauthorized",
+			mockBehavior:         func(r *mock_service.MockRole) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/role\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockRole(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{Role: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/role", pkg.MockJWTAuthPermission(entity.PermissionRoleRead, handler.roles))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/role", nil)
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_getRole(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockRole, id string)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		id                   string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			id:          "2",
+			mockBehavior: func(r *mock_service.MockRole, id string) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().GetRole(gomock.Any(), idd).Return(presenter.RoleResponse{
+					Id: 2, Role: "USER", Permissions: []string{"film:read"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":2,\"role\":\"USER\",\"permissions\":[\"film:read\"]}\n",
+		},
+		{
+			name:        "Not found",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			id:          "10",
+			mockBehavior: func(r *mock_service.MockRole, id string) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().GetRole(gomock.Any(), idd).Return(presenter.RoleResponse{}, errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/role/10\"}\n",
+		},
+		{
+			name:                 "Invalid id",
+			headerName:           "Authorization",
+			headerValue:          "Bearer ADMIN",
+			id:                   "1s",
+			mockBehavior:         func(r *mock_service.MockRole, id string) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/role/1s\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockRole(c)
+			test.mockBehavior(repo, test.id)
+
+			services := &service.Service{Role: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/role/", pkg.MockJWTAuthPermission(entity.PermissionRoleRead, handler.getRole))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/role/"+test.id, nil)
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_postRole(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockRole, role presenter.RoleRequest)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		inputBody            string
+		inputRole            presenter.RoleRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"role": "CURATOR", "permissions": ["film:read", "film:write"]}`,
+			inputRole: presenter.RoleRequest{
+				Role:        "CURATOR",
+				Permissions: []string{"film:read", "film:write"},
+			},
+			mockBehavior: func(r *mock_service.MockRole, role presenter.RoleRequest) {
+				r.EXPECT().CreateRole(gomock.Any(), role).Return(5, nil)
+			},
+			expectedStatusCode:   201,
+			expectedResponseBody: "{\"id\":5}\n",
+		},
+		{
+			name:        "Unknown permission",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"role": "CURATOR", "permissions": ["film:fly"]}`,
+			inputRole: presenter.RoleRequest{
+				Role:        "CURATOR",
+				Permissions: []string{"film:fly"},
+			},
+			mockBehavior: func(r *mock_service.MockRole, role presenter.RoleRequest) {
+				r.EXPECT().CreateRole(gomock.Any(), role).Return(0, errors.New("permission film:fly does not exist"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"permission film:fly does not exist\",\"instance\":\"/api/role\"}\n",
+		},
+		{
+			name:                 "Missing permissions",
+			headerName:           "Authorization",
+			headerValue:          "Bearer ADMIN",
+			inputBody:            `{"role": "CURATOR"}`,
+			mockBehavior:         func(r *mock_service.MockRole, role presenter.RoleRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/role\",\"errors\":[{\"field\":\"permissions\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerName:           "Authorization",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockRole, role presenter.RoleRequest) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/role\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockRole(c)
+			test.mockBehavior(repo, test.inputRole)
+
+			services := &service.Service{Role: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/role", pkg.MockJWTAuthPermission(entity.PermissionRoleWrite, handler.createRole))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/role",
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_putRole(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockRole, role presenter.RoleRequest, id string)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		inputBody            string
+		id                   string
+		inputRole            presenter.RoleRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"role": "EDITOR", "permissions": ["film:read"]}`,
+			id:          "3",
+			inputRole: presenter.RoleRequest{
+				Role:        "EDITOR",
+				Permissions: []string{"film:read"},
+			},
+			mockBehavior: func(r *mock_service.MockRole, role presenter.RoleRequest, id string) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().PutRole(gomock.Any(), idd, role).Return(presenter.RoleResponse{
+					Id: 3, Role: "EDITOR", Permissions: []string{"film:read"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":3,\"role\":\"EDITOR\",\"permissions\":[\"film:read\"]}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerName:           "Authorization",
+			headerValue:          "Bearer USER",
+			id:                   "3",
+			mockBehavior:         func(r *mock_service.MockRole, role presenter.RoleRequest, id string) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/role/3\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockRole(c)
+			test.mockBehavior(repo, test.inputRole, test.id)
+
+			services := &service.Service{Role: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/role/", pkg.MockJWTAuthPermission(entity.PermissionRoleWrite, handler.putRole))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/role/"+test.id,
+				bytes.NewBufferString(test.inputBody))
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_deleteRole(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockRole, id string)
+
+	tests := []struct {
+		name                 string
+		headerName           string
+		headerValue          string
+		id                   string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok admin",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			id:          "5",
+			mockBehavior: func(r *mock_service.MockRole, id string) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().DeleteRole(gomock.Any(), idd)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:        "Role in use",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			id:          "2",
+			mockBehavior: func(r *mock_service.MockRole, id string) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().DeleteRole(gomock.Any(), idd).Return(errors.New("role is assigned to users"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"role is assigned to users\",\"instance\":\"/api/role/2\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockRole(c)
+			test.mockBehavior(repo, test.id)
+
+			services := &service.Service{Role: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/role/", pkg.MockJWTAuthPermission(entity.PermissionRoleWrite, handler.deleteRole))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/role/"+test.id, nil)
+			req.Header.Add(test.headerName, test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_role_invalid_method(t *testing.T) {
+	tests := []struct {
+		name                 string
+		method               string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Method Not Allowed",
+			method:               "PATCH",
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/role/1\"}\n",
+		},
+		{
+			name:                 "PUT without role:write in token",
+			method:               "PUT",
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/role/1\"}\n",
+		},
+		{
+			name:                 "DELETE without role:write in token",
+			method:               "DELETE",
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/role/1\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockRole(c)
+
+			services := &service.Service{Role: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/role/", pkg.MockJWTAuthPermission(entity.PermissionRoleRead, handler.role))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/role/1", nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var prefixMeSession = "/api/me/sessions/"
+
+func (h *Handler) meSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getMeSessions(w, r)
+	case "DELETE":
+		pkg.DenyImpersonation(h.deleteMeSessions)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) meSession(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "DELETE":
+		pkg.DenyImpersonation(h.deleteMeSession)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) userSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getUserSessions(w, r)
+	case "DELETE":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.deleteUserSessions(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Get own sessions
+// @Summary      Get own sessions
+// @Description  Get active sessions of the authenticated user
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  []presenter.SessionResponse
+// @Failure      401  {object}  pkg.Problem
+// @Router       /me/sessions [get]
+func (h *Handler) getMeSessions(w http.ResponseWriter, r *http.Request) {
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.services.GetSessions(r.Context(), claims.Id, claims.SessionId)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(sessions)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Revoke other sessions
+// @Summary      Revoke other sessions
+// @Description  Sign out every session of the authenticated user except the current one
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Router       /me/sessions [delete]
+func (h *Handler) deleteMeSessions(w http.ResponseWriter, r *http.Request) {
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	err = h.services.RevokeOtherSessions(r.Context(), claims.Id, claims.SessionId)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+}
+
+// Revoke own session
+// @Summary      Revoke own session
+// @Description  Sign out a session of the authenticated user
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "session id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Router       /me/sessions/{id} [delete]
+func (h *Handler) deleteMeSession(w http.ResponseWriter, r *http.Request) {
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	id, err := pkg.GetPathId(w, r, prefixMeSession)
+	if err != nil {
+		return
+	}
+
+	err = h.services.RevokeSession(r.Context(), claims.Id, id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Get user sessions
+// @Summary      Get user sessions
+// @Description  Get active sessions of user
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "user id"
+// @Success      200  {object}  []presenter.SessionResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /user/{id}/sessions [get]
+func (h *Handler) getUserSessions(w http.ResponseWriter, r *http.Request) {
+	userId, sessionId, err := parseSessionPath(r.URL.Path)
+	if err != nil || sessionId != 0 {
+		pkg.WriteProblem(w, r, http.StatusNotFound, "")
+		return
+	}
+
+	sessions, err := h.services.GetSessions(r.Context(), userId, 0)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(sessions)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Revoke user sessions
+// @Summary      Revoke user sessions
+// @Description  Sign out all sessions of user
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "user id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /user/{id}/sessions [delete]
+func (h *Handler) deleteUserSessions(w http.ResponseWriter, r *http.Request) {
+	userId, sessionId, err := parseSessionPath(r.URL.Path)
+	if err != nil {
+		pkg.WriteProblem(w, r, http.StatusNotFound, "")
+		return
+	}
+	if sessionId != 0 {
+		h.deleteUserSession(w, r, userId, sessionId)
+		return
+	}
+
+	err = h.services.RevokeOtherSessions(r.Context(), userId, 0)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Revoke user session
+// @Summary      Revoke user session
+// @Description  Sign out a session of user
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "user id"
+// @Param 		 sessionId   path 	int 	true "session id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /user/{id}/sessions/{sessionId} [delete]
+func (h *Handler) deleteUserSession(w http.ResponseWriter, r *http.Request, userId, sessionId int) {
+	err := h.services.RevokeSession(r.Context(), userId, sessionId)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// parseSessionPath parses /api/user/{id}/sessions and /api/user/{id}/sessions/{sessionId}
+func parseSessionPath(path string) (int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(path, prefixUser), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] != "sessions" {
+		return 0, 0, errors.New("unknown path " + path)
+	}
+
+	userId, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(parts) == 2 {
+		return userId, 0, nil
+	}
+
+	sessionId, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, 0, err
+	}
+	return userId, sessionId, nil
+}
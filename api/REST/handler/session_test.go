@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_getMeSessions(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	used := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			headerValue: "Bearer USER",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetSessions(gomock.Any(), 2, 2).Return([]presenter.SessionResponse{{
+					Id: 2, UserAgent: "curl/8.0", Ip: "192.0.2.1", CreatedAt: created, LastUsedAt: used, Current: true}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "[{\"id\":2,\"userAgent\":\"curl/8.0\",\"ip\":\"192.0.2.1\"," +
+				"\"createdAt\":\"2024-03-01T12:00:00Z\",\"lastUsedAt\":\"2024-03-02T12:00:00Z\",\"current\":true}]\n",
+		},
+		{
+			name:                 "Unauthorized",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/me/sessions\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me/sessions", pkg.MockJWTAuthUser(handler.meSessions))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/me/sessions", nil)
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_deleteMeSessions(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok other sessions",
+			path: "/api/me/sessions",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RevokeOtherSessions(gomock.Any(), 2, 2).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name: "Ok single session",
+			path: "/api/me/sessions/5",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RevokeSession(gomock.Any(), 2, 5).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name: "Foreign session",
+			path: "/api/me/sessions/7",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RevokeSession(gomock.Any(), 2, 7).Return(errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/me/sessions/7\"}\n",
+		},
+		{
+			name:                 "Invalid session id",
+			path:                 "/api/me/sessions/abc",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"abc\\\": invalid syntax\",\"instance\":\"/api/me/sessions/abc\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me/sessions", pkg.MockJWTAuthUser(handler.meSessions))
+			mux.Handle("/api/me/sessions/", pkg.MockJWTAuthUser(handler.meSession))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", test.path, nil)
+			req.Header.Add("Authorization", "Bearer USER")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_userSessions(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		method               string
+		path                 string
+		headerValue          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok get",
+			method:      "GET",
+			path:        "/api/user/2/sessions",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetSessions(gomock.Any(), 2, 0).Return([]presenter.SessionResponse{}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "[]\n",
+		},
+		{
+			name:                 "Forbidden get",
+			method:               "GET",
+			path:                 "/api/user/1/sessions",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1/sessions\"}\n",
+		},
+		{
+			name:                 "Unknown path",
+			method:               "GET",
+			path:                 "/api/user/2/tokens",
+			headerValue:          "Bearer ADMIN",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   404,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Not Found\",\"status\":404,\"instance\":\"/api/user/2/tokens\"}\n",
+		},
+		{
+			name:                 "Forbidden delete with mock token",
+			method:               "DELETE",
+			path:                 "/api/user/2/sessions",
+			headerValue:          "Bearer ADMIN",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/2/sessions\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserRead, handler.user))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestParseSessionPath(t *testing.T) {
+	tests := []struct {
+		path      string
+		userId    int
+		sessionId int
+		ok        bool
+	}{
+		{path: "/api/user/3/sessions", userId: 3, ok: true},
+		{path: "/api/user/3/sessions/9", userId: 3, sessionId: 9, ok: true},
+		{path: "/api/user/3/sessions/x"},
+		{path: "/api/user/x/sessions"},
+		{path: "/api/user/3/other"},
+		{path: "/api/user/3/sessions/9/more"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			userId, sessionId, err := parseSessionPath(test.path)
+
+			assert.Equal(t, err == nil, test.ok)
+			assert.Equal(t, userId, test.userId)
+			assert.Equal(t, sessionId, test.sessionId)
+		})
+	}
+}
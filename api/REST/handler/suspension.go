@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (h *Handler) userSuspend(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.suspendUser(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) userReinstate(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.reinstateUser(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Suspend user only with user:write permission
+// @Summary      Suspend user
+// @Description  Suspend the user until reinstated or for expiresIn seconds, or ban the user. Sessions of the
+// @Description  user are revoked and tokens already issued are rejected
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Param 		 request body presenter.SuspensionRequest true "suspension"
+// @Success      200  {object}  presenter.UserResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /user/{id}/suspend [post]
+func (h *Handler) suspendUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefixUser), "/suspend"))
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	claims, err := pkg.GetClaims(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.SuspensionRequest
+	if err := pkg.DecodeJSON(r, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.services.SuspendUser(r.Context(), claims.Id, id, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(user)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Reinstate user only with user:write permission
+// @Summary      Reinstate user
+// @Description  Lift the suspension or ban of the user
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Success      200  {object}  presenter.UserResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /user/{id}/reinstate [post]
+func (h *Handler) reinstateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefixUser), "/reinstate"))
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.services.ReinstateUser(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(user)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_suspendUser(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.SuspensionRequest)
+
+	suspendedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	until := suspendedAt.Add(time.Hour)
+	actorId := 1
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		path                 string
+		inputBody            string
+		inputRequest         presenter.SuspensionRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok suspend",
+			headerValue:  "Bearer ADMIN",
+			path:         "/api/user/5/suspend",
+			inputBody:    `{"reason": "spam", "expiresIn": 3600}`,
+			inputRequest: presenter.SuspensionRequest{Reason: "spam", ExpiresIn: 3600},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.SuspensionRequest) {
+				r.EXPECT().SuspendUser(gomock.Any(), 1, 5, request).Return(presenter.UserResponse{
+					Id: 5, Username: "user", Role: "USER", Status: entity.UserStatusSuspended,
+					Suspension: &presenter.Suspension{Reason: "spam", Until: &until, SuspendedBy: &actorId,
+						SuspendedAt: suspendedAt}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "{\"id\":5,\"username\":\"user\",\"role\":\"USER\",\"status\":\"suspended\"," +
+				"\"suspension\":{\"reason\":\"spam\",\"until\":\"2024-01-02T04:04:05Z\",\"suspendedBy\":1," +
+				"\"suspendedAt\":\"2024-01-02T03:04:05Z\"}}\n",
+		},
+		{
+			name:         "Ok ban",
+			headerValue:  "Bearer ADMIN",
+			path:         "/api/user/5/suspend",
+			inputBody:    `{"reason": "fraud", "ban": true}`,
+			inputRequest: presenter.SuspensionRequest{Reason: "fraud", Ban: true},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.SuspensionRequest) {
+				r.EXPECT().SuspendUser(gomock.Any(), 1, 5, request).Return(presenter.UserResponse{
+					Id: 5, Username: "user", Role: "USER", Status: entity.UserStatusBanned,
+					Suspension: &presenter.Suspension{Reason: "fraud", SuspendedBy: &actorId,
+						SuspendedAt: suspendedAt}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "{\"id\":5,\"username\":\"user\",\"role\":\"USER\",\"status\":\"banned\"," +
+				"\"suspension\":{\"reason\":\"fraud\",\"suspendedBy\":1,\"suspendedAt\":\"2024-01-02T03:04:05Z\"}}\n",
+		},
+		{
+			name:         "Yourself",
+			headerValue:  "Bearer ADMIN",
+			path:         "/api/user/1/suspend",
+			inputBody:    `{"reason": "test"}`,
+			inputRequest: presenter.SuspensionRequest{Reason: "test"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.SuspensionRequest) {
+				r.EXPECT().SuspendUser(gomock.Any(), 1, 1, request).Return(presenter.UserResponse{},
+					errors.New("Can not suspend yourself"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Can not suspend yourself\",\"instance\":\"/api/user/1/suspend\"}\n",
+		},
+		{
+			name:                 "No reason",
+			headerValue:          "Bearer ADMIN",
+			path:                 "/api/user/5/suspend",
+			inputBody:            `{"expiresIn": 3600}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.SuspensionRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/user/5/suspend\",\"errors\":[{\"field\":\"reason\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Negative expiresIn",
+			headerValue:          "Bearer ADMIN",
+			path:                 "/api/user/5/suspend",
+			inputBody:            `{"reason": "spam", "expiresIn": -1}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.SuspensionRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/user/5/suspend\",\"errors\":[{\"field\":\"expiresIn\",\"message\":\"must be at least 0\"}]}\n",
+		},
+		{
+			name:                 "Invalid id",
+			headerValue:          "Bearer ADMIN",
+			path:                 "/api/user/x/suspend",
+			inputBody:            `{"reason": "spam"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.SuspensionRequest) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"x\\\": invalid syntax\",\"instance\":\"/api/user/x/suspend\"}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			path:                 "/api/user/5/suspend",
+			inputBody:            `{"reason": "spam"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.SuspensionRequest) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/5/suspend\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.suspendUser))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_reinstateUser(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		headerValue          string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			headerValue: "Bearer ADMIN",
+			path:        "/api/user/5/reinstate",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ReinstateUser(gomock.Any(), 5).Return(presenter.UserResponse{
+					Id: 5, Username: "user", Role: "USER", Status: entity.UserStatusActive}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"id\":5,\"username\":\"user\",\"role\":\"USER\",\"status\":\"active\"}\n",
+		},
+		{
+			name:        "Not suspended",
+			headerValue: "Bearer ADMIN",
+			path:        "/api/user/6/reinstate",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ReinstateUser(gomock.Any(), 6).Return(presenter.UserResponse{}, errors.New("User is not suspended"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"User is not suspended\",\"instance\":\"/api/user/6/reinstate\"}\n",
+		},
+		{
+			name:                 "Forbidden for user",
+			headerValue:          "Bearer USER",
+			path:                 "/api/user/5/reinstate",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/5/reinstate\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.reinstateUser))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", test.path, nil)
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_userSuspendMethodNotAllowed(t *testing.T) {
+	handler := Handler{&service.Service{}}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.user))
+
+	for _, path := range []string{"/api/user/5/suspend", "/api/user/5/reinstate"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Add("Authorization", "Bearer ADMIN")
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,377 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (h *Handler) meTwoFactor(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		h.getMeTwoFactor(w, r)
+	case "POST":
+		pkg.DenyImpersonation(h.enrollMeTwoFactor)(w, r)
+	case "DELETE":
+		pkg.DenyImpersonation(h.deleteMeTwoFactor)(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) userTwoFactor(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "DELETE":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.resetUserTwoFactor(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+// Complete login with second factor
+// @Summary      Complete login with second factor
+// @Description  Exchange the mfaToken from /auth/authenticate and a TOTP or recovery code for tokens.
+// @Description  If enrollment was required, recovery codes are returned along with the tokens
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.TwoFactorLogin true "challenge token and code"
+// @Success      200  {object}  presenter.TokenResponse
+// @Failure      400  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /auth/2fa [post]
+func (h *Handler) authTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var request presenter.TwoFactorLogin
+	err := pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.services.LoginTwoFactor(r.Context(), request, client(r))
+
+	if writeThrottled(w, r, err) {
+		return
+	}
+	var suspended *service.SuspendedError
+	if errors.As(err, &suspended) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	pkg.WriteJSON(w, http.StatusOK, tokens)
+}
+
+// Enroll second factor during login
+// @Summary      Enroll second factor during login
+// @Description  Start TOTP enrollment with the mfaToken of a login that requires enrollment.
+// @Description  Complete the login via /auth/2fa with a code from the authenticator app
+// @Tags         accounts
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.TwoFactorEnrollmentRequest true "challenge token"
+// @Success      200  {object}  presenter.TwoFactorEnrollment
+// @Failure      400  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Router       /auth/2fa/enroll [post]
+func (h *Handler) authTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	var request presenter.TwoFactorEnrollmentRequest
+	err := pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	enrollment, err := h.services.EnrollTwoFactorLogin(r.Context(), request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(enrollment)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Get own two factor status
+// @Summary      Get own two factor status
+// @Description  Whether two factor authentication is enabled or required and how many recovery codes are left
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  presenter.TwoFactorStatus
+// @Failure      401  {object}  pkg.Problem
+// @Router       /me/2fa [get]
+func (h *Handler) getMeTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	status, err := h.services.GetTwoFactorStatus(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(status)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Start two factor enrollment
+// @Summary      Start two factor enrollment
+// @Description  Generate a TOTP secret with an otpauth URI and a QR code PNG for authenticator apps.
+// @Description  Two factor authentication is enabled after a code is confirmed via /me/2fa/confirm
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  presenter.TwoFactorEnrollment
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Router       /me/2fa [post]
+func (h *Handler) enrollMeTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	enrollment, err := h.services.EnrollTwoFactor(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(enrollment)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Confirm two factor enrollment
+// @Summary      Confirm two factor enrollment
+// @Description  Enable two factor authentication with a code from the authenticator app.
+// @Description  Returns single-use recovery codes, they are not shown again
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.TwoFactorCode true "TOTP code"
+// @Success      200  {object}  presenter.RecoveryCodes
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /me/2fa/confirm [post]
+func (h *Handler) meTwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.TwoFactorCode
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.services.ConfirmTwoFactor(r.Context(), id, request, client(r))
+	if writeThrottled(w, r, err) {
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(codes)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Regenerate recovery codes
+// @Summary      Regenerate recovery codes
+// @Description  Replace all recovery codes with new ones. Requires a TOTP or recovery code
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.TwoFactorCode true "TOTP or recovery code"
+// @Success      200  {object}  presenter.RecoveryCodes
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /me/2fa/recovery-codes [post]
+func (h *Handler) meRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+		return
+	}
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.TwoFactorCode
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.services.RegenerateRecoveryCodes(r.Context(), id, request, client(r))
+	if writeThrottled(w, r, err) {
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqBodyBytes := new(bytes.Buffer)
+	json.NewEncoder(reqBodyBytes).Encode(codes)
+	fmt.Fprintf(w, "%s", reqBodyBytes.String())
+}
+
+// Disable two factor authentication
+// @Summary      Disable two factor authentication
+// @Description  Disable two factor authentication. Requires current password and a TOTP or recovery code.
+// @Description  Not allowed if two factor authentication is required for the role
+// @Tags         me
+// @Accept       json
+// @Produce      json
+// @Param 		 request body presenter.TwoFactorDisable true "password and code"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      429  {object}  pkg.Problem
+// @Router       /me/2fa [delete]
+func (h *Handler) deleteMeTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, err := pkg.GetUserId(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var request presenter.TwoFactorDisable
+	err = pkg.DecodeJSON(r, &request)
+
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	validate := validator.New()
+
+	if err := validate.Struct(request); err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.DisableTwoFactor(r.Context(), id, request, client(r))
+	if writeThrottled(w, r, err) {
+		return
+	}
+	if errors.Is(err, service.ErrTwoFactorMandatory) {
+		pkg.HandleError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Reset two factor authentication of user only with user:write permission
+// @Summary      Reset two factor authentication of user
+// @Description  Remove the second factor and recovery codes of a user who lost them
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /user/{id}/2fa [delete]
+func (h *Handler) resetUserTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefixUser), "/2fa"))
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.ResetTwoFactor(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
@@ -0,0 +1,513 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/service"
+	mock_service "filmLibraryVk/internal/service/mocks"
+	"filmLibraryVk/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_authTwoFactor(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.TwoFactorLogin)
+
+	tests := []struct {
+		name                 string
+		method               string
+		inputBody            string
+		inputRequest         presenter.TwoFactorLogin
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedRetryAfter   string
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			method:       "POST",
+			inputBody:    `{"mfaToken": "challenge", "code": "123456"}`,
+			inputRequest: presenter.TwoFactorLogin{MfaToken: "challenge", Code: "123456"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.TwoFactorLogin) {
+				r.EXPECT().LoginTwoFactor(gomock.Any(), request, gomock.Any()).Return(presenter.TokenResponse{
+					AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "{\"accessToken\":\"access\",\"refreshToken\":\"refresh\"," +
+				"\"tokenType\":\"Bearer\",\"expiresIn\":900}\n",
+		},
+		{
+			name:         "Ok with enrollment",
+			method:       "POST",
+			inputBody:    `{"mfaToken": "challenge", "code": "123456"}`,
+			inputRequest: presenter.TwoFactorLogin{MfaToken: "challenge", Code: "123456"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.TwoFactorLogin) {
+				r.EXPECT().LoginTwoFactor(gomock.Any(), request, gomock.Any()).Return(presenter.TokenResponse{
+					AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900,
+					RecoveryCodes: []string{"3f9a1-c07de"}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "{\"accessToken\":\"access\",\"refreshToken\":\"refresh\"," +
+				"\"tokenType\":\"Bearer\",\"expiresIn\":900,\"recoveryCodes\":[\"3f9a1-c07de\"]}\n",
+		},
+		{
+			name:         "Invalid code",
+			method:       "POST",
+			inputBody:    `{"mfaToken": "challenge", "code": "000000"}`,
+			inputRequest: presenter.TwoFactorLogin{MfaToken: "challenge", Code: "000000"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.TwoFactorLogin) {
+				r.EXPECT().LoginTwoFactor(gomock.Any(), request, gomock.Any()).Return(presenter.TokenResponse{},
+					service.ErrTwoFactorInvalidCode)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid two factor code\",\"instance\":\"/api/auth/2fa\"}\n",
+		},
+		{
+			name:         "Throttled",
+			method:       "POST",
+			inputBody:    `{"mfaToken": "challenge", "code": "000000"}`,
+			inputRequest: presenter.TwoFactorLogin{MfaToken: "challenge", Code: "000000"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.TwoFactorLogin) {
+				r.EXPECT().LoginTwoFactor(gomock.Any(), request, gomock.Any()).Return(presenter.TokenResponse{},
+					&service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:   429,
+			expectedRetryAfter:   "2",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Too Many Requests\",\"status\":429,\"detail\":\"Too many failed login attempts, try again later\",\"instance\":\"/api/auth/2fa\"}\n",
+		},
+		{
+			name:                 "Missing code",
+			method:               "POST",
+			inputBody:            `{"mfaToken": "challenge"}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.TwoFactorLogin) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/2fa\",\"errors\":[{\"field\":\"code\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Method Not Allowed",
+			method:               "GET",
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.TwoFactorLogin) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/auth/2fa\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/2fa", http.HandlerFunc(handler.authTwoFactor))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/auth/2fa",
+				bytes.NewBufferString(test.inputBody))
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Header().Get("Retry-After"), test.expectedRetryAfter)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_authTwoFactorEnroll(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser, request presenter.TwoFactorEnrollmentRequest)
+
+	tests := []struct {
+		name                 string
+		inputBody            string
+		inputRequest         presenter.TwoFactorEnrollmentRequest
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:         "Ok",
+			inputBody:    `{"mfaToken": "challenge"}`,
+			inputRequest: presenter.TwoFactorEnrollmentRequest{MfaToken: "challenge"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.TwoFactorEnrollmentRequest) {
+				r.EXPECT().EnrollTwoFactorLogin(gomock.Any(), request).Return(presenter.TwoFactorEnrollment{
+					Secret: "SECRET", Uri: "otpauth://totp/x", QrCode: []byte("png")}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"secret\":\"SECRET\",\"uri\":\"otpauth://totp/x\",\"qrCode\":\"cG5n\"}\n",
+		},
+		{
+			name:         "Expired token",
+			inputBody:    `{"mfaToken": "challenge"}`,
+			inputRequest: presenter.TwoFactorEnrollmentRequest{MfaToken: "challenge"},
+			mockBehavior: func(r *mock_service.MockUser, request presenter.TwoFactorEnrollmentRequest) {
+				r.EXPECT().EnrollTwoFactorLogin(gomock.Any(), request).Return(presenter.TwoFactorEnrollment{},
+					errors.New("Invalid or expired token"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid or expired token\",\"instance\":\"/api/auth/2fa/enroll\"}\n",
+		},
+		{
+			name:                 "Missing token",
+			inputBody:            `{}`,
+			mockBehavior:         func(r *mock_service.MockUser, request presenter.TwoFactorEnrollmentRequest) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/auth/2fa/enroll\",\"errors\":[{\"field\":\"mfaToken\",\"message\":\"is required\"}]}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo, test.inputRequest)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/auth/2fa/enroll", http.HandlerFunc(handler.authTwoFactorEnroll))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/auth/2fa/enroll",
+				bytes.NewBufferString(test.inputBody))
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_meTwoFactor(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		method               string
+		headerValue          string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Status",
+			method:      "GET",
+			headerValue: "Bearer ADMIN",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetTwoFactorStatus(gomock.Any(), 1).Return(presenter.TwoFactorStatus{
+					Enabled: true, Required: true, RecoveryCodesLeft: 8}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"enabled\":true,\"required\":true,\"recoveryCodesLeft\":8}\n",
+		},
+		{
+			name:        "Enroll",
+			method:      "POST",
+			headerValue: "Bearer USER",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().EnrollTwoFactor(gomock.Any(), 2).Return(presenter.TwoFactorEnrollment{
+					Secret: "SECRET", Uri: "otpauth://totp/x", QrCode: []byte("png")}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"secret\":\"SECRET\",\"uri\":\"otpauth://totp/x\",\"qrCode\":\"cG5n\"}\n",
+		},
+		{
+			name:        "Enroll when enabled",
+			method:      "POST",
+			headerValue: "Bearer USER",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().EnrollTwoFactor(gomock.Any(), 2).Return(presenter.TwoFactorEnrollment{}, service.ErrTwoFactorEnabled)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Two factor authentication is already enabled\",\"instance\":\"/api/me/2fa\"}\n",
+		},
+		{
+			name:        "Disable",
+			method:      "DELETE",
+			headerValue: "Bearer USER",
+			inputBody:   `{"currentPassword": "useruser", "code": "123456"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().DisableTwoFactor(gomock.Any(), 2, presenter.TwoFactorDisable{
+					CurrentPassword: "useruser", Code: "123456"}, gomock.Any()).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:        "Disable when required",
+			method:      "DELETE",
+			headerValue: "Bearer ADMIN",
+			inputBody:   `{"currentPassword": "adminadmin", "code": "123456"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().DisableTwoFactor(gomock.Any(), 1, presenter.TwoFactorDisable{
+					CurrentPassword: "adminadmin", Code: "123456"}, gomock.Any()).Return(service.ErrTwoFactorMandatory)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"detail\":\"Two factor authentication is required for your role\",\"instance\":\"/api/me/2fa\"}\n",
+		},
+		{
+			name:        "Disable throttled",
+			method:      "DELETE",
+			headerValue: "Bearer USER",
+			inputBody:   `{"currentPassword": "useruser", "code": "000000"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().DisableTwoFactor(gomock.Any(), 2, presenter.TwoFactorDisable{
+					CurrentPassword: "useruser", Code: "000000"}, gomock.Any()).Return(
+					&service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Too Many Requests\",\"status\":429,\"detail\":\"Too many failed login attempts, try again later\",\"instance\":\"/api/me/2fa\"}\n",
+		},
+		{
+			name:                 "Disable without code",
+			method:               "DELETE",
+			headerValue:          "Bearer USER",
+			inputBody:            `{"currentPassword": "useruser"}`,
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/me/2fa\",\"errors\":[{\"field\":\"code\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:                 "Unauthorized",
+			method:               "GET",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   401,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/me/2fa\"}\n",
+		},
+		{
+			name:                 "Method Not Allowed",
+			method:               "PUT",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/me/2fa\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me/2fa", pkg.MockJWTAuthUser(handler.meTwoFactor))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/me/2fa", bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", test.headerValue)
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_meTwoFactorCodes(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		method               string
+		inputBody            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:      "Confirm",
+			path:      "/api/me/2fa/confirm",
+			method:    "POST",
+			inputBody: `{"code": "123456"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ConfirmTwoFactor(gomock.Any(), 2, presenter.TwoFactorCode{Code: "123456"}, gomock.Any()).Return(
+					presenter.RecoveryCodes{RecoveryCodes: []string{"3f9a1-c07de", "e9572-5ba41"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"recoveryCodes\":[\"3f9a1-c07de\",\"e9572-5ba41\"]}\n",
+		},
+		{
+			name:      "Confirm invalid code",
+			path:      "/api/me/2fa/confirm",
+			method:    "POST",
+			inputBody: `{"code": "000000"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ConfirmTwoFactor(gomock.Any(), 2, presenter.TwoFactorCode{Code: "000000"}, gomock.Any()).Return(
+					presenter.RecoveryCodes{}, service.ErrTwoFactorInvalidCode)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Invalid two factor code\",\"instance\":\"/api/me/2fa/confirm\"}\n",
+		},
+		{
+			name:      "Confirm throttled",
+			path:      "/api/me/2fa/confirm",
+			method:    "POST",
+			inputBody: `{"code": "000000"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ConfirmTwoFactor(gomock.Any(), 2, presenter.TwoFactorCode{Code: "000000"}, gomock.Any()).Return(
+					presenter.RecoveryCodes{}, &service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Too Many Requests\",\"status\":429,\"detail\":\"Too many failed login attempts, try again later\",\"instance\":\"/api/me/2fa/confirm\"}\n",
+		},
+		{
+			name:                 "Confirm without code",
+			path:                 "/api/me/2fa/confirm",
+			method:               "POST",
+			inputBody:            `{}`,
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   422,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"Invalid request\",\"instance\":\"/api/me/2fa/confirm\",\"errors\":[{\"field\":\"code\",\"message\":\"is required\"}]}\n",
+		},
+		{
+			name:      "Regenerate recovery codes",
+			path:      "/api/me/2fa/recovery-codes",
+			method:    "POST",
+			inputBody: `{"code": "3f9a1-c07de"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RegenerateRecoveryCodes(gomock.Any(), 2, presenter.TwoFactorCode{Code: "3f9a1-c07de"}, gomock.Any()).Return(
+					presenter.RecoveryCodes{RecoveryCodes: []string{"e9572-5ba41"}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "{\"recoveryCodes\":[\"e9572-5ba41\"]}\n",
+		},
+		{
+			name:      "Regenerate throttled",
+			path:      "/api/me/2fa/recovery-codes",
+			method:    "POST",
+			inputBody: `{"code": "123456"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RegenerateRecoveryCodes(gomock.Any(), 2, presenter.TwoFactorCode{Code: "123456"}, gomock.Any()).Return(
+					presenter.RecoveryCodes{}, &service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+			},
+			expectedStatusCode:   429,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Too Many Requests\",\"status\":429,\"detail\":\"Too many failed login attempts, try again later\",\"instance\":\"/api/me/2fa/recovery-codes\"}\n",
+		},
+		{
+			name:      "Regenerate when disabled",
+			path:      "/api/me/2fa/recovery-codes",
+			method:    "POST",
+			inputBody: `{"code": "123456"}`,
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().RegenerateRecoveryCodes(gomock.Any(), 2, presenter.TwoFactorCode{Code: "123456"}, gomock.Any()).Return(
+					presenter.RecoveryCodes{}, service.ErrTwoFactorNotEnabled)
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Two factor authentication is not enabled\",\"instance\":\"/api/me/2fa/recovery-codes\"}\n",
+		},
+		{
+			name:                 "Method Not Allowed",
+			path:                 "/api/me/2fa/recovery-codes",
+			method:               "GET",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/me/2fa/recovery-codes\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/me/2fa/confirm", pkg.MockJWTAuthUser(handler.meTwoFactorConfirm))
+			mux.Handle("/api/me/2fa/recovery-codes", pkg.MockJWTAuthUser(handler.meRecoveryCodes))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, bytes.NewBufferString(test.inputBody))
+			req.Header.Add("Authorization", "Bearer USER")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_resetUserTwoFactor(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name: "Ok admin",
+			path: "/api/user/2/2fa",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ResetTwoFactor(gomock.Any(), 2).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name: "Not found",
+			path: "/api/user/9/2fa",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().ResetTwoFactor(gomock.Any(), 9).Return(errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/user/9/2fa\"}\n",
+		},
+		{
+			name:                 "Invalid id",
+			path:                 "/api/user/abc/2fa",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"abc\\\": invalid syntax\",\"instance\":\"/api/user/abc/2fa\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.resetUserTwoFactor))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", test.path, nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
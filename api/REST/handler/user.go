@@ -3,12 +3,14 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/pkg"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 var prefixUser = "/api/user/"
@@ -18,54 +20,95 @@ func (h *Handler) users(w http.ResponseWriter, r *http.Request) {
 	case "GET":
 		h.getUsers(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 }
 
 func (h *Handler) user(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/unlock") {
+		h.userUnlock(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/2fa") {
+		h.userTwoFactor(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/impersonate") {
+		h.userImpersonate(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/suspend") {
+		h.userSuspend(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/reinstate") {
+		h.userReinstate(w, r)
+		return
+	}
+	if strings.Contains(strings.TrimPrefix(r.URL.Path, prefixUser), "/") {
+		h.userSessions(w, r)
+		return
+	}
+
 	switch r.Method {
 	case "GET":
 		h.getUser(w, r)
 	case "PUT":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.putUser(w, r)
 	case "PATCH":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.patchUser(w, r)
 	case "DELETE":
-		if err := pkg.ValidateAdminRoleJWT(w, r); err != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserDelete); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 
 		h.deleteUser(w, r)
 	default:
-		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
+	}
+}
+
+func (h *Handler) userUnlock(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "POST":
+		if err := pkg.ValidatePermissionJWT(w, r, entity.PermissionUserWrite); err != nil {
+			pkg.WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		h.unlockUser(w, r)
+	default:
+		pkg.WriteProblem(w, r, http.StatusMethodNotAllowed, "")
 	}
 }
 
 // Get users
 // @Summary      Get users
-// @Description  Get users
+// @Description  Get users, optionally only those in a status
 // @Tags         users
 // @Accept       json
 // @Produce      json
+// @Param 		 status query string false "status" Enums(active, pending, suspended, banned)
 // @Success      200  {object} []presenter.UserResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /user [get]
 func (h *Handler) getUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.services.GetUsers()
+	users, err := h.services.GetUsers(r.Context(), r.URL.Query().Get("status"))
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusInternalServerError)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
@@ -79,9 +122,12 @@ func (h *Handler) getUsers(w http.ResponseWriter, r *http.Request) {
 // @Tags         users
 // @Accept       json
 // @Produce      json
+// @Param 		 If-None-Match header string false "ETags the client has"
 // @Success      200  {object}  presenter.UserResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the user"
+// @Success      304
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
 // @Router       /user/{id} [get]
 func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixUser)
@@ -89,9 +135,12 @@ func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.services.GetUserById(id)
+	user, err := h.services.GetUserById(r.Context(), id)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if pkg.NotModified(w, r, user.Version) {
 		return
 	}
 	reqBodyBytes := new(bytes.Buffer)
@@ -106,10 +155,15 @@ func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.UserRequest true "user"
 // @Success      200  {object}  presenter.UserResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the user"
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /user/{id} [put]
 func (h *Handler) putUser(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixUser)
@@ -117,27 +171,34 @@ func (h *Handler) putUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
 	var request presenter.UserRequest
-	err = json.NewDecoder(r.Body).Decode(&request)
+	err = pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	validate := validator.New()
 
 	if err := validate.Struct(request); err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
 		return
 	}
 
-	actor, err := h.services.PutUser(id, request)
+	actor, err := h.services.PutUser(r.Context(), id, version, request)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("ETag", pkg.ETag(actor.Version))
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(actor)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
@@ -145,15 +206,22 @@ func (h *Handler) putUser(w http.ResponseWriter, r *http.Request) {
 
 // Patch user by id only for ADMIN
 // @Summary      Patch user by id
-// @Description  Patch user by id
+// @Description  Patch user by id with the fields to change, a JSON Merge Patch or a JSON Patch
 // @Tags         users
-// @Accept       json
+// @Accept       json,application/merge-patch+json,application/json-patch+json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.UserRequest true "user"
 // @Success      200  {object}  presenter.UserResponse
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Header       200  {string}  ETag "version of the user"
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      409  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      415  {object}  pkg.Problem
+// @Failure      422  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /user/{id} [patch]
 func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixUser)
@@ -161,34 +229,104 @@ func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
+	patchType, ok := acceptPatch(w, r)
+	if !ok {
+		return
+	}
+	if patchType != "application/json" {
+		h.patchUserDocument(w, r, id, version, patchType)
+		return
+	}
+
 	var request presenter.UserRequest
-	err = json.NewDecoder(r.Body).Decode(&request)
+	err = pkg.DecodeJSON(r, &request)
 
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if request.Username != nil && len(*request.Username) < 2 {
-		pkg.HandleError(w, errors.New("username length must be greater than 2"), http.StatusBadRequest)
-		return
-	}
-	if request.Password != nil && len(*request.Password) < 8 {
-		pkg.HandleError(w, errors.New("password length must be greater than 8"), http.StatusBadRequest)
+		pkg.HandleError(w, r, pkg.InvalidField("username", "length must be greater than 2"), http.StatusBadRequest)
 		return
 	}
 
-	film, err := h.services.PatchUser(id, request)
+	film, err := h.services.PatchUser(r.Context(), id, version, request)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("ETag", pkg.ETag(film.Version))
 	reqBodyBytes := new(bytes.Buffer)
 	json.NewEncoder(reqBodyBytes).Encode(film)
 	fmt.Fprintf(w, "%s", reqBodyBytes.String())
 }
 
+// patchUserDocument applies a merge patch or JSON patch to the username and
+// role of the user and saves the result, validated like a PUT. The password
+// is not part of the document, a patch may add it to change it
+func (h *Handler) patchUserDocument(w http.ResponseWriter, r *http.Request, id, version int, patchType string) {
+	user, err := h.services.GetUserById(r.Context(), id)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if version != 0 && version != user.Version {
+		pkg.HandleError(w, r, &entity.PreconditionFailedError{Entity: "user"}, http.StatusPreconditionFailed)
+		return
+	}
+	current := struct {
+		Id       int    `json:"id"`
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}{user.Id, user.Username, user.Role}
+
+	var request presenter.UserRequest
+	if err := patchDocument(r, patchType, current, &request); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	var missing []string
+	if request.Username == nil {
+		missing = append(missing, "username")
+	}
+	if request.Role == nil {
+		missing = append(missing, "role")
+	}
+	if err := pkg.RequiredFields(missing...); err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	validate := validator.New()
+	if request.Password == nil {
+		err = validate.StructExcept(request, "Password")
+	} else {
+		err = validate.Struct(request)
+	}
+	if err != nil {
+		pkg.HandleError(w, r, pkg.InvalidRequest(err), http.StatusBadRequest)
+		return
+	}
+
+	// conditional on the version patched, so changes made meanwhile are not lost
+	user, err = h.services.PatchUser(r.Context(), id, user.Version, request)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("ETag", pkg.ETag(user.Version))
+	pkg.WriteJSON(w, http.StatusOK, user)
+}
+
 // Delete user by id only for ADMIN
 // @Summary      Delete user by id
 // @Description  Delete user by id
@@ -196,10 +334,13 @@ func (h *Handler) patchUser(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param 		 id   path 	int 	true "id"
+// @Param 		 If-Match header string false "ETag the write is conditional on"
 // @Param 		 request body presenter.UserRequest true "user"
 // @Success      200  {object}  string
-// @Failure      401  {object}  string
-// @Failure      403  {object}  string
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Failure      412  {object}  pkg.Problem
+// @Failure      428  {object}  pkg.Problem
 // @Router       /user/{id} [delete]
 func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 	id, err := pkg.GetPathId(w, r, prefixUser)
@@ -207,9 +348,41 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.services.DeleteUser(id)
+	version, err := pkg.IfMatch(r)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusPreconditionRequired)
+		return
+	}
+
+	err = h.services.DeleteUser(r.Context(), id, version)
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// Unlock user login only with user:write permission
+// @Summary      Unlock user login
+// @Description  Reset failed login attempts of user and lift the lockout
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param 		 id   path 	int 	true "id"
+// @Success      200  {object}  string
+// @Failure      400  {object}  pkg.Problem
+// @Failure      401  {object}  pkg.Problem
+// @Failure      403  {object}  pkg.Problem
+// @Router       /user/{id}/unlock [post]
+func (h *Handler) unlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, prefixUser), "/unlock"))
+	if err != nil {
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.services.UnlockUser(r.Context(), id)
 	if err != nil {
-		pkg.HandleError(w, err, http.StatusBadRequest)
+		pkg.HandleError(w, r, err, http.StatusBadRequest)
 		return
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/service"
 	mock_service "filmLibraryVk/internal/service/mocks"
 	"filmLibraryVk/pkg"
@@ -13,6 +14,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestHandler_getUsers(t *testing.T) {
@@ -22,37 +24,60 @@ func TestHandler_getUsers(t *testing.T) {
 		name                 string
 		headerName           string
 		headerValue          string
+		query                string
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
 		{
-			name:        "Ok user",
-			headerName:  "Authorization",
-			headerValue: "Bearer USER",
-			mockBehavior: func(r *mock_service.MockUser) {
-				r.EXPECT().GetUsers().Return([]presenter.UserResponse{
-					{Id: 1, Username: "username", Role: "USER"}}, nil)
-			},
-			expectedStatusCode:   200,
-			expectedResponseBody: "[{\"id\":1,\"username\":\"username\",\"role\":\"USER\"}]\n",
+			name:                 "Forbidden for user",
+			headerName:           "Authorization",
+			headerValue:          "Bearer USER",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user\"}\n",
 		},
 		{
 			name:        "Ok admin",
 			headerName:  "Authorization",
 			headerValue: "Bearer ADMIN",
 			mockBehavior: func(r *mock_service.MockUser) {
-				r.EXPECT().GetUsers().Return([]presenter.UserResponse{
+				r.EXPECT().GetUsers(gomock.Any(), "").Return([]presenter.UserResponse{
 					{Id: 1, Username: "username", Role: "ADMIN"}}, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: "[{\"id\":1,\"username\":\"username\",\"role\":\"ADMIN\"}]\n",
 		},
+		{
+			name:        "Ok filtered by status",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			query:       "?status=banned",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUsers(gomock.Any(), entity.UserStatusBanned).Return([]presenter.UserResponse{
+					{Id: 2, Username: "username", Role: "USER", Status: "banned", Suspension: &presenter.Suspension{
+						Reason: "spam", SuspendedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}}}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedResponseBody: "[{\"id\":2,\"username\":\"username\",\"role\":\"USER\",\"status\":\"banned\"," +
+				"\"suspension\":{\"reason\":\"spam\",\"suspendedAt\":\"2024-01-02T03:04:05Z\"}}]\n",
+		},
+		{
+			name:        "Unknown status",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			query:       "?status=deleted",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().GetUsers(gomock.Any(), "deleted").Return(nil, errors.New("Unknown user status deleted"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"Unknown user status deleted\",\"instance\":\"/api/user\"}\n",
+		},
 		{
 			name:                 "Unauthorized",
 			mockBehavior:         func(r *mock_service.MockUser) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -60,7 +85,7 @@ func TestHandler_getUsers(t *testing.T) {
 			headerValue:          "Bearer USE",
 			mockBehavior:         func(r *mock_service.MockUser) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -76,10 +101,10 @@ func TestHandler_getUsers(t *testing.T) {
 
 			mux := http.NewServeMux()
 
-			mux.Handle("/api/user", pkg.MockJWTAuthUser(handler.getUsers))
+			mux.Handle("/api/user", pkg.MockJWTAuthPermission(entity.PermissionUserRead, handler.getUsers))
 
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", "/api/user", nil)
+			req := httptest.NewRequest("GET", "/api/user"+test.query, nil)
 			req.Header.Add(test.headerName, test.headerValue)
 			mux.ServeHTTP(w, req)
 
@@ -102,26 +127,22 @@ func TestHandler_getUser(t *testing.T) {
 		expectedResponseBody string
 	}{
 		{
-			name:        "Ok user",
-			headerName:  "Authorization",
-			headerValue: "Bearer USER",
-			id:          "1",
-			mockBehavior: func(r *mock_service.MockUser, id string) {
-				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetUserById(idd).Return(presenter.UserResponse{
-					Id: 1, Username: "username", Role: "USER"}, nil)
-			},
-			expectedStatusCode:   200,
-			expectedResponseBody: "{\"id\":1,\"username\":\"username\",\"role\":\"USER\"}\n",
+			name:                 "Forbidden for user",
+			headerName:           "Authorization",
+			headerValue:          "Bearer USER",
+			id:                   "1",
+			mockBehavior:         func(r *mock_service.MockUser, id string) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:                 "Invalid id",
 			headerName:           "Authorization",
-			headerValue:          "Bearer USER",
+			headerValue:          "Bearer ADMIN",
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockUser, id string) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/user/1s\"}\n",
 		},
 		{
 			name:        "Ok admin",
@@ -130,7 +151,7 @@ func TestHandler_getUser(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockUser, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetUserById(idd).Return(presenter.UserResponse{
+				r.EXPECT().GetUserById(gomock.Any(), idd).Return(presenter.UserResponse{
 					Id: 1, Username: "username", Role: "ADMIN"}, nil)
 			},
 			expectedStatusCode:   200,
@@ -141,7 +162,7 @@ func TestHandler_getUser(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:                 "Invalid token",
@@ -150,7 +171,7 @@ func TestHandler_getUser(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -166,7 +187,7 @@ func TestHandler_getUser(t *testing.T) {
 
 			mux := http.NewServeMux()
 
-			mux.Handle("/api/user/", pkg.MockJWTAuthUser(handler.getUser))
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserRead, handler.getUser))
 
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", "/api/user/"+test.id, nil)
@@ -214,7 +235,7 @@ func TestHandler_putUser(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PutUser(idd, actor).Return(presenter.UserResponse{
+				r.EXPECT().PutUser(gomock.Any(), idd, 0, actor).Return(presenter.UserResponse{
 					Id:       1,
 					Username: "username",
 					Role:     "USER",
@@ -230,7 +251,7 @@ func TestHandler_putUser(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/user/1s\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -239,14 +260,14 @@ func TestHandler_putUser(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -303,7 +324,7 @@ func TestHandler_patchUser(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockUser, id string, user presenter.UserRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PatchUser(idd, user).Return(presenter.UserResponse{
+				r.EXPECT().PatchUser(gomock.Any(), idd, 0, user).Return(presenter.UserResponse{
 					Id:       1,
 					Username: "username",
 					Role:     "ADMIN",
@@ -319,7 +340,24 @@ func TestHandler_patchUser(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockUser, id string, user presenter.UserRequest) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/user/1s\"}\n",
+		},
+		{
+			name:        "Database error",
+			headerName:  "Authorization",
+			headerValue: "Bearer ADMIN",
+			id:          "1",
+			inputBody:   `{"role": "ADMIN"}`,
+			inputUser: presenter.UserRequest{
+				Role: role,
+			},
+			mockBehavior: func(r *mock_service.MockUser, id string, user presenter.UserRequest) {
+				idd, _ := strconv.Atoi(id)
+				r.EXPECT().PatchUser(gomock.Any(), idd, 0, user).Return(presenter.UserResponse{},
+					&entity.InternalError{Err: errors.New("pq: syntax error at or near \"WHERE\"")})
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Internal Server Error\",\"status\":500,\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:        "Entity not found",
@@ -332,10 +370,10 @@ func TestHandler_patchUser(t *testing.T) {
 			},
 			mockBehavior: func(r *mock_service.MockUser, id string, user presenter.UserRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().PatchUser(idd, user).Return(presenter.UserResponse{}, errors.New("entity not found"))
+				r.EXPECT().PatchUser(gomock.Any(), idd, 0, user).Return(presenter.UserResponse{}, errors.New("entity not found"))
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: "entity not found\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/user/-1\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -344,14 +382,14 @@ func TestHandler_patchUser(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string, user presenter.UserRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string, user presenter.UserRequest) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -400,7 +438,7 @@ func TestHandler_deleteUser(t *testing.T) {
 			id:          "1",
 			mockBehavior: func(r *mock_service.MockUser, id string) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().DeleteUser(idd)
+				r.EXPECT().DeleteUser(gomock.Any(), idd, 0)
 			},
 			expectedStatusCode: 200,
 		},
@@ -411,7 +449,7 @@ func TestHandler_deleteUser(t *testing.T) {
 			id:                   "1s",
 			mockBehavior:         func(r *mock_service.MockUser, id string) {},
 			expectedStatusCode:   400,
-			expectedResponseBody: "strconv.Atoi: parsing \"1s\": invalid syntax\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"1s\\\": invalid syntax\",\"instance\":\"/api/user/1s\"}\n",
 		},
 		{
 			name:                 "Forbidden for user",
@@ -420,14 +458,14 @@ func TestHandler_deleteUser(t *testing.T) {
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:                 "Unauthorized",
 			id:                   "1",
 			mockBehavior:         func(r *mock_service.MockUser, id string) {},
 			expectedStatusCode:   401,
-			expectedResponseBody: "Invalid JWT token\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Unauthorized\",\"status\":401,\"detail\":\"Invalid JWT token\",\"instance\":\"/api/user/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -473,7 +511,7 @@ func TestHandler_users_invalid_method(t *testing.T) {
 			headerValue:          "Bearer USER",
 			mockBehavior:         func(r *mock_service.MockUser) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/user\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -533,7 +571,7 @@ func TestHandler_user_invalid_method(t *testing.T) {
 			method:               "POST",
 			mockBehavior:         func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {},
 			expectedStatusCode:   405,
-			expectedResponseBody: "Method Not Allowed\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/user/\"}\n",
 		},
 		{
 			name:        "GET",
@@ -543,7 +581,7 @@ func TestHandler_user_invalid_method(t *testing.T) {
 			method:      "GET",
 			mockBehavior: func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {
 				idd, _ := strconv.Atoi(id)
-				r.EXPECT().GetUserById(idd).Return(presenter.UserResponse{
+				r.EXPECT().GetUserById(gomock.Any(), idd).Return(presenter.UserResponse{
 					Id: 1, Username: "username", Role: "ADMIN"}, nil)
 			},
 			expectedStatusCode:   200,
@@ -563,7 +601,7 @@ func TestHandler_user_invalid_method(t *testing.T) {
 			},
 			mockBehavior:         func(r *mock_service.MockUser, id string, actor presenter.UserRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1\"}\n",
 		},
 		{
 			name:        "PATCH",
@@ -577,7 +615,7 @@ func TestHandler_user_invalid_method(t *testing.T) {
 			},
 			mockBehavior:         func(r *mock_service.MockUser, id string, user presenter.UserRequest) {},
 			expectedStatusCode:   403,
-			expectedResponseBody: "Forbidden\n",
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/1\"}\n",
 		},
 	}
 	for _, test := range tests {
@@ -605,3 +643,107 @@ func TestHandler_user_invalid_method(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_unlockUser(t *testing.T) {
+	type mockBehavior func(r *mock_service.MockUser)
+
+	tests := []struct {
+		name                 string
+		method               string
+		path                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:   "Ok admin",
+			method: "POST",
+			path:   "/api/user/2/unlock",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().UnlockUser(gomock.Any(), 2).Return(nil)
+			},
+			expectedStatusCode: 200,
+		},
+		{
+			name:   "Not found",
+			method: "POST",
+			path:   "/api/user/9/unlock",
+			mockBehavior: func(r *mock_service.MockUser) {
+				r.EXPECT().UnlockUser(gomock.Any(), 9).Return(errors.New("entity not found"))
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"entity not found\",\"instance\":\"/api/user/9/unlock\"}\n",
+		},
+		{
+			name:                 "Invalid id",
+			method:               "POST",
+			path:                 "/api/user/abc/unlock",
+			mockBehavior:         func(r *mock_service.MockUser) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Bad Request\",\"status\":400,\"detail\":\"strconv.Atoi: parsing \\\"abc\\\": invalid syntax\",\"instance\":\"/api/user/abc/unlock\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			repo := mock_service.NewMockUser(c)
+			test.mockBehavior(repo)
+
+			services := &service.Service{User: repo}
+			handler := Handler{services}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserWrite, handler.unlockUser))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
+
+func TestHandler_userUnlock_routing(t *testing.T) {
+	tests := []struct {
+		name                 string
+		method               string
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Method Not Allowed",
+			method:               "GET",
+			expectedStatusCode:   405,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Method Not Allowed\",\"status\":405,\"instance\":\"/api/user/2/unlock\"}\n",
+		},
+		{
+			name:                 "POST without user:write in token",
+			method:               "POST",
+			expectedStatusCode:   403,
+			expectedResponseBody: "{\"type\":\"about:blank\",\"title\":\"Forbidden\",\"status\":403,\"instance\":\"/api/user/2/unlock\"}\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Handler{&service.Service{}}
+
+			mux := http.NewServeMux()
+
+			mux.Handle("/api/user/", pkg.MockJWTAuthPermission(entity.PermissionUserRead, handler.user))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, "/api/user/2/unlock", nil)
+			req.Header.Add("Authorization", "Bearer ADMIN")
+			mux.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), test.expectedResponseBody)
+		})
+	}
+}
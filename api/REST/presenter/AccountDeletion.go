@@ -0,0 +1,5 @@
+package presenter
+
+type AccountDeletion struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+}
@@ -2,13 +2,15 @@ package presenter
 
 import (
 	"encoding/json"
+	"filmLibraryVk/internal/model/entity"
+	"strings"
 	"time"
 )
 
 type ActorRequest struct {
 	Name     *string    `json:"name"`
 	Sex      *string    `json:"sex"`
-	Birthday *time.Time `json:"birthday"`
+	Birthday *time.Time `json:"birthday" time:"2006-01-02"`
 	FilmsId  *[]int     `json:"filmsId"`
 }
 
@@ -24,18 +26,25 @@ func (actor *ActorRequest) UnmarshalJSON(p []byte) error {
 	if err != nil {
 		return err
 	}
-	var t time.Time
-
+	// an absent or null date stays nil
+	var date *time.Time
 	if aux.Birthday != nil {
-		t, err = time.Parse(dateFormat, *aux.Birthday)
+		t, err := time.Parse(dateFormat, *aux.Birthday)
 		if err != nil {
-			return err
+			return &entity.ValidationError{Message: "Invalid request",
+				Fields: []entity.FieldError{{Field: "birthday", Message: "must be a date like " + dateFormat}}}
 		}
+		date = &t
+	}
+	// the sex table holds lowercase values only
+	if aux.Sex != nil {
+		sex := strings.ToLower(strings.TrimSpace(*aux.Sex))
+		aux.Sex = &sex
 	}
 
 	actor.Name = aux.Name
 	actor.Sex = aux.Sex
-	actor.Birthday = &t
+	actor.Birthday = date
 	actor.FilmsId = aux.FilmsId
 
 	return nil
@@ -1,9 +1,11 @@
 package presenter
 
 type ActorResponse struct {
-	Id       int    `json:"id""`
-	Name     string `json:"name""`
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
 	Sex      string `json:"sex"`
 	Birthday string `json:"birthday"`
 	FilmsId  []int  `json:"filmsId"`
+	// sent as the ETag, not in the body
+	Version int `json:"-"`
 }
@@ -0,0 +1,8 @@
+package presenter
+
+type ApiKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+	// lifetime in seconds, 0 for a key that does not expire
+	ExpiresIn int `json:"expiresIn" validate:"min=0"`
+}
@@ -0,0 +1,17 @@
+package presenter
+
+import "time"
+
+type ApiKeyResponse struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	// only returned on creation
+	Key        string     `json:"key,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
@@ -0,0 +1,66 @@
+package presenter
+
+import "filmLibraryVk/internal/model/entity"
+
+const (
+	// BatchAtomic applies all operations of a batch or, if one fails, none
+	BatchAtomic = "atomic"
+	// BatchPartial applies each operation of a batch on its own
+	BatchPartial = "partial"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+type FilmBatchRequest struct {
+	// atomic, the default, or partial
+	Mode       string               `json:"mode" example:"atomic"`
+	Operations []FilmBatchOperation `json:"operations"`
+}
+
+type FilmBatchOperation struct {
+	// create, update or delete
+	Op string `json:"op" example:"create"`
+	// film to update or delete
+	Id int `json:"id,omitempty"`
+	// version an update or delete is conditional on, like If-Match
+	Version int `json:"version,omitempty"`
+	// film to create or put, for update
+	Film FilmRequest `json:"film"`
+}
+
+type ActorBatchRequest struct {
+	// atomic, the default, or partial
+	Mode       string                `json:"mode" example:"atomic"`
+	Operations []ActorBatchOperation `json:"operations"`
+}
+
+type ActorBatchOperation struct {
+	// create, update or delete
+	Op string `json:"op" example:"create"`
+	// actor to update or delete
+	Id int `json:"id,omitempty"`
+	// version an update or delete is conditional on, like If-Match
+	Version int `json:"version,omitempty"`
+	// actor to create or put, for update
+	Actor ActorRequest `json:"actor"`
+}
+
+// BatchResponse has a result per operation, in the order of the request
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the status an operation would have got as a single request,
+// with the problem detail and invalid fields if it failed
+type BatchResult struct {
+	Op      string              `json:"op" example:"create"`
+	Id      int                 `json:"id,omitempty" example:"1"`
+	Version int                 `json:"version,omitempty" example:"2"`
+	Status  int                 `json:"status" example:"201"`
+	Detail  string              `json:"detail,omitempty"`
+	Errors  []entity.FieldError `json:"errors,omitempty"`
+}
@@ -0,0 +1,6 @@
+package presenter
+
+type Client struct {
+	UserAgent string
+	Ip        string
+}
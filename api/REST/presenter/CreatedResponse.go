@@ -0,0 +1,6 @@
+package presenter
+
+// CreatedResponse is the body of a 201, the entity is at the Location header
+type CreatedResponse struct {
+	Id int `json:"id"`
+}
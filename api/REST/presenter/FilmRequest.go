@@ -2,6 +2,7 @@ package presenter
 
 import (
 	"encoding/json"
+	"filmLibraryVk/internal/model/entity"
 	"time"
 )
 
@@ -10,7 +11,7 @@ const dateFormat = "2006-01-02"
 type FilmRequest struct {
 	Name        *string    `json:"name" validate:"min=1,max=150"`
 	Description *string    `json:"description" validate:"max=1000"`
-	ReleaseDate *time.Time `json:"releaseDate"`
+	ReleaseDate *time.Time `json:"releaseDate" time:"2006-01-02"`
 	Rating      *int       `json:"rating" validate:"min=0,max=10"`
 	ActorsId    *[]int     `json:"actorsId"`
 }
@@ -28,17 +29,19 @@ func (film *FilmRequest) UnmarshalJSON(p []byte) error {
 	if err != nil {
 		return err
 	}
-	var t time.Time
-
+	// an absent or null date stays nil
+	var date *time.Time
 	if aux.ReleaseDate != nil {
-		t, err = time.Parse(dateFormat, *aux.ReleaseDate)
+		t, err := time.Parse(dateFormat, *aux.ReleaseDate)
 		if err != nil {
-			return err
+			return &entity.ValidationError{Message: "Invalid request",
+				Fields: []entity.FieldError{{Field: "releaseDate", Message: "must be a date like " + dateFormat}}}
 		}
+		date = &t
 	}
 
 	film.Name = aux.Name
-	film.ReleaseDate = &t
+	film.ReleaseDate = date
 	film.Description = aux.Description
 	film.Rating = aux.Rating
 	film.ActorsId = aux.ActorsId
@@ -4,7 +4,9 @@ type FilmResponse struct {
 	Id          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	ReleaseDate string `json"releaseDate"`
-	Rating      int    `json:"rating`
+	ReleaseDate string `json:"releaseDate"`
+	Rating      int    `json:"rating"`
 	ActorsId    []int  `json:"actorsId"`
+	// sent as the ETag, not in the body
+	Version int `json:"-"`
 }
@@ -0,0 +1,5 @@
+package presenter
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
+}
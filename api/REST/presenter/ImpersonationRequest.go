@@ -0,0 +1,6 @@
+package presenter
+
+type ImpersonationRequest struct {
+	// why support acts as the user, kept in the audit trail
+	Reason string `json:"reason" validate:"required,max=500"`
+}
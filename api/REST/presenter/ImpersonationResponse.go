@@ -0,0 +1,8 @@
+package presenter
+
+type ImpersonationResponse struct {
+	Id          int    `json:"id"`
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
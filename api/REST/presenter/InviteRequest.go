@@ -0,0 +1,5 @@
+package presenter
+
+type InviteRequest struct {
+	ExpiresIn int `json:"expiresIn" validate:"min=0"`
+}
@@ -0,0 +1,13 @@
+package presenter
+
+import "time"
+
+type InviteResponse struct {
+	Id        int        `json:"id"`
+	Code      string     `json:"code,omitempty"`
+	CreatedBy *int       `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedBy    *int       `json:"usedBy"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
@@ -2,5 +2,5 @@ package presenter
 
 type Login struct {
 	Username string `json:"username" validate:"min=2"`
-	Password string `json:"password" validate:"min=8,max=20"`
+	Password string `json:"password" validate:"required,max=128"`
 }
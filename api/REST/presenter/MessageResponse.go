@@ -0,0 +1,5 @@
+package presenter
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,6 @@
+package presenter
+
+type OIDCCallback struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
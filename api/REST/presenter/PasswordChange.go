@@ -0,0 +1,6 @@
+package presenter
+
+type PasswordChange struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,max=128"`
+}
@@ -0,0 +1,5 @@
+package presenter
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
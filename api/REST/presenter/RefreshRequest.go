@@ -0,0 +1,5 @@
+package presenter
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
@@ -1,6 +1,8 @@
 package presenter
 
 type Register struct {
-	Username string `json:"username" validate:"min=2"`
-	Password string `json:"password" validate:"min=8,max=20"`
+	Username   string `json:"username" validate:"min=2"`
+	Password   string `json:"password" validate:"required,max=128"`
+	Email      string `json:"email,omitempty" validate:"omitempty,email"`
+	InviteCode string `json:"inviteCode,omitempty"`
 }
@@ -0,0 +1,6 @@
+package presenter
+
+type ResetPassword struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,max=128"`
+}
@@ -0,0 +1,6 @@
+package presenter
+
+type RoleRequest struct {
+	Role        string   `json:"role" validate:"min=2,max=50"`
+	Permissions []string `json:"permissions" validate:"required"`
+}
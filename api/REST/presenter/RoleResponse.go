@@ -0,0 +1,7 @@
+package presenter
+
+type RoleResponse struct {
+	Id          int      `json:"id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,12 @@
+package presenter
+
+import "time"
+
+type SessionResponse struct {
+	Id         int       `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	Current    bool      `json:"current"`
+}
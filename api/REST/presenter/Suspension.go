@@ -0,0 +1,19 @@
+package presenter
+
+import "time"
+
+type SuspensionRequest struct {
+	// shown to the user when tokens are refused
+	Reason string `json:"reason" validate:"required,max=500"`
+	// lifetime in seconds, 0 for a suspension lasting until the user is reinstated
+	ExpiresIn int `json:"expiresIn" validate:"min=0"`
+	// ban the user instead, a ban does not expire
+	Ban bool `json:"ban"`
+}
+
+type Suspension struct {
+	Reason      string     `json:"reason"`
+	Until       *time.Time `json:"until,omitempty"`
+	SuspendedBy *int       `json:"suspendedBy,omitempty"`
+	SuspendedAt time.Time  `json:"suspendedAt"`
+}
@@ -0,0 +1,10 @@
+package presenter
+
+type TokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+	// issued when two factor enrollment is completed during login
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
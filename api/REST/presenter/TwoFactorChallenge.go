@@ -0,0 +1,7 @@
+package presenter
+
+type TwoFactorChallenge struct {
+	MfaToken           string `json:"mfaToken"`
+	EnrollmentRequired bool   `json:"enrollmentRequired"`
+	ExpiresIn          int    `json:"expiresIn"`
+}
@@ -0,0 +1,5 @@
+package presenter
+
+type TwoFactorCode struct {
+	Code string `json:"code" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package presenter
+
+type TwoFactorDisable struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Code            string `json:"code" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package presenter
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+	// PNG image of a QR code with Uri, base64 encoded
+	QrCode []byte `json:"qrCode" swaggertype:"string" format:"base64"`
+}
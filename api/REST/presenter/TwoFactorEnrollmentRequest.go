@@ -0,0 +1,5 @@
+package presenter
+
+type TwoFactorEnrollmentRequest struct {
+	MfaToken string `json:"mfaToken" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package presenter
+
+type TwoFactorLogin struct {
+	MfaToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -2,6 +2,6 @@ package presenter
 
 type UserRequest struct {
 	Username *string `json:"username" validate:"min=2"`
-	Password *string `json:"password" validate:"min=8,max=20"`
+	Password *string `json:"password" validate:"required,max=128"`
 	Role     *string `json:"role"`
 }
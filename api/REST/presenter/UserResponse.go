@@ -3,5 +3,11 @@ package presenter
 type UserResponse struct {
 	Id       int    `json:"id"`
 	Username string `json:"username"`
-	Role     string `json:"role""`
+	Role     string `json:"role"`
+	Status   string `json:"status,omitempty"`
+	Email    string `json:"email,omitempty"`
+	// set while the user is suspended or banned
+	Suspension *Suspension `json:"suspension,omitempty"`
+	// sent as the ETag, not in the body
+	Version int `json:"-"`
 }
@@ -0,0 +1,6 @@
+package presenter
+
+type UsernameChange struct {
+	Username        string `json:"username" validate:"min=2"`
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+}
@@ -0,0 +1,5 @@
+package presenter
+
+type VerifyEmail struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -1,6 +1,5 @@
 package main
 
-
 import (
 	"filmLibraryVk/api/REST/handler"
 	"filmLibraryVk/internal/repository"
@@ -10,6 +9,8 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 func main() {
@@ -21,6 +22,23 @@ func main() {
 		log.Fatalf("can not load env variables: %s", err.Error())
 	}
 
+	var keys []pkg.KeyConfig
+	if err := viper.UnmarshalKey("jwt.keys", &keys); err != nil {
+		log.Fatalf("can not read jwt keys config: %s", err.Error())
+	}
+	if err := pkg.LoadKeys(viper.GetString("jwt.signing_kid"), keys); err != nil {
+		log.Fatalf("can not load jwt keys: %s", err.Error())
+	}
+
+	tokens := pkg.TokenConfig{
+		AccessTTL:  envSeconds("JWT_EXPIRATION"),
+		RefreshTTL: envSeconds("JWT_REFRESH_EXPIRATION"),
+	}
+	if err := tokens.Validate(); err != nil {
+		log.Fatalf("invalid JWT_EXPIRATION or JWT_REFRESH_EXPIRATION: %s", err.Error())
+	}
+	pkg.SetTokenConfig(tokens)
+
 	db, err := repository.NewPostgresDB(repository.Config{
 		Host:         viper.GetString("db.host"),
 		Port:         viper.GetString("db.port"),
@@ -34,14 +52,175 @@ func main() {
 		log.Fatalf("can not initialize db: %s", err.Error())
 	}
 
+	registration := service.RegistrationConfig{
+		Mode:        viper.GetString("registration.mode"),
+		DefaultRole: viper.GetString("registration.default_role"),
+		InviteTTL:   viper.GetDuration("registration.invite_ttl"),
+		VerifyEmail: viper.GetBool("registration.verify_email"),
+	}
+	if err := registration.Validate(); err != nil {
+		log.Fatalf("invalid registration config: %s", err.Error())
+	}
+
+	login := service.LoginConfig{
+		MaxFailures:   viper.GetInt("login.max_failures"),
+		IpMaxFailures: viper.GetInt("login.ip_max_failures"),
+		BaseDelay:     viper.GetDuration("login.base_delay"),
+		MaxDelay:      viper.GetDuration("login.max_delay"),
+		Lockout:       viper.GetDuration("login.lockout"),
+		ResetAfter:    viper.GetDuration("login.reset_after"),
+	}
+	if err := login.Validate(); err != nil {
+		log.Fatalf("invalid login config: %s", err.Error())
+	}
+
+	account := service.AccountConfig{
+		PasswordResetTTL:     viper.GetDuration("account.password_reset_ttl"),
+		EmailVerificationTTL: viper.GetDuration("account.email_verification_ttl"),
+	}
+	if err := account.Validate(); err != nil {
+		log.Fatalf("invalid account config: %s", err.Error())
+	}
+
+	twoFactor := service.TwoFactorConfig{
+		Issuer:          viper.GetString("two_factor.issuer"),
+		RequireForAdmin: viper.GetBool("two_factor.require_for_admin"),
+		ChallengeTTL:    viper.GetDuration("two_factor.challenge_ttl"),
+		Skew:            viper.GetInt("two_factor.skew"),
+	}
+	if err := twoFactor.Validate(); err != nil {
+		log.Fatalf("invalid two factor config: %s", err.Error())
+	}
+
+	pkg.SetPasswordHasher(&pkg.Argon2idHasher{
+		Memory:      viper.GetUint32("password.argon2id.memory"),
+		Iterations:  viper.GetUint32("password.argon2id.iterations"),
+		Parallelism: uint8(viper.GetUint("password.argon2id.parallelism")),
+		SaltLength:  16,
+		KeyLength:   32,
+	})
+
+	breached, err := service.LoadBreachedPasswords(viper.GetString("password.breached_list"))
+	if err != nil {
+		log.Fatalf("can not load breached passwords: %s", err.Error())
+	}
+	password := service.PasswordConfig{
+		MinLength:     viper.GetInt("password.min_length"),
+		MaxLength:     viper.GetInt("password.max_length"),
+		RequireUpper:  viper.GetBool("password.require_upper"),
+		RequireLower:  viper.GetBool("password.require_lower"),
+		RequireDigit:  viper.GetBool("password.require_digit"),
+		RequireSymbol: viper.GetBool("password.require_symbol"),
+		Breached:      breached,
+	}
+	if err := password.Validate(); err != nil {
+		log.Fatalf("invalid password config: %s", err.Error())
+	}
+
+	mailer, err := pkg.NewMailer(pkg.MailerConfig{
+		Driver:       viper.GetString("mail.driver"),
+		From:         viper.GetString("mail.from"),
+		OutboxDir:    viper.GetString("mail.outbox_dir"),
+		SMTPHost:     viper.GetString("mail.smtp.host"),
+		SMTPPort:     viper.GetInt("mail.smtp.port"),
+		SMTPUsername: viper.GetString("mail.smtp.username"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+	})
+	if err != nil {
+		log.Fatalf("can not initialize mailer: %s", err.Error())
+	}
+
+	oidcConfig := service.OIDCConfig{
+		Enabled:     viper.GetBool("oidc.enabled"),
+		DefaultRole: viper.GetString("oidc.default_role"),
+		LoginTTL:    viper.GetDuration("oidc.login_ttl"),
+	}
+	if err := viper.UnmarshalKey("oidc.role_mapping", &oidcConfig.RoleMapping); err != nil {
+		log.Fatalf("can not read oidc role mapping: %s", err.Error())
+	}
+	if err := oidcConfig.Validate(); err != nil {
+		log.Fatalf("invalid oidc config: %s", err.Error())
+	}
+
+	impersonation := service.ImpersonationConfig{
+		TTL: viper.GetDuration("impersonation.ttl"),
+	}
+	if err := impersonation.Validate(); err != nil {
+		log.Fatalf("invalid impersonation config: %s", err.Error())
+	}
+
+	anonymous := service.AnonymousConfig{
+		Enabled:    viper.GetBool("anonymous.enabled"),
+		Role:       viper.GetString("anonymous.role"),
+		RateLimit:  viper.GetInt("anonymous.rate_limit"),
+		RatePeriod: viper.GetDuration("anonymous.rate_period"),
+	}
+	if err := anonymous.Validate(); err != nil {
+		log.Fatalf("invalid anonymous config: %s", err.Error())
+	}
+
+	idempotency := service.IdempotencyConfig{
+		TTL: viper.GetDuration("idempotency.ttl"),
+	}
+	if err := idempotency.Validate(); err != nil {
+		log.Fatalf("invalid idempotency config: %s", err.Error())
+	}
+
+	deadline := pkg.DeadlineConfig{
+		Read:  viper.GetDuration("deadline.read"),
+		Write: viper.GetDuration("deadline.write"),
+	}
+	if err := deadline.Validate(); err != nil {
+		log.Fatalf("invalid deadline config: %s", err.Error())
+	}
+
+	bodyLimit := pkg.BodyLimitConfig{
+		MaxBytes: viper.GetInt64("request.max_body_bytes"),
+	}
+	if err := bodyLimit.Validate(); err != nil {
+		log.Fatalf("invalid request config: %s", err.Error())
+	}
+
+	pkg.RequireIfMatch(viper.GetBool("concurrency.require_if_match"))
+
+	var oidc service.OIDCProvider
+	if oidcConfig.Enabled {
+		oidc, err = pkg.NewOIDCClient(pkg.OIDCConfig{
+			Issuer:       viper.GetString("oidc.issuer"),
+			ClientId:     viper.GetString("oidc.client_id"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  viper.GetString("oidc.redirect_url"),
+			Scopes:       viper.GetStringSlice("oidc.scopes"),
+			GroupsClaim:  viper.GetString("oidc.groups_claim"),
+		})
+		if err != nil {
+			log.Fatalf("can not initialize oidc client: %s", err.Error())
+		}
+	}
+
 	repo := repository.NewRepository(db)
-	services := service.NewService(repo)
+	services := service.NewService(repo, mailer, oidc, service.Config{
+		Registration:  registration,
+		Login:         login,
+		Account:       account,
+		TwoFactor:     twoFactor,
+		Password:      password,
+		OIDC:          oidcConfig,
+		Impersonation: impersonation,
+		Anonymous:     anonymous,
+		Idempotency:   idempotency,
+	})
 	handlers := handler.NewHandler(services)
+	pkg.SetRevocationList(services.User)
+	pkg.SetApiKeyAuthenticator(services.ApiKey)
+	if anonymous.Enabled {
+		pkg.SetAnonymousAccess(services.Anonymous, pkg.NewRateLimiter(anonymous.RateLimit, anonymous.RatePeriod))
+	}
 
 	srv := new(pkg.Server)
 
-
-	if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
+	if err := srv.Run(viper.GetString("port"), pkg.RequestId(pkg.Deadline(deadline,
+		pkg.LimitBody(bodyLimit, handlers.InitRoutes())))); err != nil {
 		log.Fatalf("can not run http server: %s", err.Error())
 	}
 }
@@ -51,3 +230,13 @@ func initConfig() error {
 	viper.SetConfigName("config")
 	return viper.ReadInConfig()
 }
+
+// envSeconds reads a duration in seconds from the environment, 0 if it is not
+// set or not a number
+func envSeconds(name string) time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
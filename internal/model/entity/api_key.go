@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+type ApiKey struct {
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	Permissions []string   `json:"permissions"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+	RevokedAt   *time.Time `json:"revokedAt"`
+}
@@ -0,0 +1,9 @@
+package entity
+
+// BatchResult is the outcome of one operation of a batch, Err if it failed
+type BatchResult struct {
+	Id int
+	// version of an updated entity
+	Version int
+	Err     error
+}
@@ -0,0 +1,100 @@
+package entity
+
+import "strings"
+
+// NotFoundError is returned for an entity that does not exist
+type NotFoundError struct {
+	Entity string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Entity == "" {
+		return "entity not found"
+	}
+	return e.Entity + " not found"
+}
+
+// ConflictError is returned for a write clashing with stored data, like a
+// duplicate name or a delete of an entity still in use. Constraint names the
+// database constraint that was violated, if any
+type ConflictError struct {
+	Message    string
+	Constraint string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// ValidationError is returned for input that can not be accepted. Fields
+// says what is wrong with which field, if known. Constraint names the database
+// constraint that rejected it, if any
+type ValidationError struct {
+	Message    string
+	Fields     []FieldError
+	Constraint string
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+" "+field.Message)
+	}
+	return e.Message + ": " + strings.Join(fields, ", ")
+}
+
+// ForbiddenError is returned for an action the caller is not allowed to take
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+// UnavailableError is returned when the database or another dependency can
+// not serve the request. Err is logged, clients only see that it may pass later
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return "Service is temporarily unavailable"
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// InternalError is returned for a failure of the database or another
+// dependency no other error describes. Err is logged, clients are not told
+// more than that the request failed
+type InternalError struct {
+	Err error
+}
+
+func (e *InternalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// PreconditionFailedError is returned for a conditional write to an entity
+// that was changed, or deleted, since the version the client read
+type PreconditionFailedError struct {
+	Entity string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return e.Entity + " was modified since it was read"
+}
@@ -0,0 +1,12 @@
+package entity
+
+// IdempotentRequest is a request made with an Idempotency-Key and the response
+// it got, replayed when the request is retried
+type IdempotentRequest struct {
+	Fingerprint string
+	// 0 while the first request is still being processed
+	Status      int
+	ContentType string
+	Location    string
+	Body        []byte
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+const (
+	LoginFailureUsername = "username"
+	LoginFailureIp       = "ip"
+)
+
+type LoginFailure struct {
+	Kind          string     `json:"kind"`
+	Subject       string     `json:"subject"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil"`
+}
@@ -0,0 +1,7 @@
+package entity
+
+// OIDCLogin is a started single sign-on login waiting for the provider callback
+type OIDCLogin struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+}
@@ -0,0 +1,23 @@
+package entity
+
+const (
+	PermissionFilmRead   = "film:read"
+	PermissionFilmWrite  = "film:write"
+	PermissionFilmDelete = "film:delete"
+
+	PermissionActorRead   = "actor:read"
+	PermissionActorWrite  = "actor:write"
+	PermissionActorDelete = "actor:delete"
+
+	PermissionUserRead   = "user:read"
+	PermissionUserWrite  = "user:write"
+	PermissionUserDelete = "user:delete"
+	// act as another user with an impersonation token
+	PermissionUserImpersonate = "user:impersonate"
+
+	PermissionRoleRead  = "role:read"
+	PermissionRoleWrite = "role:write"
+
+	PermissionApiKeyRead  = "apikey:read"
+	PermissionApiKeyWrite = "apikey:write"
+)
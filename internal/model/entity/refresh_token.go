@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type RefreshToken struct {
+	Id               int        `json:"id"`
+	UserId           int        `json:"userId"`
+	SessionId        int        `json:"sessionId"`
+	TokenHash        string     `json:"-"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	ExpiresAt        time.Time  `json:"expiresAt"`
+	RevokedAt        *time.Time `json:"revokedAt"`
+	SessionRevokedAt *time.Time `json:"sessionRevokedAt"`
+}
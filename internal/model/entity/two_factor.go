@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+type TwoFactor struct {
+	UserId       int        `json:"userId"`
+	Secret       string     `json:"secret"`
+	EnabledAt    *time.Time `json:"enabledAt"`
+	LastUsedStep int64      `json:"lastUsedStep"`
+}
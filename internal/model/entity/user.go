@@ -1,8 +1,29 @@
 package entity
 
+import "time"
+
+const (
+	UserStatusActive    = "active"
+	UserStatusPending   = "pending"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeTwoFactor         = "two_factor"
+)
+
 type User struct {
-	Id       int    `json:"id"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	RoleId   int    `json:"roleId"`
+	Id            int    `json:"id"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	RoleId        int    `json:"roleId"`
+	Status        string `json:"status"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	// set while the user is suspended or banned
+	SuspensionReason string     `json:"suspensionReason"`
+	SuspendedUntil   *time.Time `json:"suspendedUntil"`
 }
@@ -1,9 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"fmt"
 	"log"
 	"strconv"
@@ -11,38 +12,38 @@ import (
 )
 
 type ActorRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewActorRepo(db *sql.DB) *ActorRepo {
+func NewActorRepo(db DBTX) *ActorRepo {
 	return &ActorRepo{db: db}
 }
 
-func (r *ActorRepo) GetActor(id int) (presenter.ActorResponse, error) {
+func (r *ActorRepo) GetActor(ctx context.Context, id int) (presenter.ActorResponse, error) {
 	act := presenter.ActorResponse{}
 	filmsId := make([]int, 0)
 	var birthday string
 	var filmId sql.NullInt64
 
-	query, err := r.db.Prepare("SELECT actor.id, name, sex, birthday, film_id FROM actor " +
-		"LEFT JOIN actor_film ON actor.id = actor_film.actor_id " +
+	query, err := r.db.PrepareContext(ctx, "SELECT actor.id, name, sex, birthday, version, film_id FROM actor "+
+		"LEFT JOIN actor_film ON actor.id = actor_film.actor_id "+
 		"WHERE actor.id = $1")
 
 	if err != nil {
-		return presenter.ActorResponse{}, err
+		return presenter.ActorResponse{}, dbError(err)
 	}
 
 	defer query.Close()
-	rows, err := query.Query(id)
+	rows, err := query.QueryContext(ctx, id)
 
 	if err != nil {
-		return presenter.ActorResponse{}, err
+		return presenter.ActorResponse{}, dbError(err)
 	}
 
 	for rows.Next() {
-		err = rows.Scan(&act.Id, &act.Name, &act.Sex, &birthday, &filmId)
+		err = rows.Scan(&act.Id, &act.Name, &act.Sex, &birthday, &act.Version, &filmId)
 		if err != nil {
-			return presenter.ActorResponse{}, err
+			return presenter.ActorResponse{}, dbError(err)
 		}
 		act.Birthday = strings.Split(birthday, "T")[0]
 		if filmId.Valid {
@@ -50,14 +51,14 @@ func (r *ActorRepo) GetActor(id int) (presenter.ActorResponse, error) {
 		}
 	}
 	if act.Id != id {
-		return presenter.ActorResponse{}, errors.New("entity not found")
+		return presenter.ActorResponse{}, &entity.NotFoundError{Entity: "actor"}
 	}
 	act.FilmsId = filmsId
 	log.Printf("Get actor with id %d", id)
 	return act, nil
 }
 
-func (r *ActorRepo) GetActors() ([]presenter.ActorResponse, error) {
+func (r *ActorRepo) GetActors(ctx context.Context) ([]presenter.ActorResponse, error) {
 	actors := make([]presenter.ActorResponse, 0)
 	isActorExistsMap := make(map[int]int)
 	mapFilms := make(map[int][]int)
@@ -66,21 +67,21 @@ func (r *ActorRepo) GetActors() ([]presenter.ActorResponse, error) {
 	var birthday string
 	var filmId sql.NullInt64
 
-	query, err := r.db.Prepare("SELECT actor.id, name, sex, birthday, film_id FROM actor " +
+	query, err := r.db.PrepareContext(ctx, "SELECT actor.id, name, sex, birthday, film_id FROM actor "+
 		"LEFT JOIN actor_film ON actor.id = actor_film.actor_id ")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer query.Close()
-	rows, err := query.Query()
+	rows, err := query.QueryContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	for rows.Next() {
 		err = rows.Scan(&act.Id, &act.Name, &act.Sex, &birthday, &filmId)
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 		act.Birthday = strings.Split(birthday, "T")[0]
 
@@ -105,84 +106,78 @@ func (r *ActorRepo) GetActors() ([]presenter.ActorResponse, error) {
 	return actors, nil
 }
 
-func (r *ActorRepo) CreateActor(request presenter.ActorRequest) (int, error) {
+func (r *ActorRepo) CreateActor(ctx context.Context, request presenter.ActorRequest) (int, error) {
 	var id int
-	query, err := r.db.Prepare("INSERT INTO actor (name, sex, birthday) VALUES ($1, $2, $3) RETURNING id")
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO actor (name, sex, birthday) VALUES ($1, $2, $3) RETURNING id")
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	defer query.Close()
-	row, err := query.Query(*request.Name, *request.Sex, *request.Birthday)
+	row, err := query.QueryContext(ctx, *request.Name, *request.Sex, *request.Birthday)
 
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&id); err != nil {
-			return 0, err
+			return 0, dbError(err)
 		}
 	}
 
-	query, err = r.db.Prepare("INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
+	query, err = r.db.PrepareContext(ctx, "INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
+	defer query.Close()
 
 	for _, val := range *request.FilmsId {
-		row, err = query.Query(id, val)
-
-		if err != nil {
-			return 0, errors.New("film with such id does not exist")
+		if _, err := query.ExecContext(ctx, id, val); err != nil {
+			log.Printf("Can not link film %d to actor %d: %s", val, id, err.Error())
+			return 0, referenceError(err, "filmsId", fmt.Sprintf("Film with id %d does not exist", val))
 		}
-
-		row.Next()
 	}
 
 	log.Printf("Insert actor with id %d", id)
 	return id, nil
 }
 
-func (r *ActorRepo) PutActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
+func (r *ActorRepo) PutActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
 	var updatedId int
-	query, err := r.db.Prepare("UPDATE actor SET name = $1, sex = $2, birthday = $3 WHERE id = $4 RETURNING id")
+	query, err := r.db.PrepareContext(ctx, "UPDATE actor SET name = $1, sex = $2, birthday = $3 "+
+		"WHERE id = $4 AND ($5 = 0 OR version = $5) RETURNING id")
 	if err != nil {
-		return presenter.ActorResponse{}, err
+		return presenter.ActorResponse{}, dbError(err)
 	}
 	defer query.Close()
-	row, err := query.Query(*request.Name, *request.Sex, *request.Birthday, id)
+	row, err := query.QueryContext(ctx, *request.Name, *request.Sex, *request.Birthday, id, version)
 
 	if err != nil {
-		return presenter.ActorResponse{}, err
+		return presenter.ActorResponse{}, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&updatedId); err != nil {
-			return presenter.ActorResponse{}, err
+			return presenter.ActorResponse{}, dbError(err)
 		}
 	}
 
 	if updatedId != id {
-		return presenter.ActorResponse{}, errors.New("entity not found")
+		return presenter.ActorResponse{}, writeError(ctx, r.db, "actor", "actor", id, version)
 	}
 
-	err = r.updateFilmsId(request, id)
+	err = r.updateFilmsId(ctx, request, id)
 
 	if err != nil {
 		return presenter.ActorResponse{}, err
 	}
 
 	log.Printf("Put actor with id %d", id)
-	return presenter.ActorResponse{
-		Id:       id,
-		Name:     *request.Name,
-		Sex:      *request.Sex,
-		Birthday: strings.Split((*request.Birthday).String(), " ")[0],
-		FilmsId:  *request.FilmsId,
-	}, nil
+	// read back for the version, relinking films bumps it again
+	return r.GetActor(ctx, id)
 }
 
-func (r *ActorRepo) PatchActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
+func (r *ActorRepo) PatchActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
 	q := `UPDATE actor SET `
 	qParts := make([]string, 0, 3)
 	args := make([]interface{}, 0, 3)
@@ -200,85 +195,91 @@ func (r *ActorRepo) PatchActor(id int, request presenter.ActorRequest) (presente
 		counter++
 		args = append(args, request.Sex)
 	}
-	if !request.Birthday.IsZero() {
+	if request.Birthday != nil {
 		qParts = append(qParts, fmt.Sprintf("birthday=$%d", counter))
 		counter++
 		args = append(args, request.Birthday)
 	}
-	q += strings.Join(qParts, ",") + ` WHERE id = $` + strconv.Itoa(counter) + "RETURNING id"
-	args = append(args, id)
+	// a patch of the films only still checks and bumps the version
+	if len(qParts) == 0 {
+		qParts = append(qParts, "id=id")
+	}
+	q += strings.Join(qParts, ",") + ` WHERE id = $` + strconv.Itoa(counter) +
+		` AND ($` + strconv.Itoa(counter+1) + ` = 0 OR version = $` + strconv.Itoa(counter+1) + `) RETURNING id`
+	args = append(args, id, version)
 
-	row, err := r.db.Query(q, args...)
+	row, err := r.db.QueryContext(ctx, q, args...)
 
 	if err != nil {
-		return presenter.ActorResponse{}, err
+		return presenter.ActorResponse{}, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&updatedId); err != nil {
-			return presenter.ActorResponse{}, err
+			return presenter.ActorResponse{}, dbError(err)
 		}
 	}
 	if updatedId != id {
-		return presenter.ActorResponse{}, errors.New("entity not found")
+		return presenter.ActorResponse{}, writeError(ctx, r.db, "actor", "actor", id, version)
 	}
 
-	err = r.updateFilmsId(request, id)
+	err = r.updateFilmsId(ctx, request, id)
 
 	if err != nil {
 		return presenter.ActorResponse{}, err
 	}
 
 	log.Printf("Patch actor with id %d", id)
-	return r.GetActor(id)
+	return r.GetActor(ctx, id)
 }
 
-func (r *ActorRepo) DeleteActor(id int) error {
-	query, err := r.db.Prepare("DELETE FROM actor WHERE id = $1")
+func (r *ActorRepo) DeleteActor(ctx context.Context, id, version int) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM actor WHERE id = $1 AND ($2 = 0 OR version = $2)")
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	defer query.Close()
-	_, err = query.Query(id)
+	result, err := query.ExecContext(ctx, id, version)
 
 	if err != nil {
-		return err
+		return dbError(err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return writeError(ctx, r.db, "actor", "actor", id, version)
 	}
 	log.Printf("Delete actor with id %d", id)
 
 	return nil
 }
 
-func (r *ActorRepo) updateFilmsId(request presenter.ActorRequest, id int) error {
+func (r *ActorRepo) updateFilmsId(ctx context.Context, request presenter.ActorRequest, id int) error {
 	if request.FilmsId == nil {
 		return nil
 	}
-	query, err := r.db.Prepare("DELETE FROM actor_film WHERE actor_id = $1")
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM actor_film WHERE actor_id = $1")
 
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	defer query.Close()
 
-	_, err = query.Query(id)
+	_, err = query.ExecContext(ctx, id)
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 
-	query, err = r.db.Prepare("INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
+	query, err = r.db.PrepareContext(ctx, "INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
 
 	if err != nil {
-		return err
+		return dbError(err)
 	}
+	defer query.Close()
 
 	for _, val := range *request.FilmsId {
-		row, err := query.Query(id, val)
-
-		if err != nil {
-			return errors.New("film with such id does not exist")
+		if _, err := query.ExecContext(ctx, id, val); err != nil {
+			log.Printf("Can not link film %d to actor %d: %s", val, id, err.Error())
+			return referenceError(err, "filmsId", fmt.Sprintf("Film with id %d does not exist", val))
 		}
-
-		row.Next()
 	}
 	return nil
 }
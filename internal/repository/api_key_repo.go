@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"time"
+)
+
+type ApiKeyRepo struct {
+	db DBTX
+}
+
+func NewApiKeyRepo(db DBTX) *ApiKeyRepo {
+	return &ApiKeyRepo{db: db}
+}
+
+func (r *ApiKeyRepo) GetApiKeys(ctx context.Context) ([]presenter.ApiKeyResponse, error) {
+	keys := make([]presenter.ApiKeyResponse, 0)
+	index := make(map[int]int)
+
+	query, err := r.db.PrepareContext(ctx, "SELECT api_key.id, name, prefix, created_by, created_at, expires_at, "+
+		"last_used_at, revoked_at, permission FROM api_key "+
+		"LEFT JOIN api_key_permission ON api_key.id = api_key_permission.api_key_id "+
+		"LEFT JOIN permission ON api_key_permission.permission_id = permission.id "+
+		"ORDER BY api_key.id, permission")
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer query.Close()
+
+	rows, err := query.QueryContext(ctx)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key := presenter.ApiKeyResponse{Scopes: make([]string, 0)}
+		var createdBy sql.NullInt64
+		var expiresAt, lastUsedAt, revokedAt sql.NullTime
+		var permission sql.NullString
+
+		err := rows.Scan(&key.Id, &key.Name, &key.Prefix, &createdBy, &key.CreatedAt, &expiresAt,
+			&lastUsedAt, &revokedAt, &permission)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		i, ok := index[key.Id]
+		if !ok {
+			if createdBy.Valid {
+				id := int(createdBy.Int64)
+				key.CreatedBy = &id
+			}
+			key.ExpiresAt = nullTime(expiresAt)
+			key.LastUsedAt = nullTime(lastUsedAt)
+			key.RevokedAt = nullTime(revokedAt)
+
+			i = len(keys)
+			index[key.Id] = i
+			keys = append(keys, key)
+		}
+		if permission.Valid {
+			keys[i].Scopes = append(keys[i].Scopes, permission.String)
+		}
+	}
+	log.Printf("Get api keys")
+	return keys, rows.Err()
+}
+
+func (r *ApiKeyRepo) GetApiKeyByHash(ctx context.Context, keyHash string) (entity.ApiKey, error) {
+	key := entity.ApiKey{Permissions: make([]string, 0)}
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, expires_at, last_used_at, revoked_at FROM api_key "+
+		"WHERE key_hash = $1", keyHash).Scan(&key.Id, &key.Name, &expiresAt, &lastUsedAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ApiKey{}, &entity.NotFoundError{Entity: "api key"}
+	}
+	if err != nil {
+		return entity.ApiKey{}, dbError(err)
+	}
+	key.ExpiresAt = nullTime(expiresAt)
+	key.LastUsedAt = nullTime(lastUsedAt)
+	key.RevokedAt = nullTime(revokedAt)
+
+	query, err := r.db.PrepareContext(ctx, "SELECT permission FROM permission "+
+		"JOIN api_key_permission ON permission.id = api_key_permission.permission_id "+
+		"WHERE api_key_permission.api_key_id = $1")
+	if err != nil {
+		return entity.ApiKey{}, dbError(err)
+	}
+	defer query.Close()
+
+	rows, err := query.QueryContext(ctx, key.Id)
+	if err != nil {
+		return entity.ApiKey{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return entity.ApiKey{}, dbError(err)
+		}
+		key.Permissions = append(key.Permissions, permission)
+	}
+	return key, rows.Err()
+}
+
+func (r *ApiKeyRepo) CreateApiKey(ctx context.Context, createdBy int, name, prefix, keyHash string, scopes []string,
+	expiresAt *time.Time) (presenter.ApiKeyResponse, error) {
+	key := presenter.ApiKeyResponse{Name: name, Prefix: prefix, Scopes: scopes, ExpiresAt: expiresAt}
+	if createdBy != 0 {
+		key.CreatedBy = &createdBy
+	}
+
+	err := inTransaction(ctx, r.db, func(tx DBTX) error {
+		err := tx.QueryRowContext(ctx, "INSERT INTO api_key (name, prefix, key_hash, created_by, expires_at) "+
+			"VALUES ($1, $2, $3, NULLIF($4, 0), $5) RETURNING id, created_at",
+			name, prefix, keyHash, createdBy, expiresAt).Scan(&key.Id, &key.CreatedAt)
+		if err != nil {
+			return dbError(err)
+		}
+
+		for _, scope := range scopes {
+			result, err := tx.ExecContext(ctx, "INSERT INTO api_key_permission (api_key_id, permission_id) "+
+				"SELECT $1, id FROM permission WHERE permission = $2 ON CONFLICT DO NOTHING", key.Id, scope)
+			if err != nil {
+				return dbError(err)
+			}
+			if inserted, _ := result.RowsAffected(); inserted == 0 {
+				return &entity.ValidationError{Message: "Permission " + scope + " does not exist",
+					Fields: []entity.FieldError{{Field: "scopes", Message: "does not exist"}}}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return presenter.ApiKeyResponse{}, dbError(err)
+	}
+
+	log.Printf("Create api key %d by user %d", key.Id, createdBy)
+	return key, nil
+}
+
+// TouchApiKey records the use of a key. The timestamp is updated at most once
+// a minute so a busy client does not write on every request
+func (r *ApiKeyRepo) TouchApiKey(ctx context.Context, id int) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE api_key SET last_used_at = now() "+
+		"WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, id)
+	return dbError(err)
+}
+
+func (r *ApiKeyRepo) RevokeApiKey(ctx context.Context, id int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE api_key SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id)
+	if err != nil {
+		return false, dbError(err)
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Revoke api key %d", id)
+	return revoked == 1, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// constraintMessages names what clashed for the unique constraints clients
+// can run into
+var constraintMessages = map[string]string{
+	"_user_username_key":               "User with such username already exists",
+	"_user_email_key":                  "User with such email already exists",
+	"role_role_key":                    "Role with such name already exists",
+	"user_identity_issuer_subject_key": "Identity is already linked to a user",
+	"actor_film_actor_id_film_id_key":  "Actor is already linked to the film",
+}
+
+// constraintFields says which field a check or foreign key constraint guards
+// and what it requires of it
+var constraintFields = map[string]entity.FieldError{
+	"film_name_length_check":        {Field: "name", Message: "must be 1 to 150 characters long"},
+	"film_description_length_check": {Field: "description", Message: "must be at most 1000 characters long"},
+	"film_rating_check":             {Field: "rating", Message: "must be between 0 and 10"},
+	"actor_sex_fkey":                {Field: "sex", Message: "must be a known sex"},
+}
+
+// dbError turns errors of the database driver into domain errors, those of
+// the database no other error describes into an InternalError. Other errors,
+// domain errors included, are returned as they are
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return &entity.NotFoundError{}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqError(pqErr)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		log.Printf("Database is unavailable: %s", err.Error())
+		return &entity.UnavailableError{Err: err}
+	}
+	return err
+}
+
+func pqError(err *pq.Error) error {
+	switch err.Code.Name() {
+	case "unique_violation":
+		message, ok := constraintMessages[err.Constraint]
+		if !ok {
+			message = "Entity already exists"
+		}
+		return &entity.ConflictError{Message: message, Constraint: err.Constraint}
+	case "foreign_key_violation":
+		// deleting a referenced row, or inserting a reference to a missing one
+		if strings.Contains(err.Detail, "is still referenced") {
+			return &entity.ConflictError{Message: "Entity is still in use", Constraint: err.Constraint}
+		}
+		if field, ok := constraintFields[err.Constraint]; ok {
+			return &entity.ValidationError{Message: "Invalid request body",
+				Fields: []entity.FieldError{field}, Constraint: err.Constraint}
+		}
+		return &entity.ValidationError{Message: "Referenced entity does not exist",
+			Fields:     []entity.FieldError{{Field: detailKey(err.Detail), Message: "does not exist"}},
+			Constraint: err.Constraint}
+	case "not_null_violation":
+		return &entity.ValidationError{Message: "Invalid request body",
+			Fields: []entity.FieldError{{Field: err.Column, Message: "is required"}}}
+	case "check_violation":
+		field, ok := constraintFields[err.Constraint]
+		if !ok {
+			field = entity.FieldError{Field: err.Constraint, Message: "is invalid"}
+		}
+		return &entity.ValidationError{Message: "Invalid request body",
+			Fields: []entity.FieldError{field}, Constraint: err.Constraint}
+	}
+
+	switch err.Code.Class() {
+	// data exceptions like a too long string or an invalid date
+	case "22":
+		return &entity.ValidationError{Message: "Invalid request body: " + err.Message}
+	// connection errors, out of resources, shutdown, cancelled statements, and
+	// serialization failures or deadlocks a retry may get past
+	case "08", "53", "57", "40":
+		log.Printf("Database is unavailable: %s", err.Error())
+		return &entity.UnavailableError{Err: err}
+	}
+	// anything else, like a syntax error, is a fault of the server
+	return &entity.InternalError{Err: err}
+}
+
+// detailKey returns the column of a detail like Key (actor_id)=(5) is not present
+func detailKey(detail string) string {
+	_, key, found := strings.Cut(detail, "Key (")
+	if !found {
+		return ""
+	}
+	key, _, _ = strings.Cut(key, ")=")
+	return key
+}
+
+// referenceError describes a foreign key violation, like a film linked to an
+// actor that does not exist, by field
+func referenceError(err error, field, message string) error {
+	err = dbError(err)
+	var validation *entity.ValidationError
+	if errors.As(err, &validation) {
+		return &entity.ValidationError{Message: message,
+			Fields: []entity.FieldError{{Field: field, Message: "does not exist"}}, Constraint: validation.Constraint}
+	}
+	return err
+}
+
+// writeError is the error of an update or delete of row id of table that
+// matched no row. The entity does not exist or, for a write conditional on
+// version, was changed since
+func writeError(ctx context.Context, db DBTX, table, entityName string, id, version int) error {
+	if version != 0 {
+		var exists bool
+		err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = $1)", id).Scan(&exists)
+		if err != nil {
+			return dbError(err)
+		}
+		if exists {
+			return &entity.PreconditionFailedError{Entity: entityName}
+		}
+	}
+	return &entity.NotFoundError{Entity: entityName}
+}
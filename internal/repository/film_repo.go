@@ -1,9 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"fmt"
 	"log"
 	"strconv"
@@ -11,38 +12,38 @@ import (
 )
 
 type FilmRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewFilmRepo(db *sql.DB) *FilmRepo {
+func NewFilmRepo(db DBTX) *FilmRepo {
 	return &FilmRepo{db: db}
 }
 
-func (r *FilmRepo) GetFilm(id int) (presenter.FilmResponse, error) {
+func (r *FilmRepo) GetFilm(ctx context.Context, id int) (presenter.FilmResponse, error) {
 	fil := presenter.FilmResponse{}
 	actorsId := make([]int, 0)
 	var releaseDate string
 	var actorId sql.NullInt64
 
-	query, err := r.db.Prepare("SELECT film.id, name, description, release_date, rating, actor_id FROM film " +
-		"LEFT JOIN actor_film ON film.id = actor_film.film_id " +
+	query, err := r.db.PrepareContext(ctx, "SELECT film.id, name, description, release_date, rating, version, actor_id FROM film "+
+		"LEFT JOIN actor_film ON film.id = actor_film.film_id "+
 		"WHERE film.id = $1")
 
 	if err != nil {
-		return presenter.FilmResponse{}, err
+		return presenter.FilmResponse{}, dbError(err)
 	}
 
 	defer query.Close()
-	row, err := query.Query(id)
+	row, err := query.QueryContext(ctx, id)
 
 	if err != nil {
-		return presenter.FilmResponse{}, err
+		return presenter.FilmResponse{}, dbError(err)
 	}
 
 	for row.Next() {
-		err = row.Scan(&fil.Id, &fil.Name, &fil.Description, &releaseDate, &fil.Rating, &actorId)
+		err = row.Scan(&fil.Id, &fil.Name, &fil.Description, &releaseDate, &fil.Rating, &fil.Version, &actorId)
 		if err != nil {
-			return presenter.FilmResponse{}, err
+			return presenter.FilmResponse{}, dbError(err)
 		}
 		fil.ReleaseDate = strings.Split(releaseDate, "T")[0]
 		if actorId.Valid {
@@ -50,14 +51,14 @@ func (r *FilmRepo) GetFilm(id int) (presenter.FilmResponse, error) {
 		}
 	}
 	if fil.Id != id {
-		return presenter.FilmResponse{}, errors.New("entity not found")
+		return presenter.FilmResponse{}, &entity.NotFoundError{Entity: "film"}
 	}
 	fil.ActorsId = actorsId
 	log.Printf("Get film with id %d", id)
 	return fil, nil
 }
 
-func (r *FilmRepo) GetFilms(sortBy string) ([]presenter.FilmResponse, error) {
+func (r *FilmRepo) GetFilms(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error) {
 	films := make([]presenter.FilmResponse, 0)
 	isFilmExistsMap := make(map[int]int)
 	mapActors := make(map[int][]int)
@@ -66,22 +67,22 @@ func (r *FilmRepo) GetFilms(sortBy string) ([]presenter.FilmResponse, error) {
 	var releaseDate string
 	var actorId sql.NullInt64
 
-	query, err := r.db.Prepare("SELECT film.id, name, description, release_date, rating, actor_id FROM film " +
-		"LEFT JOIN actor_film ON film.id = actor_film.film_id " +
-		"ORDER BY " + sortBy)
+	query, err := r.db.PrepareContext(ctx, "SELECT film.id, name, description, release_date, rating, actor_id FROM film "+
+		"LEFT JOIN actor_film ON film.id = actor_film.film_id "+
+		"ORDER BY "+sortBy)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer query.Close()
-	rows, err := query.Query()
+	rows, err := query.QueryContext(ctx)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	for rows.Next() {
 		err = rows.Scan(&fil.Id, &fil.Name, &fil.Description, &releaseDate, &fil.Rating, &actorId)
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 		fil.ReleaseDate = strings.Split(releaseDate, "T")[0]
 
@@ -106,86 +107,78 @@ func (r *FilmRepo) GetFilms(sortBy string) ([]presenter.FilmResponse, error) {
 	return films, nil
 }
 
-func (r *FilmRepo) CreateFilm(request presenter.FilmRequest) (int, error) {
+func (r *FilmRepo) CreateFilm(ctx context.Context, request presenter.FilmRequest) (int, error) {
 	var id int
-	query, err := r.db.Prepare("INSERT INTO film (name, description, release_date, rating) " +
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO film (name, description, release_date, rating) "+
 		"VALUES ($1, $2, $3, $4) RETURNING id")
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	defer query.Close()
-	row, err := query.Query(*request.Name, *request.Description, *request.ReleaseDate, *request.Rating)
+	row, err := query.QueryContext(ctx, *request.Name, *request.Description, *request.ReleaseDate, *request.Rating)
 
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&id); err != nil {
-			return 0, err
+			return 0, dbError(err)
 		}
 	}
 
-	query, err = r.db.Prepare("INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
+	query, err = r.db.PrepareContext(ctx, "INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
+	defer query.Close()
 
 	for _, val := range *request.ActorsId {
-		row, err = query.Query(val, id)
-
-		if err != nil {
-			return 0, errors.New("actor with such id does not exist")
+		if _, err := query.ExecContext(ctx, val, id); err != nil {
+			log.Printf("Can not link actor %d to film %d: %s", val, id, err.Error())
+			return 0, referenceError(err, "actorsId", fmt.Sprintf("Actor with id %d does not exist", val))
 		}
-
-		row.Next()
 	}
 
 	log.Printf("Insert film with id %d", id)
 	return id, nil
 }
 
-func (r *FilmRepo) PutFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
+func (r *FilmRepo) PutFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
 	var updatedId int
-	query, err := r.db.Prepare("UPDATE film SET name = $1, description = $2, release_date = $3, rating = $4" +
-		" WHERE id = $5 RETURNING id")
+	query, err := r.db.PrepareContext(ctx, "UPDATE film SET name = $1, description = $2, release_date = $3, rating = $4"+
+		" WHERE id = $5 AND ($6 = 0 OR version = $6) RETURNING id")
 	if err != nil {
-		return presenter.FilmResponse{}, err
+		return presenter.FilmResponse{}, dbError(err)
 	}
 	defer query.Close()
-	row, err := query.Query(*request.Name, *request.Description, *request.ReleaseDate, *request.Rating, id)
+	row, err := query.QueryContext(ctx, *request.Name, *request.Description, *request.ReleaseDate, *request.Rating, id, version)
 
 	if err != nil {
-		return presenter.FilmResponse{}, err
+		return presenter.FilmResponse{}, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&updatedId); err != nil {
-			return presenter.FilmResponse{}, err
+			return presenter.FilmResponse{}, dbError(err)
 		}
 	}
 	if updatedId != id {
-		return presenter.FilmResponse{}, errors.New("entity not found")
+		return presenter.FilmResponse{}, writeError(ctx, r.db, "film", "film", id, version)
 	}
 
-	err = r.updateFilmsId(request, id)
+	err = r.updateFilmsId(ctx, request, id)
 
 	if err != nil {
 		return presenter.FilmResponse{}, err
 	}
 
 	log.Printf("Put film with id %d", id)
-	return presenter.FilmResponse{
-		Id:          id,
-		Name:        *request.Name,
-		Description: *request.Description,
-		ReleaseDate: strings.Split((*request.ReleaseDate).String(), " ")[0],
-		Rating:      *request.Rating,
-		ActorsId:    *request.ActorsId,
-	}, nil
+	// read back for the version, relinking actors bumps it again
+	return r.GetFilm(ctx, id)
 }
 
-func (r *FilmRepo) PatchFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
+func (r *FilmRepo) PatchFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
 	q := `UPDATE film SET `
 	qParts := make([]string, 0, 3)
 	args := make([]interface{}, 0, 3)
@@ -203,7 +196,7 @@ func (r *FilmRepo) PatchFilm(id int, request presenter.FilmRequest) (presenter.F
 		counter++
 		args = append(args, request.Description)
 	}
-	if !request.ReleaseDate.IsZero() {
+	if request.ReleaseDate != nil {
 		qParts = append(qParts, fmt.Sprintf("release_date=$%d", counter))
 		counter++
 		args = append(args, request.ReleaseDate)
@@ -213,107 +206,113 @@ func (r *FilmRepo) PatchFilm(id int, request presenter.FilmRequest) (presenter.F
 		counter++
 		args = append(args, request.Rating)
 	}
-	q += strings.Join(qParts, ",") + ` WHERE id = $` + strconv.Itoa(counter) + "RETURNING id"
-	args = append(args, id)
+	// a patch of the actors only still checks and bumps the version
+	if len(qParts) == 0 {
+		qParts = append(qParts, "id=id")
+	}
+	q += strings.Join(qParts, ",") + ` WHERE id = $` + strconv.Itoa(counter) +
+		` AND ($` + strconv.Itoa(counter+1) + ` = 0 OR version = $` + strconv.Itoa(counter+1) + `) RETURNING id`
+	args = append(args, id, version)
 
-	row, err := r.db.Query(q, args...)
+	row, err := r.db.QueryContext(ctx, q, args...)
 
 	if err != nil {
-		return presenter.FilmResponse{}, err
+		return presenter.FilmResponse{}, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&updatedId); err != nil {
-			return presenter.FilmResponse{}, err
+			return presenter.FilmResponse{}, dbError(err)
 		}
 	}
 	if updatedId != id {
-		return presenter.FilmResponse{}, errors.New("entity not found")
+		return presenter.FilmResponse{}, writeError(ctx, r.db, "film", "film", id, version)
 	}
 
-	err = r.updateFilmsId(request, id)
+	err = r.updateFilmsId(ctx, request, id)
 
 	if err != nil {
 		return presenter.FilmResponse{}, err
 	}
 
 	log.Printf("Patch film with id %d", id)
-	return r.GetFilm(id)
+	return r.GetFilm(ctx, id)
 }
 
-func (r *FilmRepo) DeleteFilm(id int) error {
-	query, err := r.db.Prepare("DELETE FROM film WHERE id = $1")
+func (r *FilmRepo) DeleteFilm(ctx context.Context, id, version int) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM film WHERE id = $1 AND ($2 = 0 OR version = $2)")
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	defer query.Close()
-	_, err = query.Query(id)
+	result, err := query.ExecContext(ctx, id, version)
 
 	if err != nil {
-		return err
+		return dbError(err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return writeError(ctx, r.db, "film", "film", id, version)
 	}
 	log.Printf("Delete film with id %d", id)
 
 	return nil
 }
 
-func (r *FilmRepo) updateFilmsId(request presenter.FilmRequest, id int) error {
+func (r *FilmRepo) updateFilmsId(ctx context.Context, request presenter.FilmRequest, id int) error {
 	if request.ActorsId == nil {
 		return nil
 	}
-	query, err := r.db.Prepare("DELETE FROM actor_film WHERE film_id = $1")
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM actor_film WHERE film_id = $1")
 
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	defer query.Close()
 
-	_, err = query.Query(id)
+	_, err = query.ExecContext(ctx, id)
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 
-	query, err = r.db.Prepare("INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
+	query, err = r.db.PrepareContext(ctx, "INSERT INTO actor_film (actor_id, film_id) VALUES ($1, $2)")
 
 	if err != nil {
-		return err
+		return dbError(err)
 	}
+	defer query.Close()
 
 	for _, val := range *request.ActorsId {
-		row, err := query.Query(val, id)
-
-		if err != nil {
-			return errors.New("actor with such id does not exist")
+		if _, err := query.ExecContext(ctx, val, id); err != nil {
+			log.Printf("Can not link actor %d to film %d: %s", val, id, err.Error())
+			return referenceError(err, "actorsId", fmt.Sprintf("Actor with id %d does not exist", val))
 		}
-
-		row.Next()
 	}
 	return nil
 }
 
-func (r *FilmRepo) SearchFilmsByName(name string) ([]presenter.FilmResponse, error) {
+func (r *FilmRepo) SearchFilmsByName(ctx context.Context, name string) ([]presenter.FilmResponse, error) {
 	films := make([]presenter.FilmResponse, 0)
 	isFilmExistsMap := make(map[int]int)
 	mapActors := make(map[int][]int)
 	fil := presenter.FilmResponse{}
 	var releaseDate string
 	var actorId sql.NullInt64
-	query, err := r.db.Prepare("SELECT film.id, name, description, release_date, rating, actor_id FROM film " +
-		"LEFT JOIN actor_film ON film.id = actor_film.film_id " +
-		"WHERE film.name LIKE '" + name + "%'")
+	query, err := r.db.PrepareContext(ctx, "SELECT film.id, name, description, release_date, rating, actor_id FROM film "+
+		"LEFT JOIN actor_film ON film.id = actor_film.film_id "+
+		"WHERE film.name LIKE $1 || '%' ESCAPE '\\'")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer query.Close()
-	rows, err := query.Query()
+	rows, err := query.QueryContext(ctx, escapeLike(name))
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	for rows.Next() {
 		err = rows.Scan(&fil.Id, &fil.Name, &fil.Description, &releaseDate, &fil.Rating, &actorId)
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 		fil.ReleaseDate = strings.Split(releaseDate, "T")[0]
 
@@ -337,7 +336,7 @@ func (r *FilmRepo) SearchFilmsByName(name string) ([]presenter.FilmResponse, err
 	return films, nil
 }
 
-func (r *FilmRepo) SearchFilmsByActor(name string) ([]presenter.FilmResponse, error) {
+func (r *FilmRepo) SearchFilmsByActor(ctx context.Context, name string) ([]presenter.FilmResponse, error) {
 	films := make([]presenter.FilmResponse, 0)
 	isFilmExistsMap := make(map[int]int)
 	mapActors := make(map[int][]int)
@@ -345,23 +344,23 @@ func (r *FilmRepo) SearchFilmsByActor(name string) ([]presenter.FilmResponse, er
 	fil := presenter.FilmResponse{}
 	var releaseDate string
 	var actorId sql.NullInt64
-	query, err := r.db.Prepare("SELECT film.id, film.name, description, release_date, rating, actor_id FROM film " +
-		"JOIN actor_film ON film.id = actor_film.film_id " +
-		"JOIN actor ON actor_film.actor_id = actor.id " +
-		"WHERE actor.name LIKE '" + name + "%'")
+	query, err := r.db.PrepareContext(ctx, "SELECT film.id, film.name, description, release_date, rating, actor_id FROM film "+
+		"JOIN actor_film ON film.id = actor_film.film_id "+
+		"JOIN actor ON actor_film.actor_id = actor.id "+
+		"WHERE actor.name LIKE $1 || '%' ESCAPE '\\'")
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 	defer query.Close()
-	rows, err := query.Query()
+	rows, err := query.QueryContext(ctx, escapeLike(name))
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	for rows.Next() {
 		err = rows.Scan(&fil.Id, &fil.Name, &fil.Description, &releaseDate, &fil.Rating, &actorId)
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
 		fil.ReleaseDate = strings.Split(releaseDate, "T")[0]
 
@@ -384,3 +383,8 @@ func (r *FilmRepo) SearchFilmsByActor(name string) ([]presenter.FilmResponse, er
 	log.Printf("Search film by actor")
 	return films, nil
 }
+
+// escapeLike makes LIKE match s literally, its % and _ included
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
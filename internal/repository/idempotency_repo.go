@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"time"
+)
+
+type IdempotencyRepo struct {
+	db DBTX
+}
+
+func NewIdempotencyRepo(db DBTX) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db}
+}
+
+// ReserveIdempotencyKey stores key for a request that is about to be processed
+// and returns true, or returns false and the request stored earlier with key
+func (r *IdempotencyRepo) ReserveIdempotencyKey(ctx context.Context, owner, key, fingerprint string,
+	expiresAt time.Time) (entity.IdempotentRequest, bool, error) {
+	// keys of abandoned and old requests can be used again
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotent_request WHERE expires_at <= now()")
+	if err != nil {
+		return entity.IdempotentRequest{}, false, dbError(err)
+	}
+
+	insert, err := r.db.PrepareContext(ctx, "INSERT INTO idempotent_request (owner, idempotency_key, fingerprint, expires_at) "+
+		"VALUES ($1, $2, $3, $4) ON CONFLICT (owner, idempotency_key) DO NOTHING")
+	if err != nil {
+		return entity.IdempotentRequest{}, false, dbError(err)
+	}
+	defer insert.Close()
+
+	result, err := insert.ExecContext(ctx, owner, key, fingerprint, expiresAt)
+	if err != nil {
+		return entity.IdempotentRequest{}, false, dbError(err)
+	}
+	if inserted, err := result.RowsAffected(); err == nil && inserted == 1 {
+		return entity.IdempotentRequest{}, true, nil
+	}
+
+	var (
+		request     entity.IdempotentRequest
+		status      sql.NullInt64
+		contentType sql.NullString
+		location    sql.NullString
+	)
+	query, err := r.db.PrepareContext(ctx, "SELECT fingerprint, status, content_type, location, body FROM idempotent_request "+
+		"WHERE owner = $1 AND idempotency_key = $2")
+	if err != nil {
+		return entity.IdempotentRequest{}, false, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, owner, key).Scan(&request.Fingerprint, &status, &contentType, &location, &request.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// released meanwhile, the client may retry
+		return entity.IdempotentRequest{Fingerprint: fingerprint}, false, nil
+	}
+	if err != nil {
+		return entity.IdempotentRequest{}, false, dbError(err)
+	}
+	request.Status = int(status.Int64)
+	request.ContentType = contentType.String
+	request.Location = location.String
+	return request, false, nil
+}
+
+// CompleteIdempotencyKey stores the response to the request reserved with key
+func (r *IdempotencyRepo) CompleteIdempotencyKey(ctx context.Context, owner, key string,
+	response entity.IdempotentRequest, expiresAt time.Time) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE idempotent_request SET status = $1, content_type = $2, "+
+		"location = $3, body = $4, expires_at = $5 WHERE owner = $6 AND idempotency_key = $7")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, response.Status, response.ContentType, response.Location, response.Body,
+		expiresAt, owner, key)
+	return dbError(err)
+}
+
+// ReleaseIdempotencyKey forgets a request that failed, so it can be retried
+func (r *IdempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, owner, key string) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM idempotent_request WHERE owner = $1 AND idempotency_key = $2 "+
+		"AND status IS NULL")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, owner, key)
+	return dbError(err)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type ImpersonationRepo struct {
+	db DBTX
+}
+
+func NewImpersonationRepo(db DBTX) *ImpersonationRepo {
+	return &ImpersonationRepo{db: db}
+}
+
+func (r *ImpersonationRepo) CreateImpersonation(ctx context.Context, actorId, subjectId int, reason string, expiresAt time.Time) (int, error) {
+	var id int
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO impersonation (actor_id, subject_id, reason, expires_at) "+
+		"VALUES ($1, $2, $3, $4) RETURNING id")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, actorId, subjectId, reason, expiresAt).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	log.Printf("Record impersonation %d of user %d by user %d", id, subjectId, actorId)
+	return id, nil
+}
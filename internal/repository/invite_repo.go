@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"time"
+)
+
+type InviteRepo struct {
+	db DBTX
+}
+
+func NewInviteRepo(db DBTX) *InviteRepo {
+	return &InviteRepo{db: db}
+}
+
+func (r *InviteRepo) GetInvites(ctx context.Context) ([]presenter.InviteResponse, error) {
+	invites := make([]presenter.InviteResponse, 0)
+
+	query, err := r.db.PrepareContext(ctx, "SELECT id, created_by, created_at, expires_at, used_by, used_at "+
+		"FROM invite ORDER BY id")
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer query.Close()
+
+	rows, err := query.QueryContext(ctx)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		invite := presenter.InviteResponse{}
+		var createdBy, usedBy sql.NullInt64
+		var usedAt sql.NullTime
+
+		err := rows.Scan(&invite.Id, &createdBy, &invite.CreatedAt, &invite.ExpiresAt, &usedBy, &usedAt)
+		if err != nil {
+			return nil, dbError(err)
+		}
+		if createdBy.Valid {
+			id := int(createdBy.Int64)
+			invite.CreatedBy = &id
+		}
+		if usedBy.Valid {
+			id := int(usedBy.Int64)
+			invite.UsedBy = &id
+		}
+		if usedAt.Valid {
+			invite.UsedAt = &usedAt.Time
+		}
+		invites = append(invites, invite)
+	}
+	log.Printf("Get invites")
+	return invites, rows.Err()
+}
+
+func (r *InviteRepo) CreateInvite(ctx context.Context, createdBy int, codeHash string, expiresAt time.Time) (presenter.InviteResponse, error) {
+	invite := presenter.InviteResponse{}
+	if createdBy != 0 {
+		invite.CreatedBy = &createdBy
+	}
+
+	// invites created with an api key have no creating user
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO invite (code_hash, created_by, expires_at) "+
+		"VALUES ($1, NULLIF($2, 0), $3) "+
+		"RETURNING id, created_at, expires_at")
+	if err != nil {
+		return presenter.InviteResponse{}, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, codeHash, createdBy, expiresAt).Scan(&invite.Id, &invite.CreatedAt, &invite.ExpiresAt)
+	if err != nil {
+		return presenter.InviteResponse{}, dbError(err)
+	}
+
+	log.Printf("Create invite %d by user %d", invite.Id, createdBy)
+	return invite, nil
+}
+
+func (r *InviteRepo) UseInvite(ctx context.Context, codeHash string, userId int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE invite SET used_by = $2, used_at = now() "+
+		"WHERE code_hash = $1 AND used_at IS NULL AND expires_at > now()")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, codeHash, userId)
+	if err != nil {
+		return false, dbError(err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+	if used == 1 {
+		log.Printf("User %d registered with invite", userId)
+	}
+	return used == 1, nil
+}
+
+func (r *InviteRepo) DeleteInvite(ctx context.Context, id int) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM invite WHERE id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id)
+	if err != nil {
+		return dbError(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err)
+	}
+	if deleted == 0 {
+		return &entity.NotFoundError{Entity: "invite"}
+	}
+
+	log.Printf("Delete invite %d", id)
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"time"
+)
+
+type LoginFailureRepo struct {
+	db DBTX
+}
+
+func NewLoginFailureRepo(db DBTX) *LoginFailureRepo {
+	return &LoginFailureRepo{db: db}
+}
+
+func (r *LoginFailureRepo) GetLoginFailure(ctx context.Context, kind, subject string) (entity.LoginFailure, error) {
+	failure := entity.LoginFailure{Kind: kind, Subject: subject}
+	var lockedUntil sql.NullTime
+
+	query, err := r.db.PrepareContext(ctx, "SELECT failures, last_failure_at, locked_until FROM login_failure "+
+		"WHERE kind = $1 AND subject = $2")
+	if err != nil {
+		return entity.LoginFailure{}, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, kind, subject).Scan(&failure.Failures, &failure.LastFailureAt, &lockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return failure, nil
+	}
+	if err != nil {
+		return entity.LoginFailure{}, dbError(err)
+	}
+	if lockedUntil.Valid {
+		failure.LockedUntil = &lockedUntil.Time
+	}
+	return failure, nil
+}
+
+func (r *LoginFailureRepo) RecordLoginFailure(ctx context.Context, kind, subject string, resetAfter time.Duration) (int, error) {
+	var failures int
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO login_failure (kind, subject, failures) VALUES ($1, $2, 1) "+
+		"ON CONFLICT (kind, subject) DO UPDATE SET "+
+		"failures = CASE WHEN login_failure.last_failure_at < now() - make_interval(secs => $3) "+
+		"THEN 1 ELSE login_failure.failures + 1 END, last_failure_at = now() "+
+		"RETURNING failures")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, kind, subject, resetAfter.Seconds()).Scan(&failures)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	log.Printf("Failed login attempt %d for %s %s", failures, kind, subject)
+	return failures, nil
+}
+
+func (r *LoginFailureRepo) LockLogin(ctx context.Context, kind, subject string, until time.Time) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE login_failure SET locked_until = $3 WHERE kind = $1 AND subject = $2")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, kind, subject, until)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Lock login for %s %s until %s", kind, subject, until.Format(time.RFC3339))
+	return nil
+}
+
+func (r *LoginFailureRepo) ResetLoginFailures(ctx context.Context, kind, subject string) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM login_failure WHERE kind = $1 AND subject = $2")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, kind, subject)
+	return dbError(err)
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"time"
+)
+
+type OIDCRepo struct {
+	db DBTX
+}
+
+func NewOIDCRepo(db DBTX) *OIDCRepo {
+	return &OIDCRepo{db: db}
+}
+
+func (r *OIDCRepo) CreateOIDCLogin(ctx context.Context, stateHash, nonce, codeVerifier string, expiresAt time.Time) error {
+	// logins abandoned at the provider are never used
+	_, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login WHERE expires_at <= now()")
+	if err != nil {
+		return dbError(err)
+	}
+
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO oidc_login (state_hash, nonce, code_verifier, expires_at) "+
+		"VALUES ($1, $2, $3, $4)")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, stateHash, nonce, codeVerifier, expiresAt)
+	return dbError(err)
+}
+
+func (r *OIDCRepo) UseOIDCLogin(ctx context.Context, stateHash string) (entity.OIDCLogin, error) {
+	login := entity.OIDCLogin{}
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM oidc_login WHERE state_hash = $1 AND expires_at > now() "+
+		"RETURNING nonce, code_verifier")
+	if err != nil {
+		return entity.OIDCLogin{}, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, stateHash).Scan(&login.Nonce, &login.CodeVerifier)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.OIDCLogin{}, &entity.NotFoundError{Entity: "login"}
+	}
+	if err != nil {
+		return entity.OIDCLogin{}, dbError(err)
+	}
+	return login, nil
+}
+
+// TouchIdentity records a login with an external identity and returns the
+// linked user, 0 if the identity is not linked yet
+func (r *OIDCRepo) TouchIdentity(ctx context.Context, issuer, subject string) (int, error) {
+	var userId int
+	query, err := r.db.PrepareContext(ctx, "UPDATE user_identity SET last_login_at = now() "+
+		"WHERE issuer = $1 AND subject = $2 RETURNING user_id")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, issuer, subject).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return userId, nil
+}
+
+func (r *OIDCRepo) CreateIdentity(ctx context.Context, userId int, issuer, subject string) error {
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO user_identity (user_id, issuer, subject) VALUES ($1, $2, $3)")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, userId, issuer, subject)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Link user %d to %s identity %s", userId, issuer, subject)
+	return nil
+}
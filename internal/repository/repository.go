@@ -1,61 +1,187 @@
 package repository
 
 import (
-	"database/sql"
+	"context"
 	"filmLibraryVk/api/REST/presenter"
 	"filmLibraryVk/internal/model/entity"
+	"time"
 )
 
 type Actor interface {
-	GetActor(id int) (presenter.ActorResponse, error)
-	GetActors() ([]presenter.ActorResponse, error)
+	GetActor(ctx context.Context, id int) (presenter.ActorResponse, error)
+	GetActors(ctx context.Context) ([]presenter.ActorResponse, error)
 
-	CreateActor(request presenter.ActorRequest) (int, error)
+	CreateActor(ctx context.Context, request presenter.ActorRequest) (int, error)
 
-	PutActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error)
-	PatchActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error)
+	PutActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error)
+	PatchActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error)
 
-	DeleteActor(id int) error
+	DeleteActor(ctx context.Context, id, version int) error
 }
 
 type Film interface {
-	GetFilm(id int) (presenter.FilmResponse, error)
-	GetFilms(sortBy string) ([]presenter.FilmResponse, error)
+	GetFilm(ctx context.Context, id int) (presenter.FilmResponse, error)
+	GetFilms(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error)
 
-	CreateFilm(request presenter.FilmRequest) (int, error)
+	CreateFilm(ctx context.Context, request presenter.FilmRequest) (int, error)
 
-	PutFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error)
-	PatchFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error)
+	PutFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error)
+	PatchFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error)
 
-	DeleteFilm(id int) error
+	DeleteFilm(ctx context.Context, id, version int) error
 
-	SearchFilmsByName(name string) ([]presenter.FilmResponse, error)
-	SearchFilmsByActor(name string) ([]presenter.FilmResponse, error)
+	SearchFilmsByName(ctx context.Context, name string) ([]presenter.FilmResponse, error)
+	SearchFilmsByActor(ctx context.Context, name string) ([]presenter.FilmResponse, error)
 }
 
 type User interface {
-	GetUserById(id int) (presenter.UserResponse, error)
-	GetUserByUsername(username string) (entity.User, error)
-	GetUsers() ([]presenter.UserResponse, error)
+	GetUserById(ctx context.Context, id int) (presenter.UserResponse, error)
+	GetUserByUsername(ctx context.Context, username string) (entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (entity.User, error)
+	GetUserCredentials(ctx context.Context, id int) (entity.User, error)
+	GetUsers(ctx context.Context, status string) ([]presenter.UserResponse, error)
+	GetPendingUsers(ctx context.Context) ([]presenter.UserResponse, error)
+
+	PutUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error)
+	PatchUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error)
+
+	DeleteUser(ctx context.Context, id, version int) error
+
+	CreateUser(ctx context.Context, register presenter.Register, role, status string) (int, error)
+	UpdatePassword(ctx context.Context, id int, hash string) error
+	SetUserRole(ctx context.Context, id int, role string) error
+	VerifyEmail(ctx context.Context, id int) error
+	ApproveUser(ctx context.Context, id int) (bool, error)
+	DeletePendingUser(ctx context.Context, id int) (bool, error)
+	SuspendUser(ctx context.Context, id int, status, reason string, until *time.Time, suspendedBy int) (bool, error)
+	ReinstateUser(ctx context.Context, id int) (bool, error)
+}
+
+type Role interface {
+	GetRole(ctx context.Context, id int) (presenter.RoleResponse, error)
+	GetRoleByName(ctx context.Context, role string) (presenter.RoleResponse, error)
+	GetRoles(ctx context.Context) ([]presenter.RoleResponse, error)
+	GetRolePermissions(ctx context.Context, id int) ([]string, error)
+
+	CreateRole(ctx context.Context, request presenter.RoleRequest) (int, error)
+
+	PutRole(ctx context.Context, id int, request presenter.RoleRequest) (presenter.RoleResponse, error)
+
+	DeleteRole(ctx context.Context, id int) error
+}
 
-	PutUser(id int, request presenter.UserRequest) (presenter.UserResponse, error)
-	PatchUser(id int, request presenter.UserRequest) (presenter.UserResponse, error)
+type Invite interface {
+	GetInvites(ctx context.Context) ([]presenter.InviteResponse, error)
+	CreateInvite(ctx context.Context, createdBy int, codeHash string, expiresAt time.Time) (presenter.InviteResponse, error)
+	UseInvite(ctx context.Context, codeHash string, userId int) (bool, error)
+	DeleteInvite(ctx context.Context, id int) error
+}
+
+type LoginFailure interface {
+	GetLoginFailure(ctx context.Context, kind, subject string) (entity.LoginFailure, error)
+	RecordLoginFailure(ctx context.Context, kind, subject string, resetAfter time.Duration) (int, error)
+	LockLogin(ctx context.Context, kind, subject string, until time.Time) error
+	ResetLoginFailures(ctx context.Context, kind, subject string) error
+}
+
+type UserToken interface {
+	CreateUserToken(ctx context.Context, userId int, purpose, tokenHash string, expiresAt time.Time) error
+	GetUserToken(ctx context.Context, purpose, tokenHash string) (int, error)
+	UseUserToken(ctx context.Context, purpose, tokenHash string) (int, error)
+	DeleteUserTokens(ctx context.Context, userId int, purpose string) error
+}
 
-	DeleteUser(id int) error
+type TwoFactor interface {
+	GetTwoFactor(ctx context.Context, userId int) (entity.TwoFactor, error)
+	SaveTwoFactorSecret(ctx context.Context, userId int, secret string) (bool, error)
+	EnableTwoFactor(ctx context.Context, userId int) error
+	UseTwoFactorStep(ctx context.Context, userId int, step int64) (bool, error)
+	DeleteTwoFactor(ctx context.Context, userId int) error
+
+	ReplaceRecoveryCodes(ctx context.Context, userId int, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userId int) (int, error)
+}
+
+type ApiKey interface {
+	GetApiKeys(ctx context.Context) ([]presenter.ApiKeyResponse, error)
+	GetApiKeyByHash(ctx context.Context, keyHash string) (entity.ApiKey, error)
+	CreateApiKey(ctx context.Context, createdBy int, name, prefix, keyHash string, scopes []string,
+		expiresAt *time.Time) (presenter.ApiKeyResponse, error)
+	TouchApiKey(ctx context.Context, id int) error
+	RevokeApiKey(ctx context.Context, id int) (bool, error)
+}
+
+type OIDC interface {
+	CreateOIDCLogin(ctx context.Context, stateHash, nonce, codeVerifier string, expiresAt time.Time) error
+	UseOIDCLogin(ctx context.Context, stateHash string) (entity.OIDCLogin, error)
+	TouchIdentity(ctx context.Context, issuer, subject string) (int, error)
+	CreateIdentity(ctx context.Context, userId int, issuer, subject string) error
+}
+
+type Idempotency interface {
+	ReserveIdempotencyKey(ctx context.Context, owner, key, fingerprint string,
+		expiresAt time.Time) (entity.IdempotentRequest, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, owner, key string, response entity.IdempotentRequest,
+		expiresAt time.Time) error
+	ReleaseIdempotencyKey(ctx context.Context, owner, key string) error
+}
+
+type Impersonation interface {
+	CreateImpersonation(ctx context.Context, actorId, subjectId int, reason string, expiresAt time.Time) (int, error)
+}
 
-	CreateUser(register presenter.Register) (int, error)
+type Token interface {
+	CreateSession(ctx context.Context, userId int, client presenter.Client) (int, error)
+	TouchSession(ctx context.Context, id int, client presenter.Client) error
+	GetSessions(ctx context.Context, userId int) ([]presenter.SessionResponse, error)
+	RevokeSession(ctx context.Context, userId, id int) (bool, error)
+	RevokeSessions(ctx context.Context, userId, exceptId int) error
+
+	CreateRefreshToken(ctx context.Context, userId, sessionId int, tokenHash string, expiresAt time.Time) (int, error)
+	GetRefreshToken(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id int) (bool, error)
+	RevokeUserTokens(ctx context.Context, userId int) error
+	RevokeRoleAccessTokens(ctx context.Context, roleId int) error
+
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string, userId, sessionId int, issuedAt time.Time) (bool, error)
 }
 
 type Repository struct {
 	Actor
 	Film
 	User
+	Role
+	Invite
+	LoginFailure
+	UserToken
+	TwoFactor
+	ApiKey
+	OIDC
+	Impersonation
+	Token
+	Idempotency
+	UnitOfWork
 }
 
-func NewRepository(db *sql.DB) *Repository {
+// NewRepository returns repositories running statements on db, the database
+// or a transaction
+func NewRepository(db DBTX) *Repository {
 	return &Repository{
-		Actor: NewActorRepo(db),
-		Film:  NewFilmRepo(db),
-		User:  NewUserRepo(db),
+		Actor:         NewActorRepo(db),
+		Film:          NewFilmRepo(db),
+		User:          NewUserRepo(db),
+		Role:          NewRoleRepo(db),
+		Invite:        NewInviteRepo(db),
+		LoginFailure:  NewLoginFailureRepo(db),
+		UserToken:     NewUserTokenRepo(db),
+		TwoFactor:     NewTwoFactorRepo(db),
+		ApiKey:        NewApiKeyRepo(db),
+		OIDC:          NewOIDCRepo(db),
+		Impersonation: NewImpersonationRepo(db),
+		Token:         NewTokenRepo(db),
+		Idempotency:   NewIdempotencyRepo(db),
+		UnitOfWork:    NewUnitOfWorkRepo(db),
 	}
 }
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+)
+
+type RoleRepo struct {
+	db DBTX
+}
+
+func NewRoleRepo(db DBTX) *RoleRepo {
+	return &RoleRepo{db: db}
+}
+
+func (r *RoleRepo) GetRole(ctx context.Context, id int) (presenter.RoleResponse, error) {
+	rol := presenter.RoleResponse{}
+	permissions := make([]string, 0)
+	var permission sql.NullString
+
+	query, err := r.db.PrepareContext(ctx, "SELECT role.id, role, permission FROM role "+
+		"LEFT JOIN role_permission ON role.id = role_permission.role_id "+
+		"LEFT JOIN permission ON role_permission.permission_id = permission.id "+
+		"WHERE role.id = $1 ORDER BY permission")
+
+	if err != nil {
+		return presenter.RoleResponse{}, dbError(err)
+	}
+
+	defer query.Close()
+	rows, err := query.QueryContext(ctx, id)
+
+	if err != nil {
+		return presenter.RoleResponse{}, dbError(err)
+	}
+
+	for rows.Next() {
+		err = rows.Scan(&rol.Id, &rol.Role, &permission)
+		if err != nil {
+			return presenter.RoleResponse{}, dbError(err)
+		}
+		if permission.Valid {
+			permissions = append(permissions, permission.String)
+		}
+	}
+	if rol.Id != id {
+		return presenter.RoleResponse{}, &entity.NotFoundError{Entity: "role"}
+	}
+	rol.Permissions = permissions
+	log.Printf("Get role with id %d", id)
+	return rol, nil
+}
+
+func (r *RoleRepo) GetRoleByName(ctx context.Context, role string) (presenter.RoleResponse, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM role WHERE role = $1", role).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return presenter.RoleResponse{}, &entity.NotFoundError{Entity: "role"}
+	}
+	if err != nil {
+		return presenter.RoleResponse{}, dbError(err)
+	}
+	return r.GetRole(ctx, id)
+}
+
+func (r *RoleRepo) GetRoles(ctx context.Context) ([]presenter.RoleResponse, error) {
+	roles := make([]presenter.RoleResponse, 0)
+	isRoleExistsMap := make(map[int]int)
+	mapPermissions := make(map[int][]string)
+
+	rol := presenter.RoleResponse{}
+	var permission sql.NullString
+
+	query, err := r.db.PrepareContext(ctx, "SELECT role.id, role, permission FROM role "+
+		"LEFT JOIN role_permission ON role.id = role_permission.role_id "+
+		"LEFT JOIN permission ON role_permission.permission_id = permission.id "+
+		"ORDER BY role.id, permission")
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer query.Close()
+	rows, err := query.QueryContext(ctx)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for rows.Next() {
+		err = rows.Scan(&rol.Id, &rol.Role, &permission)
+		if err != nil {
+			return nil, dbError(err)
+		}
+
+		_, ok := mapPermissions[rol.Id]
+		if !ok {
+			mapPermissions[rol.Id] = make([]string, 0)
+		}
+		if permission.Valid {
+			mapPermissions[rol.Id] = append(mapPermissions[rol.Id], permission.String)
+		}
+		_, ok = isRoleExistsMap[rol.Id]
+		if !ok {
+			roles = append(roles, rol)
+			isRoleExistsMap[rol.Id] = rol.Id
+		}
+	}
+	for i := range roles {
+		roles[i].Permissions = mapPermissions[roles[i].Id]
+	}
+	log.Printf("Get roles")
+	return roles, nil
+}
+
+func (r *RoleRepo) GetRolePermissions(ctx context.Context, id int) ([]string, error) {
+	permissions := make([]string, 0)
+	var permission string
+
+	query, err := r.db.PrepareContext(ctx, "SELECT permission FROM permission "+
+		"JOIN role_permission ON permission.id = role_permission.permission_id "+
+		"WHERE role_permission.role_id = $1")
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer query.Close()
+	rows, err := query.QueryContext(ctx, id)
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(&permission); err != nil {
+			return nil, dbError(err)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+func (r *RoleRepo) CreateRole(ctx context.Context, request presenter.RoleRequest) (int, error) {
+	var id int
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO role (role) VALUES ($1) RETURNING id")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, request.Role).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	err = r.updatePermissions(ctx, request, id)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Insert role with id %d", id)
+	return id, nil
+}
+
+func (r *RoleRepo) PutRole(ctx context.Context, id int, request presenter.RoleRequest) (presenter.RoleResponse, error) {
+	var updatedId int
+	query, err := r.db.PrepareContext(ctx, "UPDATE role SET role = $1 WHERE id = $2 RETURNING id")
+	if err != nil {
+		return presenter.RoleResponse{}, dbError(err)
+	}
+	defer query.Close()
+	row, err := query.QueryContext(ctx, request.Role, id)
+
+	if err != nil {
+		return presenter.RoleResponse{}, dbError(err)
+	}
+
+	for row.Next() {
+		if err := row.Scan(&updatedId); err != nil {
+			return presenter.RoleResponse{}, dbError(err)
+		}
+	}
+	if updatedId != id {
+		return presenter.RoleResponse{}, &entity.NotFoundError{Entity: "role"}
+	}
+
+	err = r.updatePermissions(ctx, request, id)
+	if err != nil {
+		return presenter.RoleResponse{}, err
+	}
+
+	log.Printf("Put role with id %d", id)
+	return r.GetRole(ctx, id)
+}
+
+func (r *RoleRepo) DeleteRole(ctx context.Context, id int) error {
+	var users int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM _user WHERE role_id = $1", id).Scan(&users)
+	if err != nil {
+		return dbError(err)
+	}
+	if users != 0 {
+		return &entity.ConflictError{Message: "Role is assigned to users"}
+	}
+
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM role WHERE id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+	_, err = query.ExecContext(ctx, id)
+
+	if err != nil {
+		return dbError(err)
+	}
+	log.Printf("Delete role with id %d", id)
+
+	return nil
+}
+
+func (r *RoleRepo) updatePermissions(ctx context.Context, request presenter.RoleRequest, id int) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM role_permission WHERE role_id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, id)
+	if err != nil {
+		return dbError(err)
+	}
+
+	query, err = r.db.PrepareContext(ctx, "INSERT INTO role_permission (role_id, permission_id) "+
+		"SELECT $1, id FROM permission WHERE permission = $2")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	for _, val := range request.Permissions {
+		result, err := query.ExecContext(ctx, id, val)
+		if err != nil {
+			return dbError(err)
+		}
+		if inserted, _ := result.RowsAffected(); inserted == 0 {
+			return &entity.ValidationError{Message: "Permission " + val + " does not exist",
+				Fields: []entity.FieldError{{Field: "permissions", Message: "does not exist"}}}
+		}
+	}
+	return nil
+}
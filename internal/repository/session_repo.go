@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"log"
+)
+
+func (r *TokenRepo) CreateSession(ctx context.Context, userId int, client presenter.Client) (int, error) {
+	var id int
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO session (user_id, user_agent, ip) VALUES ($1, $2, $3) RETURNING id")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, userId, client.UserAgent, client.Ip).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	log.Printf("Create session %d for user %d", id, userId)
+	return id, nil
+}
+
+func (r *TokenRepo) TouchSession(ctx context.Context, id int, client presenter.Client) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE session SET user_agent = $2, ip = $3, last_used_at = now() WHERE id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, id, client.UserAgent, client.Ip)
+	return dbError(err)
+}
+
+func (r *TokenRepo) GetSessions(ctx context.Context, userId int) ([]presenter.SessionResponse, error) {
+	sessions := make([]presenter.SessionResponse, 0)
+
+	query, err := r.db.PrepareContext(ctx, "SELECT s.id, s.user_agent, s.ip, s.created_at, s.last_used_at FROM session s "+
+		"WHERE s.user_id = $1 AND s.revoked_at IS NULL AND EXISTS (SELECT 1 FROM refresh_token t "+
+		"WHERE t.session_id = s.id AND t.revoked_at IS NULL AND t.expires_at > now()) "+
+		"ORDER BY s.last_used_at DESC")
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer query.Close()
+
+	rows, err := query.QueryContext(ctx, userId)
+	if err != nil {
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session := presenter.SessionResponse{}
+		err := rows.Scan(&session.Id, &session.UserAgent, &session.Ip, &session.CreatedAt, &session.LastUsedAt)
+		if err != nil {
+			return nil, dbError(err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *TokenRepo) RevokeSession(ctx context.Context, userId, id int) (bool, error) {
+	var revoked int64
+	err := inTransaction(ctx, r.db, func(tx DBTX) error {
+		query, err := tx.PrepareContext(ctx, "UPDATE session SET revoked_at = now() "+
+			"WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL")
+		if err != nil {
+			return dbError(err)
+		}
+		defer query.Close()
+
+		result, err := query.ExecContext(ctx, id, userId)
+		if err != nil {
+			return dbError(err)
+		}
+		revoked, err = result.RowsAffected()
+		if err != nil {
+			return dbError(err)
+		}
+		if revoked == 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE refresh_token SET revoked_at = now() WHERE session_id = $1 AND revoked_at IS NULL", id)
+		return dbError(err)
+	})
+	if err != nil {
+		return false, dbError(err)
+	}
+	if revoked == 0 {
+		return false, nil
+	}
+
+	log.Printf("Revoke session %d of user %d", id, userId)
+	return true, nil
+}
+
+func (r *TokenRepo) RevokeSessions(ctx context.Context, userId, exceptId int) error {
+	err := inTransaction(ctx, r.db, func(tx DBTX) error {
+		query, err := tx.PrepareContext(ctx, "UPDATE session SET revoked_at = now() "+
+			"WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL")
+		if err != nil {
+			return dbError(err)
+		}
+		defer query.Close()
+
+		_, err = query.ExecContext(ctx, userId, exceptId)
+		if err != nil {
+			return dbError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE refresh_token SET revoked_at = now() "+
+			"WHERE user_id = $1 AND session_id <> $2 AND revoked_at IS NULL", userId, exceptId)
+		return dbError(err)
+	})
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Revoke sessions of user %d except %d", userId, exceptId)
+	return nil
+}
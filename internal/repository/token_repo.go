@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"time"
+)
+
+// tokenCutoff is the tokens_valid_after of a revocation made now. Tokens carry
+// their issue time in whole seconds, so the cutoff has whole seconds too
+const tokenCutoff = "date_trunc('second', now())"
+
+type TokenRepo struct {
+	db DBTX
+}
+
+func NewTokenRepo(db DBTX) *TokenRepo {
+	return &TokenRepo{db: db}
+}
+
+func (r *TokenRepo) CreateRefreshToken(ctx context.Context, userId, sessionId int, tokenHash string, expiresAt time.Time) (int, error) {
+	var id int
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO refresh_token (user_id, session_id, token_hash, expires_at) "+
+		"VALUES ($1, $2, $3, $4) RETURNING id")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, userId, sessionId, tokenHash, expiresAt).Scan(&id)
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	log.Printf("Issue refresh token %d for user %d", id, userId)
+	return id, nil
+}
+
+func (r *TokenRepo) GetRefreshToken(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	token := entity.RefreshToken{}
+	var revokedAt, sessionRevokedAt sql.NullTime
+
+	query, err := r.db.PrepareContext(ctx, "SELECT t.id, t.user_id, t.session_id, t.token_hash, t.created_at, t.expires_at, "+
+		"t.revoked_at, s.revoked_at FROM refresh_token t JOIN session s ON s.id = t.session_id "+
+		"WHERE t.token_hash = $1")
+	if err != nil {
+		return entity.RefreshToken{}, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, tokenHash).Scan(&token.Id, &token.UserId, &token.SessionId, &token.TokenHash,
+		&token.CreatedAt, &token.ExpiresAt, &revokedAt, &sessionRevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.RefreshToken{}, &entity.NotFoundError{Entity: "refresh token"}
+	}
+	if err != nil {
+		return entity.RefreshToken{}, dbError(err)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	if sessionRevokedAt.Valid {
+		token.SessionRevokedAt = &sessionRevokedAt.Time
+	}
+	return token, nil
+}
+
+func (r *TokenRepo) RevokeRefreshToken(ctx context.Context, id int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE refresh_token SET revoked_at = now() "+
+		"WHERE id = $1 AND revoked_at IS NULL")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id)
+	if err != nil {
+		return false, dbError(err)
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Revoke refresh token %d", id)
+	return revoked == 1, nil
+}
+
+func (r *TokenRepo) RevokeUserTokens(ctx context.Context, userId int) error {
+	err := inTransaction(ctx, r.db, func(tx DBTX) error {
+		_, err := tx.ExecContext(ctx, "UPDATE refresh_token SET revoked_at = now() "+
+			"WHERE user_id = $1 AND revoked_at IS NULL", userId)
+		if err != nil {
+			return dbError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE session SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL", userId)
+		if err != nil {
+			return dbError(err)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE _user SET tokens_valid_after = "+tokenCutoff+" WHERE id = $1", userId)
+		return dbError(err)
+	})
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Revoke all tokens of user %d", userId)
+	return nil
+}
+
+// RevokeRoleAccessTokens invalidates the access tokens of every user with
+// the role, whose permissions they carry. Refresh tokens stay valid, tokens
+// issued from them carry the permissions the role has now
+func (r *TokenRepo) RevokeRoleAccessTokens(ctx context.Context, roleId int) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET tokens_valid_after = "+tokenCutoff+" WHERE role_id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, roleId)
+	if err != nil {
+		return dbError(err)
+	}
+	if users, err := result.RowsAffected(); err == nil {
+		log.Printf("Revoke access tokens of %d users with role %d", users, roleId)
+	}
+	return nil
+}
+
+func (r *TokenRepo) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM revoked_token WHERE expires_at < now()")
+	if err != nil {
+		return dbError(err)
+	}
+
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO revoked_token (jti, expires_at) VALUES ($1, $2) "+
+		"ON CONFLICT (jti) DO NOTHING")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, jti, expiresAt)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Revoke access token %s", jti)
+	return nil
+}
+
+// IsAccessTokenRevoked also reports tokens of suspended, banned or deleted
+// users as revoked, so a suspension takes effect immediately
+func (r *TokenRepo) IsAccessTokenRevoked(ctx context.Context, jti string, userId, sessionId int, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	var validAfter time.Time
+	query, err := r.db.PrepareContext(ctx, "SELECT EXISTS (SELECT 1 FROM revoked_token WHERE jti = $1) "+
+		"OR "+userStatus+" IN ('suspended', 'banned') "+
+		"OR EXISTS (SELECT 1 FROM session WHERE id = $3 AND revoked_at IS NOT NULL), tokens_valid_after "+
+		"FROM _user WHERE id = $2")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, jti, userId, sessionId).Scan(&revoked, &validAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, dbError(err)
+	}
+	return revoked || !issuedAfter(validAfter, issuedAt), nil
+}
+
+// issuedAfter reports whether a token issued at issuedAt is newer than a
+// revocation at cutoff. Both are whole seconds, so a token of the second of
+// the revocation may have been issued before it and is refused
+func issuedAfter(cutoff, issuedAt time.Time) bool {
+	return issuedAt.After(cutoff)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"github.com/go-playground/assert/v2"
+	"testing"
+	"time"
+)
+
+func TestIssuedAfter(t *testing.T) {
+	cutoff := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		expected bool
+	}{
+		{
+			name:     "Before revocation",
+			issuedAt: cutoff.Add(-time.Second),
+			expected: false,
+		},
+		{
+			name:     "Same second as revocation",
+			issuedAt: cutoff,
+			expected: false,
+		},
+		{
+			name:     "After revocation",
+			issuedAt: cutoff.Add(time.Second),
+			expected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, issuedAfter(cutoff, test.issuedAt), test.expected)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+)
+
+type TwoFactorRepo struct {
+	db DBTX
+}
+
+func NewTwoFactorRepo(db DBTX) *TwoFactorRepo {
+	return &TwoFactorRepo{db: db}
+}
+
+// GetTwoFactor returns an empty secret if the user has not started enrollment
+func (r *TwoFactorRepo) GetTwoFactor(ctx context.Context, userId int) (entity.TwoFactor, error) {
+	twoFactor := entity.TwoFactor{UserId: userId}
+	var enabledAt sql.NullTime
+	var lastUsedStep sql.NullInt64
+
+	query, err := r.db.PrepareContext(ctx, "SELECT secret, enabled_at, last_used_step FROM two_factor WHERE user_id = $1")
+	if err != nil {
+		return entity.TwoFactor{}, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, userId).Scan(&twoFactor.Secret, &enabledAt, &lastUsedStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return twoFactor, nil
+	}
+	if err != nil {
+		return entity.TwoFactor{}, dbError(err)
+	}
+	if enabledAt.Valid {
+		twoFactor.EnabledAt = &enabledAt.Time
+	}
+	twoFactor.LastUsedStep = lastUsedStep.Int64
+	return twoFactor, nil
+}
+
+// SaveTwoFactorSecret starts or restarts an enrollment. An enabled secret is
+// never replaced, it has to be disabled first
+func (r *TwoFactorRepo) SaveTwoFactorSecret(ctx context.Context, userId int, secret string) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO two_factor (user_id, secret) VALUES ($1, $2) "+
+		"ON CONFLICT (user_id) DO UPDATE SET secret = $2, created_at = now(), last_used_step = NULL "+
+		"WHERE two_factor.enabled_at IS NULL")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, userId, secret)
+	if err != nil {
+		return false, dbError(err)
+	}
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Start two factor enrollment of user %d", userId)
+	return saved == 1, nil
+}
+
+func (r *TwoFactorRepo) EnableTwoFactor(ctx context.Context, userId int) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE two_factor SET enabled_at = now() WHERE user_id = $1 AND enabled_at IS NULL")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, userId)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Enable two factor authentication of user %d", userId)
+	return nil
+}
+
+// UseTwoFactorStep records the time step of an accepted code. It returns
+// false if a code of this or a later step was already used
+func (r *TwoFactorRepo) UseTwoFactorStep(ctx context.Context, userId int, step int64) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE two_factor SET last_used_step = $2 "+
+		"WHERE user_id = $1 AND (last_used_step IS NULL OR last_used_step < $2)")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, userId, step)
+	if err != nil {
+		return false, dbError(err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+	return used == 1, nil
+}
+
+func (r *TwoFactorRepo) DeleteTwoFactor(ctx context.Context, userId int) error {
+	err := inTransaction(ctx, r.db, func(tx DBTX) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_code WHERE user_id = $1", userId); err != nil {
+			return dbError(err)
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM two_factor WHERE user_id = $1", userId)
+		return dbError(err)
+	})
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Disable two factor authentication of user %d", userId)
+	return nil
+}
+
+func (r *TwoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userId int, codeHashes []string) error {
+	err := inTransaction(ctx, r.db, func(tx DBTX) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_code WHERE user_id = $1", userId); err != nil {
+			return dbError(err)
+		}
+		for _, codeHash := range codeHashes {
+			_, err := tx.ExecContext(ctx, "INSERT INTO recovery_code (user_id, code_hash) VALUES ($1, $2)", userId, codeHash)
+			if err != nil {
+				return dbError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Issue %d recovery codes for user %d", len(codeHashes), userId)
+	return nil
+}
+
+func (r *TwoFactorRepo) UseRecoveryCode(ctx context.Context, userId int, codeHash string) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE recovery_code SET used_at = now() "+
+		"WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, userId, codeHash)
+	if err != nil {
+		return false, dbError(err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+	if used == 1 {
+		log.Printf("Use recovery code of user %d", userId)
+	}
+	return used == 1, nil
+}
+
+func (r *TwoFactorRepo) CountRecoveryCodes(ctx context.Context, userId int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM recovery_code WHERE user_id = $1 AND used_at IS NULL",
+		userId).Scan(&count)
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return count, nil
+}
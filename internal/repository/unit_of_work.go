@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// DBTX runs statements on the database or inside a transaction
+type DBTX interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// UnitOfWork makes writes spanning several statements or repositories atomic
+type UnitOfWork interface {
+	// Do runs fn with repositories sharing one transaction, committed when fn
+	// returns nil and rolled back otherwise, also when ctx is done first. Do
+	// of the repositories given to fn joins the running transaction
+	Do(ctx context.Context, fn func(repo *Repository) error) error
+}
+
+type UnitOfWorkRepo struct {
+	db DBTX
+}
+
+func NewUnitOfWorkRepo(db DBTX) *UnitOfWorkRepo {
+	return &UnitOfWorkRepo{db: db}
+}
+
+func (u *UnitOfWorkRepo) Do(ctx context.Context, fn func(repo *Repository) error) error {
+	return inTransaction(ctx, u.db, func(tx DBTX) error {
+		return fn(NewRepository(tx))
+	})
+}
+
+// inTransaction runs fn in a new transaction, or in the one db already is
+func inTransaction(ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	sqlDB, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return dbError(err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("Can not roll back transaction: %s", rollbackErr.Error())
+		}
+		return err
+	}
+	return dbError(tx.Commit())
+}
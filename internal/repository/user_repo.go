@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
@@ -9,122 +10,233 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type UserRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewUserRepo(db *sql.DB) *UserRepo {
+func NewUserRepo(db DBTX) *UserRepo {
 	return &UserRepo{db: db}
 }
 
-func (r *UserRepo) GetUserById(id int) (presenter.UserResponse, error) {
+// userStatus is the status of a user, a suspension that has run out counts as active
+const userStatus = "CASE WHEN status = 'suspended' AND suspended_until <= now() THEN 'active' ELSE status END"
+
+const suspensionColumns = "COALESCE(suspension_reason, ''), suspended_until, suspended_by, suspended_at"
+
+type suspensionRow struct {
+	reason string
+	until  *time.Time
+	by     *int
+	at     *time.Time
+}
+
+// response is the suspension shown for a user in status, nil unless suspended or banned
+func (s *suspensionRow) response(status string) *presenter.Suspension {
+	if status != entity.UserStatusSuspended && status != entity.UserStatusBanned || s.at == nil {
+		return nil
+	}
+	return &presenter.Suspension{Reason: s.reason, Until: s.until, SuspendedBy: s.by, SuspendedAt: *s.at}
+}
+
+func (r *UserRepo) GetUserById(ctx context.Context, id int) (presenter.UserResponse, error) {
 	_user := presenter.UserResponse{}
 
-	query, err := r.db.Prepare("SELECT _user.id, _user.username, role FROM _user " +
-		"JOIN role ON _user.role_id = role.id " +
+	query, err := r.db.PrepareContext(ctx, "SELECT _user.id, _user.username, role, "+userStatus+", COALESCE(email, ''), "+
+		suspensionColumns+", _user.version FROM _user "+
+		"JOIN role ON _user.role_id = role.id "+
 		"WHERE _user.id = $1")
 
 	if err != nil {
-		return presenter.UserResponse{}, err
+		return presenter.UserResponse{}, dbError(err)
 	}
 
 	defer query.Close()
-	row, err := query.Query(id)
+	row, err := query.QueryContext(ctx, id)
 
 	if err != nil {
-		return presenter.UserResponse{}, err
+		return presenter.UserResponse{}, dbError(err)
 	}
 
 	for row.Next() {
-		err = row.Scan(&_user.Id, &_user.Username, &_user.Role)
+		var suspension suspensionRow
+		err = row.Scan(&_user.Id, &_user.Username, &_user.Role, &_user.Status, &_user.Email,
+			&suspension.reason, &suspension.until, &suspension.by, &suspension.at, &_user.Version)
 		if err != nil {
-			return presenter.UserResponse{}, err
+			return presenter.UserResponse{}, dbError(err)
 		}
+		_user.Suspension = suspension.response(_user.Status)
 	}
 	if _user.Id != id {
-		return presenter.UserResponse{}, errors.New("entity not found")
+		return presenter.UserResponse{}, &entity.NotFoundError{Entity: "user"}
 	}
 	log.Printf("Get user with id %d", id)
 	return _user, nil
 }
 
-func (r *UserRepo) GetUserByUsername(username string) (entity.User, error) {
+func (r *UserRepo) GetUserByUsername(ctx context.Context, username string) (entity.User, error) {
 	_user := entity.User{}
 
-	query, err := r.db.Prepare("SELECT * FROM _user " +
+	query, err := r.db.PrepareContext(ctx, "SELECT id, username, password, role_id, "+userStatus+", COALESCE(email, ''), "+
+		"email_verified_at IS NOT NULL, COALESCE(suspension_reason, ''), suspended_until FROM _user "+
 		"WHERE _user.username = $1")
 
 	if err != nil {
-		return entity.User{}, err
+		return entity.User{}, dbError(err)
 	}
 
 	defer query.Close()
-	row, err := query.Query(username)
+	row, err := query.QueryContext(ctx, username)
 
 	if err != nil {
-		return entity.User{}, err
+		return entity.User{}, dbError(err)
 	}
 
 	for row.Next() {
-		err = row.Scan(&_user.Id, &_user.Username, &_user.Password, &_user.RoleId)
+		err = row.Scan(&_user.Id, &_user.Username, &_user.Password, &_user.RoleId, &_user.Status,
+			&_user.Email, &_user.EmailVerified, &_user.SuspensionReason, &_user.SuspendedUntil)
 		if err != nil {
-			return entity.User{}, err
+			return entity.User{}, dbError(err)
 		}
 	}
 	if _user.Username != username {
-		return entity.User{}, errors.New("entity not found")
+		return entity.User{}, &entity.NotFoundError{Entity: "user"}
 	}
 	log.Printf("Get user with username %s", username)
 	return _user, nil
 }
 
-func (r *UserRepo) GetUsers() ([]presenter.UserResponse, error) {
+func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM _user WHERE email = $1", email).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.User{}, &entity.NotFoundError{Entity: "user"}
+	}
+	if err != nil {
+		return entity.User{}, dbError(err)
+	}
+	return r.GetUserCredentials(ctx, id)
+}
+
+func (r *UserRepo) GetUserCredentials(ctx context.Context, id int) (entity.User, error) {
+	_user := entity.User{}
+
+	query, err := r.db.PrepareContext(ctx, "SELECT id, username, password, role_id, "+userStatus+", COALESCE(email, ''), "+
+		"email_verified_at IS NOT NULL, COALESCE(suspension_reason, ''), suspended_until FROM _user "+
+		"WHERE _user.id = $1")
+
+	if err != nil {
+		return entity.User{}, dbError(err)
+	}
+
+	defer query.Close()
+	row, err := query.QueryContext(ctx, id)
+
+	if err != nil {
+		return entity.User{}, dbError(err)
+	}
+
+	for row.Next() {
+		err = row.Scan(&_user.Id, &_user.Username, &_user.Password, &_user.RoleId, &_user.Status,
+			&_user.Email, &_user.EmailVerified, &_user.SuspensionReason, &_user.SuspendedUntil)
+		if err != nil {
+			return entity.User{}, dbError(err)
+		}
+	}
+	if _user.Id != id {
+		return entity.User{}, &entity.NotFoundError{Entity: "user"}
+	}
+	return _user, nil
+}
+
+// GetUsers returns the users in status, all users for an empty status
+func (r *UserRepo) GetUsers(ctx context.Context, status string) ([]presenter.UserResponse, error) {
 	users := make([]presenter.UserResponse, 0)
 	_user := presenter.UserResponse{}
 
-	query, err := r.db.Prepare("SELECT _user.id, _user.username, role FROM _user " +
-		"JOIN role ON _user.role_id = role.id ")
+	query, err := r.db.PrepareContext(ctx, "SELECT * FROM (SELECT _user.id, _user.username, role, "+userStatus+" AS status, "+
+		"COALESCE(email, ''), "+suspensionColumns+" FROM _user "+
+		"JOIN role ON _user.role_id = role.id) AS _user WHERE $1 = '' OR status = $1")
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	defer query.Close()
-	row, err := query.Query()
+	row, err := query.QueryContext(ctx, status)
 
 	if err != nil {
-		return nil, err
+		return nil, dbError(err)
 	}
 
 	for row.Next() {
-		err = row.Scan(&_user.Id, &_user.Username, &_user.Role)
+		var suspension suspensionRow
+		err = row.Scan(&_user.Id, &_user.Username, &_user.Role, &_user.Status, &_user.Email,
+			&suspension.reason, &suspension.until, &suspension.by, &suspension.at)
 		if err != nil {
-			return nil, err
+			return nil, dbError(err)
 		}
+		_user.Suspension = suspension.response(_user.Status)
 		users = append(users, _user)
 	}
 	log.Printf("Get users")
 	return users, nil
 }
 
-func (r *UserRepo) CreateUser(register presenter.Register) (int, error) {
+func (r *UserRepo) GetPendingUsers(ctx context.Context) ([]presenter.UserResponse, error) {
+	users := make([]presenter.UserResponse, 0)
+	_user := presenter.UserResponse{}
+
+	query, err := r.db.PrepareContext(ctx, "SELECT _user.id, _user.username, role, status, COALESCE(email, '') FROM _user "+
+		"JOIN role ON _user.role_id = role.id WHERE status = $1 ORDER BY _user.id")
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	defer query.Close()
+	row, err := query.QueryContext(ctx, entity.UserStatusPending)
+
+	if err != nil {
+		return nil, dbError(err)
+	}
+
+	for row.Next() {
+		err = row.Scan(&_user.Id, &_user.Username, &_user.Role, &_user.Status, &_user.Email)
+		if err != nil {
+			return nil, dbError(err)
+		}
+		users = append(users, _user)
+	}
+	log.Printf("Get pending users")
+	return users, nil
+}
+
+func (r *UserRepo) CreateUser(ctx context.Context, register presenter.Register, role, status string) (int, error) {
 	var id int
-	query, err := r.db.Prepare(`INSERT INTO _user (username, password, role_id) VALUES ($1, $2, $3) RETURNING id`)
+	query, err := r.db.PrepareContext(ctx, `INSERT INTO _user (username, password, role_id, status, email) `+
+		`VALUES ($1, $2, $3, $4, NULLIF($5, '')) RETURNING id`)
 	if err != nil {
-		return 0, err
+		return 0, dbError(err)
 	}
 	defer query.Close()
-	row, err := query.Query(register.Username, register.Password, 1)
 
+	roleId, err := r.getRoleId(ctx, role)
 	if err != nil {
 		return 0, err
 	}
 
+	row, err := query.QueryContext(ctx, register.Username, register.Password, roleId, status, register.Email)
+
+	if err != nil {
+		return 0, dbError(err)
+	}
+
 	for row.Next() {
 		if err := row.Scan(&id); err != nil {
-			return 0, err
+			return 0, dbError(err)
 		}
 	}
 
@@ -132,39 +244,34 @@ func (r *UserRepo) CreateUser(register presenter.Register) (int, error) {
 	return id, nil
 }
 
-func (r *UserRepo) PutUser(id int, request presenter.UserRequest) (presenter.UserResponse, error) {
-	var updatedId int
-	query, err := r.db.Prepare("UPDATE _user SET username = $1, password = $2, role_id = $3 WHERE id = $4 RETURNING id")
+func (r *UserRepo) PutUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error) {
+	var updatedId, updatedVersion int
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET username = $1, password = $2, role_id = $3 "+
+		"WHERE id = $4 AND ($5 = 0 OR version = $5) RETURNING id, version")
 	if err != nil {
-		return presenter.UserResponse{}, err
+		return presenter.UserResponse{}, dbError(err)
 	}
 	defer query.Close()
-	var role int
 
-	switch *request.Role {
-	case "ADMIN":
-		role = 1
-	case "USER":
-		role = 2
-	default:
-		return presenter.UserResponse{}, errors.New("Role " + *request.Role +
-			" does not exist. Available roles: USER, ADMIN")
+	role, err := r.getRoleId(ctx, *request.Role)
+	if err != nil {
+		return presenter.UserResponse{}, err
 	}
 
-	row, err := query.Query(*request.Username, *request.Password, role, id)
+	row, err := query.QueryContext(ctx, *request.Username, *request.Password, role, id, version)
 
 	if err != nil {
-		return presenter.UserResponse{}, err
+		return presenter.UserResponse{}, dbError(err)
 	}
 
 	for row.Next() {
-		if err := row.Scan(&updatedId); err != nil {
-			return presenter.UserResponse{}, err
+		if err := row.Scan(&updatedId, &updatedVersion); err != nil {
+			return presenter.UserResponse{}, dbError(err)
 		}
 	}
 
 	if updatedId != id {
-		return presenter.UserResponse{}, errors.New("entity not found")
+		return presenter.UserResponse{}, writeError(ctx, r.db, "_user", "user", id, version)
 	}
 
 	log.Printf("Put user with id %d", id)
@@ -172,10 +279,11 @@ func (r *UserRepo) PutUser(id int, request presenter.UserRequest) (presenter.Use
 		Id:       id,
 		Username: *request.Username,
 		Role:     *request.Role,
+		Version:  updatedVersion,
 	}, nil
 }
 
-func (r *UserRepo) PatchUser(id int, request presenter.UserRequest) (presenter.UserResponse, error) {
+func (r *UserRepo) PatchUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error) {
 	q := `UPDATE _user SET `
 	qParts := make([]string, 0, 3)
 	args := make([]interface{}, 0, 3)
@@ -196,53 +304,200 @@ func (r *UserRepo) PatchUser(id int, request presenter.UserRequest) (presenter.U
 	if request.Role != nil {
 		qParts = append(qParts, fmt.Sprintf("role_id=$%d", counter))
 		counter++
-		var role int
-
-		switch *request.Role {
-		case "ADMIN":
-			role = 1
-		case "USER":
-			role = 2
-		default:
-			return presenter.UserResponse{}, errors.New("Role " + *request.Role +
-				" does not exist. Available roles: USER, ADMIN")
+		role, err := r.getRoleId(ctx, *request.Role)
+		if err != nil {
+			return presenter.UserResponse{}, err
 		}
 		args = append(args, role)
 	}
-	q += strings.Join(qParts, ",") + ` WHERE id = $` + strconv.Itoa(counter) + "RETURNING id"
-	args = append(args, id)
+	// an empty patch still checks the version
+	if len(qParts) == 0 {
+		qParts = append(qParts, "id=id")
+	}
+	q += strings.Join(qParts, ",") + ` WHERE id = $` + strconv.Itoa(counter) +
+		` AND ($` + strconv.Itoa(counter+1) + ` = 0 OR version = $` + strconv.Itoa(counter+1) + `) RETURNING id`
+	args = append(args, id, version)
 
-	row, err := r.db.Query(q, args...)
+	row, err := r.db.QueryContext(ctx, q, args...)
 
 	if err != nil {
-		return presenter.UserResponse{}, errors.New("User with such username already exists")
+		return presenter.UserResponse{}, dbError(err)
 	}
 
 	for row.Next() {
 		if err := row.Scan(&updatedId); err != nil {
-			return presenter.UserResponse{}, err
+			return presenter.UserResponse{}, dbError(err)
 		}
 	}
 	if updatedId != id {
-		return presenter.UserResponse{}, errors.New("entity not found")
+		return presenter.UserResponse{}, writeError(ctx, r.db, "_user", "user", id, version)
 	}
 
 	log.Printf("Patch user with id %d", id)
-	return r.GetUserById(id)
+	return r.GetUserById(ctx, id)
 }
 
-func (r *UserRepo) DeleteUser(id int) error {
-	query, err := r.db.Prepare("DELETE FROM _user WHERE id = $1")
+func (r *UserRepo) DeleteUser(ctx context.Context, id, version int) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM _user WHERE id = $1 AND ($2 = 0 OR version = $2)")
 	if err != nil {
-		return err
+		return dbError(err)
 	}
 	defer query.Close()
-	_, err = query.Query(id)
+	result, err := query.ExecContext(ctx, id, version)
 
 	if err != nil {
-		return err
+		return dbError(err)
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return writeError(ctx, r.db, "_user", "user", id, version)
 	}
 	log.Printf("Delete user with id %d", id)
 
 	return nil
 }
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int, hash string) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET password = $2 WHERE id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, id, hash)
+	return dbError(err)
+}
+
+func (r *UserRepo) SetUserRole(ctx context.Context, id int, role string) error {
+	roleId, err := r.getRoleId(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET role_id = $2 WHERE id = $1")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, id, roleId)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Set role of user %d to %s", id, role)
+	return nil
+}
+
+func (r *UserRepo) VerifyEmail(ctx context.Context, id int) error {
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET email_verified_at = now() "+
+		"WHERE id = $1 AND email IS NOT NULL AND email_verified_at IS NULL")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, id)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Verify email of user with id %d", id)
+	return nil
+}
+
+func (r *UserRepo) ApproveUser(ctx context.Context, id int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET status = $2 WHERE id = $1 AND status = $3")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id, entity.UserStatusActive, entity.UserStatusPending)
+	if err != nil {
+		return false, dbError(err)
+	}
+	approved, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Approve user with id %d", id)
+	return approved == 1, nil
+}
+
+// SuspendUser sets status to suspended or banned, pending users can not be suspended
+func (r *UserRepo) SuspendUser(ctx context.Context, id int, status, reason string, until *time.Time, suspendedBy int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET status = $2, suspension_reason = $3, suspended_until = $4, "+
+		"suspended_by = NULLIF($5, 0), suspended_at = now() WHERE id = $1 AND status <> $6")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id, status, reason, until, suspendedBy, entity.UserStatusPending)
+	if err != nil {
+		return false, dbError(err)
+	}
+	suspended, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Set status of user with id %d to %s", id, status)
+	return suspended == 1, nil
+}
+
+// ReinstateUser lifts the suspension or ban of a user
+func (r *UserRepo) ReinstateUser(ctx context.Context, id int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "UPDATE _user SET status = $2, suspension_reason = NULL, suspended_until = NULL, "+
+		"suspended_by = NULL, suspended_at = NULL WHERE id = $1 AND status IN ($3, $4)")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id, entity.UserStatusActive, entity.UserStatusSuspended, entity.UserStatusBanned)
+	if err != nil {
+		return false, dbError(err)
+	}
+	reinstated, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Reinstate user with id %d", id)
+	return reinstated == 1, nil
+}
+
+func (r *UserRepo) DeletePendingUser(ctx context.Context, id int) (bool, error) {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM _user WHERE id = $1 AND status = $2")
+	if err != nil {
+		return false, dbError(err)
+	}
+	defer query.Close()
+
+	result, err := query.ExecContext(ctx, id, entity.UserStatusPending)
+	if err != nil {
+		return false, dbError(err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, dbError(err)
+	}
+
+	log.Printf("Reject registration of user with id %d", id)
+	return deleted == 1, nil
+}
+
+func (r *UserRepo) getRoleId(ctx context.Context, role string) (int, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, "SELECT id FROM role WHERE role = $1", role).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &entity.ValidationError{Message: "Role " + role + " does not exist",
+			Fields: []entity.FieldError{{Field: "role", Message: "does not exist"}}}
+	}
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return id, nil
+}
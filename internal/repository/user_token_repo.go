@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+type UserTokenRepo struct {
+	db DBTX
+}
+
+func NewUserTokenRepo(db DBTX) *UserTokenRepo {
+	return &UserTokenRepo{db: db}
+}
+
+func (r *UserTokenRepo) CreateUserToken(ctx context.Context, userId int, purpose, tokenHash string, expiresAt time.Time) error {
+	query, err := r.db.PrepareContext(ctx, "INSERT INTO user_token (user_id, purpose, token_hash, expires_at) "+
+		"VALUES ($1, $2, $3, $4)")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, userId, purpose, tokenHash, expiresAt)
+	if err != nil {
+		return dbError(err)
+	}
+
+	log.Printf("Issue %s token for user %d", purpose, userId)
+	return nil
+}
+
+func (r *UserTokenRepo) GetUserToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	var userId int
+	query, err := r.db.PrepareContext(ctx, "SELECT user_id FROM user_token "+
+		"WHERE purpose = $1 AND token_hash = $2 AND used_at IS NULL AND expires_at > now()")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, purpose, tokenHash).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.New("Invalid or expired token")
+	}
+	if err != nil {
+		return 0, dbError(err)
+	}
+	return userId, nil
+}
+
+func (r *UserTokenRepo) UseUserToken(ctx context.Context, purpose, tokenHash string) (int, error) {
+	var userId int
+	query, err := r.db.PrepareContext(ctx, "UPDATE user_token SET used_at = now() "+
+		"WHERE purpose = $1 AND token_hash = $2 AND used_at IS NULL AND expires_at > now() "+
+		"RETURNING user_id")
+	if err != nil {
+		return 0, dbError(err)
+	}
+	defer query.Close()
+
+	err = query.QueryRowContext(ctx, purpose, tokenHash).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.New("Invalid or expired token")
+	}
+	if err != nil {
+		return 0, dbError(err)
+	}
+
+	log.Printf("Use %s token of user %d", purpose, userId)
+	return userId, nil
+}
+
+func (r *UserTokenRepo) DeleteUserTokens(ctx context.Context, userId int, purpose string) error {
+	query, err := r.db.PrepareContext(ctx, "DELETE FROM user_token WHERE user_id = $1 AND purpose = $2")
+	if err != nil {
+		return dbError(err)
+	}
+	defer query.Close()
+
+	_, err = query.ExecContext(ctx, userId, purpose)
+	return dbError(err)
+}
@@ -1,37 +1,79 @@
 package service
 
 import (
+	"context"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/repository"
 )
 
 type ActorService struct {
 	repo repository.Actor
+	uow  repository.UnitOfWork
 }
 
-func NewActorService(repo repository.Actor) *ActorService {
-	return &ActorService{repo: repo}
+func NewActorService(repo repository.Actor, uow repository.UnitOfWork) *ActorService {
+	return &ActorService{repo: repo, uow: uow}
 }
 
-func (s *ActorService) GetActor(id int) (presenter.ActorResponse, error) {
-	return s.repo.GetActor(id)
+func (s *ActorService) GetActor(ctx context.Context, id int) (presenter.ActorResponse, error) {
+	return s.repo.GetActor(ctx, id)
 }
-func (s *ActorService) GetActors() ([]presenter.ActorResponse, error) {
-	return s.repo.GetActors()
+func (s *ActorService) GetActors(ctx context.Context) ([]presenter.ActorResponse, error) {
+	return s.repo.GetActors(ctx)
 }
 
-func (s *ActorService) CreateActor(request presenter.ActorRequest) (int, error) {
-	return s.repo.CreateActor(request)
+// CreateActor inserts the actor and its film links in one transaction, so an
+// unknown film id leaves no actor behind
+func (s *ActorService) CreateActor(ctx context.Context, request presenter.ActorRequest) (int, error) {
+	var id int
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		id, err = repo.Actor.CreateActor(ctx, request)
+		return err
+	})
+	return id, err
 }
 
-func (s *ActorService) PutActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
-	return s.repo.PutActor(id, request)
+func (s *ActorService) PutActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
+	var actor presenter.ActorResponse
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		actor, err = repo.Actor.PutActor(ctx, id, version, request)
+		return err
+	})
+	return actor, err
 }
 
-func (s *ActorService) PatchActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
-	return s.repo.PatchActor(id, request)
+func (s *ActorService) PatchActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
+	var actor presenter.ActorResponse
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		actor, err = repo.Actor.PatchActor(ctx, id, version, request)
+		return err
+	})
+	return actor, err
 }
 
-func (s *ActorService) DeleteActor(id int) error {
-	return s.repo.DeleteActor(id)
+func (s *ActorService) DeleteActor(ctx context.Context, id, version int) error {
+	return s.repo.DeleteActor(ctx, id, version)
+}
+
+// BatchActors creates, updates and deletes actors, see runBatch
+func (s *ActorService) BatchActors(ctx context.Context, atomic bool, operations []presenter.ActorBatchOperation) ([]entity.BatchResult, error) {
+	return runBatch(ctx, s.uow, atomic, len(operations), func(repo *repository.Repository, i int) entity.BatchResult {
+		operation := operations[i]
+		switch operation.Op {
+		case presenter.BatchCreate:
+			id, err := repo.Actor.CreateActor(ctx, operation.Actor)
+			return entity.BatchResult{Id: id, Err: err}
+		case presenter.BatchUpdate:
+			actor, err := repo.Actor.PutActor(ctx, operation.Id, operation.Version, operation.Actor)
+			return entity.BatchResult{Id: operation.Id, Version: actor.Version, Err: err}
+		case presenter.BatchDelete:
+			err := repo.Actor.DeleteActor(ctx, operation.Id, operation.Version)
+			return entity.BatchResult{Id: operation.Id, Err: err}
+		}
+		return entity.BatchResult{Err: &entity.ValidationError{Message: "unknown operation " + operation.Op}}
+	})
 }
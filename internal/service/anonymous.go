@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/internal/repository"
+	"filmLibraryVk/pkg"
+	"strings"
+	"sync"
+	"time"
+)
+
+// anonymousRoleTTL bounds how long changes to the permissions of the anonymous
+// role take to apply, requests in between do not query the database
+const anonymousRoleTTL = time.Minute
+
+// AnonymousService authenticates requests without credentials as the anonymous role
+type AnonymousService struct {
+	roleRepo repository.Role
+	role     string
+
+	mu       sync.Mutex
+	claims   *pkg.Claims
+	loadedAt time.Time
+}
+
+func NewAnonymousService(roleRepo repository.Role, role string) *AnonymousService {
+	return &AnonymousService{roleRepo: roleRepo, role: role}
+}
+
+// AuthenticateAnonymous grants only the read permissions of the anonymous
+// role, writes always need an account
+func (s *AnonymousService) AuthenticateAnonymous(ctx context.Context) (*pkg.Claims, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claims == nil || time.Since(s.loadedAt) >= anonymousRoleTTL {
+		role, err := s.roleRepo.GetRoleByName(ctx, s.role)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions := make([]string, 0, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			if strings.HasSuffix(permission, ":read") {
+				permissions = append(permissions, permission)
+			}
+		}
+		s.claims, s.loadedAt = &pkg.Claims{Role: role.Id, Permissions: permissions}, time.Now()
+	}
+
+	// a copy, as requests must not share claims
+	claims := *s.claims
+	return &claims, nil
+}
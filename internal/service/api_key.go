@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/repository"
+	"filmLibraryVk/pkg"
+	"log"
+	"time"
+)
+
+var ErrInvalidApiKey = errors.New("Invalid API key")
+
+type ApiKeyService struct {
+	repo repository.ApiKey
+}
+
+func NewApiKeyService(repo repository.ApiKey) *ApiKeyService {
+	return &ApiKeyService{repo: repo}
+}
+
+func (s *ApiKeyService) GetApiKeys(ctx context.Context) ([]presenter.ApiKeyResponse, error) {
+	return s.repo.GetApiKeys(ctx)
+}
+
+// CreateApiKey issues a key with scopes limited to the permissions of its
+// creator, so a key can not be used to mint a more powerful one
+func (s *ApiKeyService) CreateApiKey(ctx context.Context, createdBy int, grantable []string,
+	request presenter.ApiKeyRequest) (presenter.ApiKeyResponse, error) {
+	allowed := make(map[string]bool, len(grantable))
+	for _, permission := range grantable {
+		allowed[permission] = true
+	}
+	for _, scope := range request.Scopes {
+		if !allowed[scope] {
+			return presenter.ApiKeyResponse{}, &entity.ForbiddenError{Message: "Can not grant permission " + scope}
+		}
+	}
+
+	var expiresAt *time.Time
+	if request.ExpiresIn > 0 {
+		at := time.Now().Add(time.Second * time.Duration(request.ExpiresIn))
+		expiresAt = &at
+	}
+
+	key := pkg.GenerateApiKey()
+	prefix := key[:len(pkg.ApiKeyPrefix)+6]
+	apiKey, err := s.repo.CreateApiKey(ctx, createdBy, request.Name, prefix, pkg.HashToken(key), request.Scopes, expiresAt)
+	if err != nil {
+		return presenter.ApiKeyResponse{}, err
+	}
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+func (s *ApiKeyService) RevokeApiKey(ctx context.Context, id int) error {
+	revoked, err := s.repo.RevokeApiKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return &entity.NotFoundError{Entity: "api key"}
+	}
+	return nil
+}
+
+func (s *ApiKeyService) AuthenticateApiKey(ctx context.Context, key string) (*pkg.Claims, error) {
+	apiKey, err := s.repo.GetApiKeyByHash(ctx, pkg.HashToken(key))
+	if err != nil {
+		return nil, ErrInvalidApiKey
+	}
+	if apiKey.RevokedAt != nil {
+		log.Printf("Use of revoked api key %d", apiKey.Id)
+		return nil, ErrInvalidApiKey
+	}
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now()) {
+		log.Printf("Use of expired api key %d", apiKey.Id)
+		return nil, ErrInvalidApiKey
+	}
+
+	if err := s.repo.TouchApiKey(ctx, apiKey.Id); err != nil {
+		log.Printf("Can not record use of api key %d: %s", apiKey.Id, err.Error())
+	}
+	return &pkg.Claims{ApiKeyId: apiKey.Id, Permissions: apiKey.Permissions}, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/repository"
+)
+
+// runBatch applies n operations with apply. Atomic batches share one
+// transaction that is rolled back when an operation fails, that error is
+// returned. Otherwise every operation has its own transaction and fails alone
+func runBatch(ctx context.Context, uow repository.UnitOfWork, atomic bool, n int,
+	apply func(repo *repository.Repository, i int) entity.BatchResult) ([]entity.BatchResult, error) {
+	results := make([]entity.BatchResult, n)
+	if atomic {
+		err := uow.Do(ctx, func(repo *repository.Repository) error {
+			for i := range results {
+				results[i] = apply(repo, i)
+				if results[i].Err != nil {
+					return results[i].Err
+				}
+			}
+			return nil
+		})
+		return results, err
+	}
+
+	for i := range results {
+		err := uow.Do(ctx, func(repo *repository.Repository) error {
+			results[i] = apply(repo, i)
+			return results[i].Err
+		})
+		// the commit may fail as well
+		if results[i].Err == nil {
+			results[i].Err = err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxPasswordLength bounds the work of hashing a password sent by a client
+const MaxPasswordLength = 128
+
+const (
+	RegistrationOpen     = "open"
+	RegistrationInvite   = "invite"
+	RegistrationClosed   = "closed"
+	RegistrationApproval = "approval"
+)
+
+type Config struct {
+	Registration  RegistrationConfig
+	Login         LoginConfig
+	Account       AccountConfig
+	TwoFactor     TwoFactorConfig
+	Password      PasswordConfig
+	OIDC          OIDCConfig
+	Impersonation ImpersonationConfig
+	Anonymous     AnonymousConfig
+	Idempotency   IdempotencyConfig
+}
+
+type RegistrationConfig struct {
+	Mode        string
+	DefaultRole string
+	InviteTTL   time.Duration
+	VerifyEmail bool
+}
+
+func (c RegistrationConfig) Validate() error {
+	switch c.Mode {
+	case RegistrationOpen, RegistrationInvite, RegistrationClosed, RegistrationApproval:
+	default:
+		return errors.New("unknown registration mode " + c.Mode)
+	}
+	if c.DefaultRole == "" {
+		return errors.New("registration default role is not set")
+	}
+	if c.InviteTTL <= 0 {
+		return errors.New("invite ttl must be positive")
+	}
+	return nil
+}
+
+// LoginConfig limits failed logins. Every failure delays the next attempt by
+// BaseDelay doubled per previous failure, up to MaxDelay. After MaxFailures
+// failures for a username (IpMaxFailures for an IP) logins are locked for
+// Lockout. Counters are forgotten ResetAfter the last failure
+type LoginConfig struct {
+	MaxFailures   int
+	IpMaxFailures int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Lockout       time.Duration
+	ResetAfter    time.Duration
+}
+
+func (c LoginConfig) Validate() error {
+	if c.MaxFailures <= 0 || c.IpMaxFailures <= 0 {
+		return errors.New("login max failures must be positive")
+	}
+	if c.BaseDelay < 0 || c.MaxDelay < c.BaseDelay {
+		return errors.New("login max delay must not be less than base delay")
+	}
+	if c.Lockout <= 0 || c.ResetAfter <= 0 {
+		return errors.New("login lockout and reset period must be positive")
+	}
+	return nil
+}
+
+type AccountConfig struct {
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+}
+
+func (c AccountConfig) Validate() error {
+	if c.PasswordResetTTL <= 0 || c.EmailVerificationTTL <= 0 {
+		return errors.New("password reset and email verification ttl must be positive")
+	}
+	return nil
+}
+
+// TwoFactorConfig configures TOTP. Codes of Skew periods before and after the
+// current one are accepted to tolerate clock drift. With RequireForAdmin set
+// ADMIN users have to enroll before they get tokens
+type TwoFactorConfig struct {
+	Issuer          string
+	RequireForAdmin bool
+	ChallengeTTL    time.Duration
+	Skew            int
+}
+
+func (c TwoFactorConfig) Validate() error {
+	if c.Issuer == "" {
+		return errors.New("two factor issuer is not set")
+	}
+	if c.ChallengeTTL <= 0 {
+		return errors.New("two factor challenge ttl must be positive")
+	}
+	if c.Skew < 0 {
+		return errors.New("two factor skew must not be negative")
+	}
+	return nil
+}
+
+// PasswordConfig is the policy for passwords chosen by users. Passwords found
+// in Breached (lowercased) are rejected
+type PasswordConfig struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	Breached      map[string]bool
+}
+
+func (c PasswordConfig) Validate() error {
+	if c.MinLength < 1 {
+		return errors.New("password min length must be positive")
+	}
+	if c.MaxLength < c.MinLength || c.MaxLength > MaxPasswordLength {
+		return fmt.Errorf("password max length must be in [min length, %d]", MaxPasswordLength)
+	}
+	return nil
+}
+
+// OIDCConfig configures single sign-on. Users are created on their first
+// login. Their role is the role of the first RoleMapping entry whose group
+// they are in, DefaultRole otherwise, and is synced on every login
+type OIDCConfig struct {
+	Enabled     bool
+	DefaultRole string
+	LoginTTL    time.Duration
+	RoleMapping []OIDCRoleMapping
+}
+
+type OIDCRoleMapping struct {
+	Group string `mapstructure:"group"`
+	Role  string `mapstructure:"role"`
+}
+
+func (c OIDCConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.DefaultRole == "" {
+		return errors.New("oidc default role is not set")
+	}
+	if c.LoginTTL <= 0 {
+		return errors.New("oidc login ttl must be positive")
+	}
+	for _, mapping := range c.RoleMapping {
+		if mapping.Group == "" || mapping.Role == "" {
+			return errors.New("oidc role mapping needs a group and a role")
+		}
+	}
+	return nil
+}
+
+type ImpersonationConfig struct {
+	TTL time.Duration
+}
+
+func (c ImpersonationConfig) Validate() error {
+	if c.TTL <= 0 {
+		return errors.New("impersonation ttl must be positive")
+	}
+	return nil
+}
+
+// AnonymousConfig allows reading the catalog without an account. Requests
+// without credentials get the read permissions of Role, at most RateLimit
+// per client IP in each RatePeriod
+type AnonymousConfig struct {
+	Enabled    bool
+	Role       string
+	RateLimit  int
+	RatePeriod time.Duration
+}
+
+func (c AnonymousConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Role == "" {
+		return errors.New("anonymous role is not set")
+	}
+	if c.RateLimit <= 0 || c.RatePeriod <= 0 {
+		return errors.New("anonymous rate limit and period must be positive")
+	}
+	return nil
+}
+
+// IdempotencyConfig keeps responses to requests made with an Idempotency-Key
+// for TTL, retries within it get the same response
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+func (c IdempotencyConfig) Validate() error {
+	if c.TTL <= 0 {
+		return errors.New("idempotency ttl must be positive")
+	}
+	return nil
+}
@@ -1,7 +1,7 @@
 package service
 
 import (
-	"errors"
+	"context"
 	"filmLibraryVk/api/REST/presenter"
 	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/repository"
@@ -12,47 +12,87 @@ import (
 
 type FilmService struct {
 	repo repository.Film
+	uow  repository.UnitOfWork
 }
 
-func NewFilmService(repo repository.Film) *FilmService {
-	return &FilmService{repo: repo}
+func NewFilmService(repo repository.Film, uow repository.UnitOfWork) *FilmService {
+	return &FilmService{repo: repo, uow: uow}
 }
 
-func (s *FilmService) GetFilm(id int) (presenter.FilmResponse, error) {
-	return s.repo.GetFilm(id)
+func (s *FilmService) GetFilm(ctx context.Context, id int) (presenter.FilmResponse, error) {
+	return s.repo.GetFilm(ctx, id)
 }
-func (s *FilmService) GetFilms(sortBy string) ([]presenter.FilmResponse, error) {
+func (s *FilmService) GetFilms(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error) {
 	sortQuery, err := validateAndReturnSortQuery(sortBy)
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.GetFilms(sortQuery)
+	return s.repo.GetFilms(ctx, sortQuery)
 }
 
-func (s *FilmService) CreateFilm(request presenter.FilmRequest) (int, error) {
-	return s.repo.CreateFilm(request)
+// CreateFilm inserts the film and its actor links in one transaction, so an
+// unknown actor id leaves no film behind
+func (s *FilmService) CreateFilm(ctx context.Context, request presenter.FilmRequest) (int, error) {
+	var id int
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		id, err = repo.Film.CreateFilm(ctx, request)
+		return err
+	})
+	return id, err
 }
 
-func (s *FilmService) PutFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
-	return s.repo.PutFilm(id, request)
+func (s *FilmService) PutFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
+	var film presenter.FilmResponse
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		film, err = repo.Film.PutFilm(ctx, id, version, request)
+		return err
+	})
+	return film, err
 }
 
-func (s *FilmService) PatchFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
-	return s.repo.PatchFilm(id, request)
+func (s *FilmService) PatchFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
+	var film presenter.FilmResponse
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		film, err = repo.Film.PatchFilm(ctx, id, version, request)
+		return err
+	})
+	return film, err
 }
 
-func (s *FilmService) DeleteFilm(id int) error {
-	return s.repo.DeleteFilm(id)
+func (s *FilmService) DeleteFilm(ctx context.Context, id, version int) error {
+	return s.repo.DeleteFilm(ctx, id, version)
 }
 
-func (s *FilmService) SearchFilmsBy(field, value string) ([]presenter.FilmResponse, error) {
+// BatchFilms creates, updates and deletes films, see runBatch
+func (s *FilmService) BatchFilms(ctx context.Context, atomic bool, operations []presenter.FilmBatchOperation) ([]entity.BatchResult, error) {
+	return runBatch(ctx, s.uow, atomic, len(operations), func(repo *repository.Repository, i int) entity.BatchResult {
+		operation := operations[i]
+		switch operation.Op {
+		case presenter.BatchCreate:
+			id, err := repo.Film.CreateFilm(ctx, operation.Film)
+			return entity.BatchResult{Id: id, Err: err}
+		case presenter.BatchUpdate:
+			film, err := repo.Film.PutFilm(ctx, operation.Id, operation.Version, operation.Film)
+			return entity.BatchResult{Id: operation.Id, Version: film.Version, Err: err}
+		case presenter.BatchDelete:
+			err := repo.Film.DeleteFilm(ctx, operation.Id, operation.Version)
+			return entity.BatchResult{Id: operation.Id, Err: err}
+		}
+		return entity.BatchResult{Err: &entity.ValidationError{Message: "unknown operation " + operation.Op}}
+	})
+}
+
+func (s *FilmService) SearchFilmsBy(ctx context.Context, field, value string) ([]presenter.FilmResponse, error) {
 	switch field {
 	case "name":
-		return s.repo.SearchFilmsByName(value)
+		return s.repo.SearchFilmsByName(ctx, value)
 	case "actor":
-		return s.repo.SearchFilmsByActor(value)
+		return s.repo.SearchFilmsByActor(ctx, value)
 	default:
-		return nil, errors.New("can not search by " + field)
+		return nil, &entity.ValidationError{Message: "can not search by " + field}
 	}
 }
 
@@ -83,14 +123,14 @@ func validateAndReturnSortQuery(sortBy string) (string, error) {
 
 	splits := strings.Split(sortBy, ".")
 	if len(splits) != 2 {
-		return "", errors.New("malformed sortBy query parameter, should be field.orderdirection")
+		return "", &entity.ValidationError{Message: "malformed sortBy query parameter, should be field.orderdirection"}
 	}
 	field, order := splits[0], splits[1]
 	if order != "desc" && order != "asc" {
-		return "", errors.New("malformed orderdirection in sortBy query parameter, should be asc or desc")
+		return "", &entity.ValidationError{Message: "malformed orderdirection in sortBy query parameter, should be asc or desc"}
 	}
 	if !stringInSlice(filmFields, field) {
-		return "", errors.New("unknown field in sortBy query parameter")
+		return "", &entity.ValidationError{Message: "unknown field in sortBy query parameter"}
 	}
 
 	if strings.ToLower(field) == "releasedate" {
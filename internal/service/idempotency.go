@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/repository"
+	"time"
+)
+
+// idempotencyLockTTL bounds how long a key is held by a request that never
+// completes, as when the server stops. Requests are cancelled long before
+const idempotencyLockTTL = time.Minute
+
+type IdempotencyService struct {
+	repo repository.Idempotency
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo repository.Idempotency, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// BeginIdempotent reserves key of owner for a request with fingerprint and
+// returns nil if the request has to be processed, or the completed request
+// whose response is to be replayed
+func (s *IdempotencyService) BeginIdempotent(ctx context.Context, owner, key, fingerprint string) (*entity.IdempotentRequest, error) {
+	stored, reserved, err := s.repo.ReserveIdempotencyKey(ctx, owner, key, fingerprint, time.Now().Add(idempotencyLockTTL))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+	if stored.Fingerprint != fingerprint {
+		return nil, &entity.ValidationError{Message: "Idempotency-Key was already used for a different request"}
+	}
+	if stored.Status == 0 {
+		return nil, &entity.ConflictError{Message: "A request with this Idempotency-Key is still being processed"}
+	}
+	return &stored, nil
+}
+
+// CompleteIdempotent keeps the response to replay to retries with key
+func (s *IdempotencyService) CompleteIdempotent(ctx context.Context, owner, key string, response entity.IdempotentRequest) error {
+	return s.repo.CompleteIdempotencyKey(ctx, owner, key, response, time.Now().Add(s.ttl))
+}
+
+// ReleaseIdempotent frees key of a request that failed, so a retry runs it again
+func (s *IdempotencyService) ReleaseIdempotent(ctx context.Context, owner, key string) error {
+	return s.repo.ReleaseIdempotencyKey(ctx, owner, key)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/internal/repository"
+	"filmLibraryVk/pkg"
+	"log"
+	"time"
+)
+
+var ErrImpersonationForbidden = &entity.ForbiddenError{Message: "Can not impersonate a user with permissions you do not have"}
+
+type ImpersonationService struct {
+	repo     repository.Impersonation
+	userRepo repository.User
+	roleRepo repository.Role
+	ttl      time.Duration
+}
+
+func NewImpersonationService(repo repository.Impersonation, userRepo repository.User, roleRepo repository.Role,
+	ttl time.Duration) *ImpersonationService {
+	return &ImpersonationService{repo: repo, userRepo: userRepo, roleRepo: roleRepo, ttl: ttl}
+}
+
+// Impersonate issues a short-lived token acting as the subject. The subject
+// may not have permissions the actor lacks, so impersonation can not be used
+// to gain them
+func (s *ImpersonationService) Impersonate(ctx context.Context, actorId, actorSessionId int, grantable []string, subjectId int,
+	request presenter.ImpersonationRequest) (presenter.ImpersonationResponse, error) {
+	if subjectId == actorId {
+		return presenter.ImpersonationResponse{}, &entity.ForbiddenError{Message: "Can not impersonate yourself"}
+	}
+
+	subject, err := s.userRepo.GetUserCredentials(ctx, subjectId)
+	if err != nil {
+		return presenter.ImpersonationResponse{}, err
+	}
+	if subject.Status == entity.UserStatusPending {
+		return presenter.ImpersonationResponse{}, ErrRegistrationPending
+	}
+	if err := suspensionError(subject); err != nil {
+		return presenter.ImpersonationResponse{}, err
+	}
+
+	permissions, err := s.roleRepo.GetRolePermissions(ctx, subject.RoleId)
+	if err != nil {
+		return presenter.ImpersonationResponse{}, err
+	}
+	allowed := make(map[string]bool, len(grantable))
+	for _, permission := range grantable {
+		allowed[permission] = true
+	}
+	for _, permission := range permissions {
+		if !allowed[permission] {
+			log.Printf("User %d can not impersonate user %d without %s", actorId, subjectId, permission)
+			return presenter.ImpersonationResponse{}, ErrImpersonationForbidden
+		}
+	}
+
+	id, err := s.repo.CreateImpersonation(ctx, actorId, subjectId, request.Reason, time.Now().Add(s.ttl))
+	if err != nil {
+		return presenter.ImpersonationResponse{}, err
+	}
+	token, err := pkg.GenerateImpersonationJWT(subject, actorId, actorSessionId, permissions, s.ttl)
+	if err != nil {
+		return presenter.ImpersonationResponse{}, err
+	}
+
+	log.Printf("User %d impersonates user %d: %s", actorId, subjectId, request.Reason)
+	return presenter.ImpersonationResponse{
+		Id:          id,
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.ttl.Seconds()),
+	}, nil
+}
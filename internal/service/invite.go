@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/repository"
+	"filmLibraryVk/pkg"
+	"time"
+)
+
+type InviteService struct {
+	repo repository.Invite
+	ttl  time.Duration
+}
+
+func NewInviteService(repo repository.Invite, ttl time.Duration) *InviteService {
+	return &InviteService{repo: repo, ttl: ttl}
+}
+
+func (s *InviteService) GetInvites(ctx context.Context) ([]presenter.InviteResponse, error) {
+	return s.repo.GetInvites(ctx)
+}
+
+func (s *InviteService) CreateInvite(ctx context.Context, createdBy int, request presenter.InviteRequest) (presenter.InviteResponse, error) {
+	ttl := s.ttl
+	if request.ExpiresIn > 0 {
+		ttl = time.Second * time.Duration(request.ExpiresIn)
+	}
+
+	code := pkg.GenerateInviteCode()
+	invite, err := s.repo.CreateInvite(ctx, createdBy, pkg.HashToken(code), time.Now().Add(ttl))
+	if err != nil {
+		return presenter.InviteResponse{}, err
+	}
+	invite.Code = code
+	return invite, nil
+}
+
+func (s *InviteService) DeleteInvite(ctx context.Context, id int) error {
+	return s.repo.DeleteInvite(ctx, id)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"time"
+)
+
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "Too many failed login attempts, try again later"
+}
+
+func (s *UserService) checkLoginThrottle(ctx context.Context, login presenter.Login, client presenter.Client) error {
+	now := time.Now()
+	var retryAt time.Time
+
+	for _, key := range loginFailureKeys(login, client) {
+		failure, err := s.failureRepo.GetLoginFailure(ctx, key.Kind, key.Subject)
+		if err != nil {
+			return err
+		}
+		if at := s.nextLoginAttempt(failure, now); at.After(retryAt) {
+			retryAt = at
+		}
+	}
+
+	if retryAt.After(now) {
+		log.Printf("Login of %s from %s is throttled until %s", login.Username, client.Ip, retryAt.Format(time.RFC3339))
+		return &LoginThrottledError{RetryAfter: retryAt.Sub(now)}
+	}
+	return nil
+}
+
+func (s *UserService) recordLoginFailure(ctx context.Context, login presenter.Login, client presenter.Client) error {
+	for _, key := range loginFailureKeys(login, client) {
+		failures, err := s.failureRepo.RecordLoginFailure(ctx, key.Kind, key.Subject, s.config.Login.ResetAfter)
+		if err != nil {
+			return err
+		}
+
+		maxFailures := s.config.Login.MaxFailures
+		if key.Kind == entity.LoginFailureIp {
+			maxFailures = s.config.Login.IpMaxFailures
+		}
+		if failures >= maxFailures {
+			err := s.failureRepo.LockLogin(ctx, key.Kind, key.Subject, time.Now().Add(s.config.Login.Lockout))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nextLoginAttempt returns the earliest time a login may be attempted again
+func (s *UserService) nextLoginAttempt(failure entity.LoginFailure, now time.Time) time.Time {
+	var at time.Time
+	if failure.LockedUntil != nil {
+		at = *failure.LockedUntil
+	}
+	if failure.Failures == 0 || now.Sub(failure.LastFailureAt) > s.config.Login.ResetAfter {
+		return at
+	}
+
+	delay := s.config.Login.BaseDelay
+	for i := 1; i < failure.Failures && delay < s.config.Login.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.Login.MaxDelay {
+		delay = s.config.Login.MaxDelay
+	}
+
+	if backoff := failure.LastFailureAt.Add(delay); backoff.After(at) {
+		at = backoff
+	}
+	return at
+}
+
+func loginFailureKeys(login presenter.Login, client presenter.Client) []entity.LoginFailure {
+	keys := []entity.LoginFailure{{Kind: entity.LoginFailureUsername, Subject: login.Username}}
+	if client.Ip != "" {
+		keys = append(keys, entity.LoginFailure{Kind: entity.LoginFailureIp, Subject: client.Ip})
+	}
+	return keys
+}
+
+// reauthenticate runs check, a proof of identity by a signed in user, under
+// the login throttle of the user. A failed check counts as a failed login and
+// is answered with failure. A passed check does not reset the counter, so one
+// known factor can not be used to reset the guesses of another
+func (s *UserService) reauthenticate(ctx context.Context, userId int, client presenter.Client,
+	check func(user entity.User) (bool, error), failure error) error {
+	user, err := s.repo.GetUserCredentials(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	login := presenter.Login{Username: user.Username}
+	if err := s.checkLoginThrottle(ctx, login, client); err != nil {
+		return err
+	}
+
+	valid, err := check(user)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		if err := s.recordLoginFailure(ctx, login, client); err != nil {
+			return err
+		}
+		return failure
+	}
+	return nil
+}
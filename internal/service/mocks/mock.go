@@ -5,8 +5,12 @@
 package mock_service
 
 import (
+	context "context"
 	presenter "filmLibraryVk/api/REST/presenter"
+	entity "filmLibraryVk/internal/model/entity"
+	pkg "filmLibraryVk/pkg"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,93 +38,108 @@ func (m *MockActor) EXPECT() *MockActorMockRecorder {
 	return m.recorder
 }
 
+// BatchActors mocks base method.
+func (m *MockActor) BatchActors(ctx context.Context, atomic bool, operations []presenter.ActorBatchOperation) ([]entity.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchActors", ctx, atomic, operations)
+	ret0, _ := ret[0].([]entity.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchActors indicates an expected call of BatchActors.
+func (mr *MockActorMockRecorder) BatchActors(ctx, atomic, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchActors", reflect.TypeOf((*MockActor)(nil).BatchActors), ctx, atomic, operations)
+}
+
 // CreateActor mocks base method.
-func (m *MockActor) CreateActor(request presenter.ActorRequest) (int, error) {
+func (m *MockActor) CreateActor(ctx context.Context, request presenter.ActorRequest) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateActor", request)
+	ret := m.ctrl.Call(m, "CreateActor", ctx, request)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateActor indicates an expected call of CreateActor.
-func (mr *MockActorMockRecorder) CreateActor(request interface{}) *gomock.Call {
+func (mr *MockActorMockRecorder) CreateActor(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateActor", reflect.TypeOf((*MockActor)(nil).CreateActor), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateActor", reflect.TypeOf((*MockActor)(nil).CreateActor), ctx, request)
 }
 
 // DeleteActor mocks base method.
-func (m *MockActor) DeleteActor(id int) error {
+func (m *MockActor) DeleteActor(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteActor", id)
+	ret := m.ctrl.Call(m, "DeleteActor", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteActor indicates an expected call of DeleteActor.
-func (mr *MockActorMockRecorder) DeleteActor(id interface{}) *gomock.Call {
+func (mr *MockActorMockRecorder) DeleteActor(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteActor", reflect.TypeOf((*MockActor)(nil).DeleteActor), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteActor", reflect.TypeOf((*MockActor)(nil).DeleteActor), ctx, id, version)
 }
 
 // GetActor mocks base method.
-func (m *MockActor) GetActor(id int) (presenter.ActorResponse, error) {
+func (m *MockActor) GetActor(ctx context.Context, id int) (presenter.ActorResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetActor", id)
+	ret := m.ctrl.Call(m, "GetActor", ctx, id)
 	ret0, _ := ret[0].(presenter.ActorResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetActor indicates an expected call of GetActor.
-func (mr *MockActorMockRecorder) GetActor(id interface{}) *gomock.Call {
+func (mr *MockActorMockRecorder) GetActor(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActor", reflect.TypeOf((*MockActor)(nil).GetActor), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActor", reflect.TypeOf((*MockActor)(nil).GetActor), ctx, id)
 }
 
 // GetActors mocks base method.
-func (m *MockActor) GetActors() ([]presenter.ActorResponse, error) {
+func (m *MockActor) GetActors(ctx context.Context) ([]presenter.ActorResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetActors")
+	ret := m.ctrl.Call(m, "GetActors", ctx)
 	ret0, _ := ret[0].([]presenter.ActorResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetActors indicates an expected call of GetActors.
-func (mr *MockActorMockRecorder) GetActors() *gomock.Call {
+func (mr *MockActorMockRecorder) GetActors(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActors", reflect.TypeOf((*MockActor)(nil).GetActors))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActors", reflect.TypeOf((*MockActor)(nil).GetActors), ctx)
 }
 
 // PatchActor mocks base method.
-func (m *MockActor) PatchActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
+func (m *MockActor) PatchActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchActor", id, request)
+	ret := m.ctrl.Call(m, "PatchActor", ctx, id, version, request)
 	ret0, _ := ret[0].(presenter.ActorResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchActor indicates an expected call of PatchActor.
-func (mr *MockActorMockRecorder) PatchActor(id, request interface{}) *gomock.Call {
+func (mr *MockActorMockRecorder) PatchActor(ctx, id, version, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchActor", reflect.TypeOf((*MockActor)(nil).PatchActor), id, request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchActor", reflect.TypeOf((*MockActor)(nil).PatchActor), ctx, id, version, request)
 }
 
 // PutActor mocks base method.
-func (m *MockActor) PutActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
+func (m *MockActor) PutActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PutActor", id, request)
+	ret := m.ctrl.Call(m, "PutActor", ctx, id, version, request)
 	ret0, _ := ret[0].(presenter.ActorResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PutActor indicates an expected call of PutActor.
-func (mr *MockActorMockRecorder) PutActor(id, request interface{}) *gomock.Call {
+func (mr *MockActorMockRecorder) PutActor(ctx, id, version, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutActor", reflect.TypeOf((*MockActor)(nil).PutActor), id, request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutActor", reflect.TypeOf((*MockActor)(nil).PutActor), ctx, id, version, request)
 }
 
 // MockFilm is a mock of Film interface.
@@ -146,108 +165,123 @@ func (m *MockFilm) EXPECT() *MockFilmMockRecorder {
 	return m.recorder
 }
 
+// BatchFilms mocks base method.
+func (m *MockFilm) BatchFilms(ctx context.Context, atomic bool, operations []presenter.FilmBatchOperation) ([]entity.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchFilms", ctx, atomic, operations)
+	ret0, _ := ret[0].([]entity.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchFilms indicates an expected call of BatchFilms.
+func (mr *MockFilmMockRecorder) BatchFilms(ctx, atomic, operations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchFilms", reflect.TypeOf((*MockFilm)(nil).BatchFilms), ctx, atomic, operations)
+}
+
 // CreateFilm mocks base method.
-func (m *MockFilm) CreateFilm(request presenter.FilmRequest) (int, error) {
+func (m *MockFilm) CreateFilm(ctx context.Context, request presenter.FilmRequest) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFilm", request)
+	ret := m.ctrl.Call(m, "CreateFilm", ctx, request)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateFilm indicates an expected call of CreateFilm.
-func (mr *MockFilmMockRecorder) CreateFilm(request interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) CreateFilm(ctx, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFilm", reflect.TypeOf((*MockFilm)(nil).CreateFilm), request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFilm", reflect.TypeOf((*MockFilm)(nil).CreateFilm), ctx, request)
 }
 
 // DeleteFilm mocks base method.
-func (m *MockFilm) DeleteFilm(id int) error {
+func (m *MockFilm) DeleteFilm(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteFilm", id)
+	ret := m.ctrl.Call(m, "DeleteFilm", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteFilm indicates an expected call of DeleteFilm.
-func (mr *MockFilmMockRecorder) DeleteFilm(id interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) DeleteFilm(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFilm", reflect.TypeOf((*MockFilm)(nil).DeleteFilm), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFilm", reflect.TypeOf((*MockFilm)(nil).DeleteFilm), ctx, id, version)
 }
 
 // GetFilm mocks base method.
-func (m *MockFilm) GetFilm(id int) (presenter.FilmResponse, error) {
+func (m *MockFilm) GetFilm(ctx context.Context, id int) (presenter.FilmResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFilm", id)
+	ret := m.ctrl.Call(m, "GetFilm", ctx, id)
 	ret0, _ := ret[0].(presenter.FilmResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFilm indicates an expected call of GetFilm.
-func (mr *MockFilmMockRecorder) GetFilm(id interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) GetFilm(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilm", reflect.TypeOf((*MockFilm)(nil).GetFilm), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilm", reflect.TypeOf((*MockFilm)(nil).GetFilm), ctx, id)
 }
 
 // GetFilms mocks base method.
-func (m *MockFilm) GetFilms(sortBy string) ([]presenter.FilmResponse, error) {
+func (m *MockFilm) GetFilms(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFilms", sortBy)
+	ret := m.ctrl.Call(m, "GetFilms", ctx, sortBy)
 	ret0, _ := ret[0].([]presenter.FilmResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFilms indicates an expected call of GetFilms.
-func (mr *MockFilmMockRecorder) GetFilms(sortBy interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) GetFilms(ctx, sortBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilms", reflect.TypeOf((*MockFilm)(nil).GetFilms), sortBy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilms", reflect.TypeOf((*MockFilm)(nil).GetFilms), ctx, sortBy)
 }
 
 // PatchFilm mocks base method.
-func (m *MockFilm) PatchFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
+func (m *MockFilm) PatchFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchFilm", id, request)
+	ret := m.ctrl.Call(m, "PatchFilm", ctx, id, version, request)
 	ret0, _ := ret[0].(presenter.FilmResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchFilm indicates an expected call of PatchFilm.
-func (mr *MockFilmMockRecorder) PatchFilm(id, request interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) PatchFilm(ctx, id, version, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchFilm", reflect.TypeOf((*MockFilm)(nil).PatchFilm), id, request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchFilm", reflect.TypeOf((*MockFilm)(nil).PatchFilm), ctx, id, version, request)
 }
 
 // PutFilm mocks base method.
-func (m *MockFilm) PutFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
+func (m *MockFilm) PutFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PutFilm", id, request)
+	ret := m.ctrl.Call(m, "PutFilm", ctx, id, version, request)
 	ret0, _ := ret[0].(presenter.FilmResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PutFilm indicates an expected call of PutFilm.
-func (mr *MockFilmMockRecorder) PutFilm(id, request interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) PutFilm(ctx, id, version, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutFilm", reflect.TypeOf((*MockFilm)(nil).PutFilm), id, request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutFilm", reflect.TypeOf((*MockFilm)(nil).PutFilm), ctx, id, version, request)
 }
 
 // SearchFilmsBy mocks base method.
-func (m *MockFilm) SearchFilmsBy(field, value string) ([]presenter.FilmResponse, error) {
+func (m *MockFilm) SearchFilmsBy(ctx context.Context, field, value string) ([]presenter.FilmResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchFilmsBy", field, value)
+	ret := m.ctrl.Call(m, "SearchFilmsBy", ctx, field, value)
 	ret0, _ := ret[0].([]presenter.FilmResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchFilmsBy indicates an expected call of SearchFilmsBy.
-func (mr *MockFilmMockRecorder) SearchFilmsBy(field, value interface{}) *gomock.Call {
+func (mr *MockFilmMockRecorder) SearchFilmsBy(ctx, field, value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchFilmsBy", reflect.TypeOf((*MockFilm)(nil).SearchFilmsBy), field, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchFilmsBy", reflect.TypeOf((*MockFilm)(nil).SearchFilmsBy), ctx, field, value)
 }
 
 // MockUser is a mock of User interface.
@@ -273,106 +307,901 @@ func (m *MockUser) EXPECT() *MockUserMockRecorder {
 	return m.recorder
 }
 
+// ApproveUser mocks base method.
+func (m *MockUser) ApproveUser(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveUser indicates an expected call of ApproveUser.
+func (mr *MockUserMockRecorder) ApproveUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveUser", reflect.TypeOf((*MockUser)(nil).ApproveUser), ctx, id)
+}
+
+// ChangePassword mocks base method.
+func (m *MockUser) ChangePassword(ctx context.Context, id int, request presenter.PasswordChange, client presenter.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, id, request, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserMockRecorder) ChangePassword(ctx, id, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUser)(nil).ChangePassword), ctx, id, request, client)
+}
+
+// ChangeUsername mocks base method.
+func (m *MockUser) ChangeUsername(ctx context.Context, id int, request presenter.UsernameChange, client presenter.Client) (presenter.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUsername", ctx, id, request, client)
+	ret0, _ := ret[0].(presenter.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeUsername indicates an expected call of ChangeUsername.
+func (mr *MockUserMockRecorder) ChangeUsername(ctx, id, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUsername", reflect.TypeOf((*MockUser)(nil).ChangeUsername), ctx, id, request, client)
+}
+
+// ConfirmTwoFactor mocks base method.
+func (m *MockUser) ConfirmTwoFactor(ctx context.Context, userId int, request presenter.TwoFactorCode, client presenter.Client) (presenter.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTwoFactor", ctx, userId, request, client)
+	ret0, _ := ret[0].(presenter.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTwoFactor indicates an expected call of ConfirmTwoFactor.
+func (mr *MockUserMockRecorder) ConfirmTwoFactor(ctx, userId, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTwoFactor", reflect.TypeOf((*MockUser)(nil).ConfirmTwoFactor), ctx, userId, request, client)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockUser) DeleteAccount(ctx context.Context, id int, request presenter.AccountDeletion, client presenter.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id, request, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockUserMockRecorder) DeleteAccount(ctx, id, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockUser)(nil).DeleteAccount), ctx, id, request, client)
+}
+
 // DeleteUser mocks base method.
-func (m *MockUser) DeleteUser(id int) error {
+func (m *MockUser) DeleteUser(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserMockRecorder) DeleteUser(id interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) DeleteUser(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUser)(nil).DeleteUser), ctx, id, version)
+}
+
+// DisableTwoFactor mocks base method.
+func (m *MockUser) DisableTwoFactor(ctx context.Context, userId int, request presenter.TwoFactorDisable, client presenter.Client) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", ctx, userId, request, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockUserMockRecorder) DisableTwoFactor(ctx, userId, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockUser)(nil).DisableTwoFactor), ctx, userId, request, client)
+}
+
+// EnrollTwoFactor mocks base method.
+func (m *MockUser) EnrollTwoFactor(ctx context.Context, userId int) (presenter.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactor", ctx, userId)
+	ret0, _ := ret[0].(presenter.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTwoFactor indicates an expected call of EnrollTwoFactor.
+func (mr *MockUserMockRecorder) EnrollTwoFactor(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactor", reflect.TypeOf((*MockUser)(nil).EnrollTwoFactor), ctx, userId)
+}
+
+// EnrollTwoFactorLogin mocks base method.
+func (m *MockUser) EnrollTwoFactorLogin(ctx context.Context, request presenter.TwoFactorEnrollmentRequest) (presenter.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactorLogin", ctx, request)
+	ret0, _ := ret[0].(presenter.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTwoFactorLogin indicates an expected call of EnrollTwoFactorLogin.
+func (mr *MockUserMockRecorder) EnrollTwoFactorLogin(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactorLogin", reflect.TypeOf((*MockUser)(nil).EnrollTwoFactorLogin), ctx, request)
+}
+
+// ForgotPassword mocks base method.
+func (m *MockUser) ForgotPassword(ctx context.Context, request presenter.ForgotPassword) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockUserMockRecorder) ForgotPassword(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockUser)(nil).ForgotPassword), ctx, request)
+}
+
+// GetPendingUsers mocks base method.
+func (m *MockUser) GetPendingUsers(ctx context.Context) ([]presenter.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingUsers", ctx)
+	ret0, _ := ret[0].([]presenter.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingUsers indicates an expected call of GetPendingUsers.
+func (mr *MockUserMockRecorder) GetPendingUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingUsers", reflect.TypeOf((*MockUser)(nil).GetPendingUsers), ctx)
+}
+
+// GetSessions mocks base method.
+func (m *MockUser) GetSessions(ctx context.Context, userId, currentSessionId int) ([]presenter.SessionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userId, currentSessionId)
+	ret0, _ := ret[0].([]presenter.SessionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockUserMockRecorder) GetSessions(ctx, userId, currentSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockUser)(nil).GetSessions), ctx, userId, currentSessionId)
+}
+
+// GetTwoFactorStatus mocks base method.
+func (m *MockUser) GetTwoFactorStatus(ctx context.Context, userId int) (presenter.TwoFactorStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactorStatus", ctx, userId)
+	ret0, _ := ret[0].(presenter.TwoFactorStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactorStatus indicates an expected call of GetTwoFactorStatus.
+func (mr *MockUserMockRecorder) GetTwoFactorStatus(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUser)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactorStatus", reflect.TypeOf((*MockUser)(nil).GetTwoFactorStatus), ctx, userId)
 }
 
 // GetUserById mocks base method.
-func (m *MockUser) GetUserById(id int) (presenter.UserResponse, error) {
+func (m *MockUser) GetUserById(ctx context.Context, id int) (presenter.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", id)
+	ret := m.ctrl.Call(m, "GetUserById", ctx, id)
 	ret0, _ := ret[0].(presenter.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockUserMockRecorder) GetUserById(id interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) GetUserById(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUser)(nil).GetUserById), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUser)(nil).GetUserById), ctx, id)
 }
 
 // GetUsers mocks base method.
-func (m *MockUser) GetUsers() ([]presenter.UserResponse, error) {
+func (m *MockUser) GetUsers(ctx context.Context, status string) ([]presenter.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers")
+	ret := m.ctrl.Call(m, "GetUsers", ctx, status)
 	ret0, _ := ret[0].([]presenter.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserMockRecorder) GetUsers() *gomock.Call {
+func (mr *MockUserMockRecorder) GetUsers(ctx, status interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUser)(nil).GetUsers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUser)(nil).GetUsers), ctx, status)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockUser) IsTokenRevoked(ctx context.Context, jti string, userId, sessionId int, issuedAt time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, jti, userId, sessionId, issuedAt)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockUserMockRecorder) IsTokenRevoked(ctx, jti, userId, sessionId, issuedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockUser)(nil).IsTokenRevoked), ctx, jti, userId, sessionId, issuedAt)
 }
 
 // Login mocks base method.
-func (m *MockUser) Login(login presenter.Login) (string, error) {
+func (m *MockUser) Login(ctx context.Context, login presenter.Login, client presenter.Client) (presenter.TokenResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", login)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Login", ctx, login, client)
+	ret0, _ := ret[0].(presenter.TokenResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockUserMockRecorder) Login(login interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) Login(ctx, login, client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUser)(nil).Login), login)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUser)(nil).Login), ctx, login, client)
+}
+
+// LoginOIDC mocks base method.
+func (m *MockUser) LoginOIDC(ctx context.Context, request presenter.OIDCCallback, client presenter.Client) (presenter.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginOIDC", ctx, request, client)
+	ret0, _ := ret[0].(presenter.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginOIDC indicates an expected call of LoginOIDC.
+func (mr *MockUserMockRecorder) LoginOIDC(ctx, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginOIDC", reflect.TypeOf((*MockUser)(nil).LoginOIDC), ctx, request, client)
+}
+
+// LoginTwoFactor mocks base method.
+func (m *MockUser) LoginTwoFactor(ctx context.Context, request presenter.TwoFactorLogin, client presenter.Client) (presenter.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginTwoFactor", ctx, request, client)
+	ret0, _ := ret[0].(presenter.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginTwoFactor indicates an expected call of LoginTwoFactor.
+func (mr *MockUserMockRecorder) LoginTwoFactor(ctx, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginTwoFactor", reflect.TypeOf((*MockUser)(nil).LoginTwoFactor), ctx, request, client)
+}
+
+// Logout mocks base method.
+func (m *MockUser) Logout(ctx context.Context, userId, sessionId int, jti string, expiresAt time.Time, request presenter.RefreshRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, userId, sessionId, jti, expiresAt, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUserMockRecorder) Logout(ctx, userId, sessionId, jti, expiresAt, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUser)(nil).Logout), ctx, userId, sessionId, jti, expiresAt, request)
 }
 
 // PatchUser mocks base method.
-func (m *MockUser) PatchUser(id int, request presenter.UserRequest) (presenter.UserResponse, error) {
+func (m *MockUser) PatchUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchUser", id, request)
+	ret := m.ctrl.Call(m, "PatchUser", ctx, id, version, request)
 	ret0, _ := ret[0].(presenter.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchUser indicates an expected call of PatchUser.
-func (mr *MockUserMockRecorder) PatchUser(id, request interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) PatchUser(ctx, id, version, request interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockUser)(nil).PatchUser), id, request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockUser)(nil).PatchUser), ctx, id, version, request)
 }
 
 // PutUser mocks base method.
-func (m *MockUser) PutUser(id int, request presenter.UserRequest) (presenter.UserResponse, error) {
+func (m *MockUser) PutUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PutUser", id, request)
+	ret := m.ctrl.Call(m, "PutUser", ctx, id, version, request)
 	ret0, _ := ret[0].(presenter.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PutUser indicates an expected call of PutUser.
-func (mr *MockUserMockRecorder) PutUser(id, request interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) PutUser(ctx, id, version, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUser", reflect.TypeOf((*MockUser)(nil).PutUser), ctx, id, version, request)
+}
+
+// Refresh mocks base method.
+func (m *MockUser) Refresh(ctx context.Context, request presenter.RefreshRequest, client presenter.Client) (presenter.TokenResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, request, client)
+	ret0, _ := ret[0].(presenter.TokenResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockUserMockRecorder) Refresh(ctx, request, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockUser)(nil).Refresh), ctx, request, client)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockUser) RegenerateRecoveryCodes(ctx context.Context, userId int, request presenter.TwoFactorCode, client presenter.Client) (presenter.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userId, request, client)
+	ret0, _ := ret[0].(presenter.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockUserMockRecorder) RegenerateRecoveryCodes(ctx, userId, request, client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutUser", reflect.TypeOf((*MockUser)(nil).PutUser), id, request)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockUser)(nil).RegenerateRecoveryCodes), ctx, userId, request, client)
 }
 
 // Register mocks base method.
-func (m *MockUser) Register(register presenter.Register) (string, error) {
+func (m *MockUser) Register(ctx context.Context, register presenter.Register, client presenter.Client) (presenter.TokenResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Register", register)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Register", ctx, register, client)
+	ret0, _ := ret[0].(presenter.TokenResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Register indicates an expected call of Register.
-func (mr *MockUserMockRecorder) Register(register interface{}) *gomock.Call {
+func (mr *MockUserMockRecorder) Register(ctx, register, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUser)(nil).Register), ctx, register, client)
+}
+
+// ReinstateUser mocks base method.
+func (m *MockUser) ReinstateUser(ctx context.Context, id int) (presenter.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReinstateUser", ctx, id)
+	ret0, _ := ret[0].(presenter.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReinstateUser indicates an expected call of ReinstateUser.
+func (mr *MockUserMockRecorder) ReinstateUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReinstateUser", reflect.TypeOf((*MockUser)(nil).ReinstateUser), ctx, id)
+}
+
+// RejectUser mocks base method.
+func (m *MockUser) RejectUser(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectUser indicates an expected call of RejectUser.
+func (mr *MockUserMockRecorder) RejectUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectUser", reflect.TypeOf((*MockUser)(nil).RejectUser), ctx, id)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUser) ResetPassword(ctx context.Context, request presenter.ResetPassword) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserMockRecorder) ResetPassword(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUser)(nil).ResetPassword), ctx, request)
+}
+
+// ResetTwoFactor mocks base method.
+func (m *MockUser) ResetTwoFactor(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetTwoFactor", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetTwoFactor indicates an expected call of ResetTwoFactor.
+func (mr *MockUserMockRecorder) ResetTwoFactor(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetTwoFactor", reflect.TypeOf((*MockUser)(nil).ResetTwoFactor), ctx, userId)
+}
+
+// RevokeOtherSessions mocks base method.
+func (m *MockUser) RevokeOtherSessions(ctx context.Context, userId, currentSessionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOtherSessions", ctx, userId, currentSessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeOtherSessions indicates an expected call of RevokeOtherSessions.
+func (mr *MockUserMockRecorder) RevokeOtherSessions(ctx, userId, currentSessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOtherSessions", reflect.TypeOf((*MockUser)(nil).RevokeOtherSessions), ctx, userId, currentSessionId)
+}
+
+// RevokeSession mocks base method.
+func (m *MockUser) RevokeSession(ctx context.Context, userId, sessionId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockUserMockRecorder) RevokeSession(ctx, userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockUser)(nil).RevokeSession), ctx, userId, sessionId)
+}
+
+// StartOIDCLogin mocks base method.
+func (m *MockUser) StartOIDCLogin(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOIDCLogin", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartOIDCLogin indicates an expected call of StartOIDCLogin.
+func (mr *MockUserMockRecorder) StartOIDCLogin(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOIDCLogin", reflect.TypeOf((*MockUser)(nil).StartOIDCLogin), ctx)
+}
+
+// SuspendUser mocks base method.
+func (m *MockUser) SuspendUser(ctx context.Context, actorId, id int, request presenter.SuspensionRequest) (presenter.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", ctx, actorId, id, request)
+	ret0, _ := ret[0].(presenter.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockUserMockRecorder) SuspendUser(ctx, actorId, id, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockUser)(nil).SuspendUser), ctx, actorId, id, request)
+}
+
+// UnlockUser mocks base method.
+func (m *MockUser) UnlockUser(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockUserMockRecorder) UnlockUser(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockUser)(nil).UnlockUser), ctx, id)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUser) VerifyEmail(ctx context.Context, request presenter.VerifyEmail) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserMockRecorder) VerifyEmail(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUser)(nil).VerifyEmail), ctx, request)
+}
+
+// MockRole is a mock of Role interface.
+type MockRole struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleMockRecorder
+}
+
+// MockRoleMockRecorder is the mock recorder for MockRole.
+type MockRoleMockRecorder struct {
+	mock *MockRole
+}
+
+// NewMockRole creates a new mock instance.
+func NewMockRole(ctrl *gomock.Controller) *MockRole {
+	mock := &MockRole{ctrl: ctrl}
+	mock.recorder = &MockRoleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRole) EXPECT() *MockRoleMockRecorder {
+	return m.recorder
+}
+
+// CreateRole mocks base method.
+func (m *MockRole) CreateRole(ctx context.Context, request presenter.RoleRequest) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, request)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockRoleMockRecorder) CreateRole(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockRole)(nil).CreateRole), ctx, request)
+}
+
+// DeleteRole mocks base method.
+func (m *MockRole) DeleteRole(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockRoleMockRecorder) DeleteRole(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockRole)(nil).DeleteRole), ctx, id)
+}
+
+// GetRole mocks base method.
+func (m *MockRole) GetRole(ctx context.Context, id int) (presenter.RoleResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", ctx, id)
+	ret0, _ := ret[0].(presenter.RoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockRoleMockRecorder) GetRole(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRole)(nil).GetRole), ctx, id)
+}
+
+// GetRoles mocks base method.
+func (m *MockRole) GetRoles(ctx context.Context) ([]presenter.RoleResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx)
+	ret0, _ := ret[0].([]presenter.RoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockRoleMockRecorder) GetRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockRole)(nil).GetRoles), ctx)
+}
+
+// PutRole mocks base method.
+func (m *MockRole) PutRole(ctx context.Context, id int, request presenter.RoleRequest) (presenter.RoleResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRole", ctx, id, request)
+	ret0, _ := ret[0].(presenter.RoleResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRole indicates an expected call of PutRole.
+func (mr *MockRoleMockRecorder) PutRole(ctx, id, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRole", reflect.TypeOf((*MockRole)(nil).PutRole), ctx, id, request)
+}
+
+// MockInvite is a mock of Invite interface.
+type MockInvite struct {
+	ctrl     *gomock.Controller
+	recorder *MockInviteMockRecorder
+}
+
+// MockInviteMockRecorder is the mock recorder for MockInvite.
+type MockInviteMockRecorder struct {
+	mock *MockInvite
+}
+
+// NewMockInvite creates a new mock instance.
+func NewMockInvite(ctrl *gomock.Controller) *MockInvite {
+	mock := &MockInvite{ctrl: ctrl}
+	mock.recorder = &MockInviteMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvite) EXPECT() *MockInviteMockRecorder {
+	return m.recorder
+}
+
+// CreateInvite mocks base method.
+func (m *MockInvite) CreateInvite(ctx context.Context, createdBy int, request presenter.InviteRequest) (presenter.InviteResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, createdBy, request)
+	ret0, _ := ret[0].(presenter.InviteResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockInviteMockRecorder) CreateInvite(ctx, createdBy, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockInvite)(nil).CreateInvite), ctx, createdBy, request)
+}
+
+// DeleteInvite mocks base method.
+func (m *MockInvite) DeleteInvite(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInvite", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInvite indicates an expected call of DeleteInvite.
+func (mr *MockInviteMockRecorder) DeleteInvite(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInvite", reflect.TypeOf((*MockInvite)(nil).DeleteInvite), ctx, id)
+}
+
+// GetInvites mocks base method.
+func (m *MockInvite) GetInvites(ctx context.Context) ([]presenter.InviteResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvites", ctx)
+	ret0, _ := ret[0].([]presenter.InviteResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvites indicates an expected call of GetInvites.
+func (mr *MockInviteMockRecorder) GetInvites(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockInvite)(nil).GetInvites), ctx)
+}
+
+// MockApiKey is a mock of ApiKey interface.
+type MockApiKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockApiKeyMockRecorder
+}
+
+// MockApiKeyMockRecorder is the mock recorder for MockApiKey.
+type MockApiKeyMockRecorder struct {
+	mock *MockApiKey
+}
+
+// NewMockApiKey creates a new mock instance.
+func NewMockApiKey(ctrl *gomock.Controller) *MockApiKey {
+	mock := &MockApiKey{ctrl: ctrl}
+	mock.recorder = &MockApiKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockApiKey) EXPECT() *MockApiKeyMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateApiKey mocks base method.
+func (m *MockApiKey) AuthenticateApiKey(ctx context.Context, key string) (*pkg.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateApiKey", ctx, key)
+	ret0, _ := ret[0].(*pkg.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateApiKey indicates an expected call of AuthenticateApiKey.
+func (mr *MockApiKeyMockRecorder) AuthenticateApiKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateApiKey", reflect.TypeOf((*MockApiKey)(nil).AuthenticateApiKey), ctx, key)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockApiKey) CreateApiKey(ctx context.Context, createdBy int, grantable []string, request presenter.ApiKeyRequest) (presenter.ApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", ctx, createdBy, grantable, request)
+	ret0, _ := ret[0].(presenter.ApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockApiKeyMockRecorder) CreateApiKey(ctx, createdBy, grantable, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockApiKey)(nil).CreateApiKey), ctx, createdBy, grantable, request)
+}
+
+// GetApiKeys mocks base method.
+func (m *MockApiKey) GetApiKeys(ctx context.Context) ([]presenter.ApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApiKeys", ctx)
+	ret0, _ := ret[0].([]presenter.ApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApiKeys indicates an expected call of GetApiKeys.
+func (mr *MockApiKeyMockRecorder) GetApiKeys(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApiKeys", reflect.TypeOf((*MockApiKey)(nil).GetApiKeys), ctx)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockApiKey) RevokeApiKey(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockApiKeyMockRecorder) RevokeApiKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockApiKey)(nil).RevokeApiKey), ctx, id)
+}
+
+// MockImpersonation is a mock of Impersonation interface.
+type MockImpersonation struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationMockRecorder
+}
+
+// MockImpersonationMockRecorder is the mock recorder for MockImpersonation.
+type MockImpersonationMockRecorder struct {
+	mock *MockImpersonation
+}
+
+// NewMockImpersonation creates a new mock instance.
+func NewMockImpersonation(ctrl *gomock.Controller) *MockImpersonation {
+	mock := &MockImpersonation{ctrl: ctrl}
+	mock.recorder = &MockImpersonationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonation) EXPECT() *MockImpersonationMockRecorder {
+	return m.recorder
+}
+
+// Impersonate mocks base method.
+func (m *MockImpersonation) Impersonate(ctx context.Context, actorId, actorSessionId int, grantable []string, subjectId int, request presenter.ImpersonationRequest) (presenter.ImpersonationResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Impersonate", ctx, actorId, actorSessionId, grantable, subjectId, request)
+	ret0, _ := ret[0].(presenter.ImpersonationResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Impersonate indicates an expected call of Impersonate.
+func (mr *MockImpersonationMockRecorder) Impersonate(ctx, actorId, actorSessionId, grantable, subjectId, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Impersonate", reflect.TypeOf((*MockImpersonation)(nil).Impersonate), ctx, actorId, actorSessionId, grantable, subjectId, request)
+}
+
+// MockAnonymous is a mock of Anonymous interface.
+type MockAnonymous struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnonymousMockRecorder
+}
+
+// MockAnonymousMockRecorder is the mock recorder for MockAnonymous.
+type MockAnonymousMockRecorder struct {
+	mock *MockAnonymous
+}
+
+// NewMockAnonymous creates a new mock instance.
+func NewMockAnonymous(ctrl *gomock.Controller) *MockAnonymous {
+	mock := &MockAnonymous{ctrl: ctrl}
+	mock.recorder = &MockAnonymousMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnonymous) EXPECT() *MockAnonymousMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateAnonymous mocks base method.
+func (m *MockAnonymous) AuthenticateAnonymous(ctx context.Context) (*pkg.Claims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAnonymous", ctx)
+	ret0, _ := ret[0].(*pkg.Claims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAnonymous indicates an expected call of AuthenticateAnonymous.
+func (mr *MockAnonymousMockRecorder) AuthenticateAnonymous(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAnonymous", reflect.TypeOf((*MockAnonymous)(nil).AuthenticateAnonymous), ctx)
+}
+
+// MockIdempotency is a mock of Idempotency interface.
+type MockIdempotency struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyMockRecorder
+}
+
+// MockIdempotencyMockRecorder is the mock recorder for MockIdempotency.
+type MockIdempotencyMockRecorder struct {
+	mock *MockIdempotency
+}
+
+// NewMockIdempotency creates a new mock instance.
+func NewMockIdempotency(ctrl *gomock.Controller) *MockIdempotency {
+	mock := &MockIdempotency{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotency) EXPECT() *MockIdempotencyMockRecorder {
+	return m.recorder
+}
+
+// BeginIdempotent mocks base method.
+func (m *MockIdempotency) BeginIdempotent(ctx context.Context, owner, key, fingerprint string) (*entity.IdempotentRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginIdempotent", ctx, owner, key, fingerprint)
+	ret0, _ := ret[0].(*entity.IdempotentRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginIdempotent indicates an expected call of BeginIdempotent.
+func (mr *MockIdempotencyMockRecorder) BeginIdempotent(ctx, owner, key, fingerprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginIdempotent", reflect.TypeOf((*MockIdempotency)(nil).BeginIdempotent), ctx, owner, key, fingerprint)
+}
+
+// CompleteIdempotent mocks base method.
+func (m *MockIdempotency) CompleteIdempotent(ctx context.Context, owner, key string, response entity.IdempotentRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotent", ctx, owner, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotent indicates an expected call of CompleteIdempotent.
+func (mr *MockIdempotencyMockRecorder) CompleteIdempotent(ctx, owner, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotent", reflect.TypeOf((*MockIdempotency)(nil).CompleteIdempotent), ctx, owner, key, response)
+}
+
+// ReleaseIdempotent mocks base method.
+func (m *MockIdempotency) ReleaseIdempotent(ctx context.Context, owner, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotent", ctx, owner, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotent indicates an expected call of ReleaseIdempotent.
+func (mr *MockIdempotencyMockRecorder) ReleaseIdempotent(ctx, owner, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUser)(nil).Register), register)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotent", reflect.TypeOf((*MockIdempotency)(nil).ReleaseIdempotent), ctx, owner, key)
 }
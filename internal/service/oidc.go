@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOIDCDisabled    = errors.New("Single sign-on is not enabled")
+	ErrOIDCLoginFailed = errors.New("Single sign-on login failed")
+
+	errUsernameTaken = errors.New("username is taken")
+)
+
+// usernames tried for a new user, the preferred one and numbered variants
+const oidcUsernameAttempts = 5
+
+// OIDCProvider is the identity provider of single sign-on, see pkg.OIDCClient
+type OIDCProvider interface {
+	AuthCodeURL(state, nonce, codeVerifier string) (string, error)
+	Exchange(code, codeVerifier string) (string, error)
+	VerifyIDToken(rawIDToken, nonce string) (pkg.OIDCIdentity, error)
+}
+
+// StartOIDCLogin returns the provider url the user is sent to. State, nonce
+// and PKCE verifier are kept until the provider redirects back
+func (s *UserService) StartOIDCLogin(ctx context.Context) (string, error) {
+	if s.oidc == nil {
+		return "", ErrOIDCDisabled
+	}
+
+	state, nonce, codeVerifier := pkg.GenerateOIDCState(), pkg.GenerateOIDCState(), pkg.GenerateCodeVerifier()
+	url, err := s.oidc.AuthCodeURL(state, nonce, codeVerifier)
+	if err != nil {
+		log.Printf("Can not start oidc login: %s", err.Error())
+		return "", err
+	}
+
+	err = s.oidcRepo.CreateOIDCLogin(ctx, pkg.HashToken(state), nonce, codeVerifier,
+		time.Now().Add(s.config.OIDC.LoginTTL))
+	if err != nil {
+		return "", err
+	}
+	return url, nil
+}
+
+// LoginOIDC finishes a login redirected back from the provider. Local two
+// factor authentication is not asked for, the provider enforces its own
+func (s *UserService) LoginOIDC(ctx context.Context, request presenter.OIDCCallback, client presenter.Client) (presenter.TokenResponse, error) {
+	if s.oidc == nil {
+		return presenter.TokenResponse{}, ErrOIDCDisabled
+	}
+
+	login, err := s.oidcRepo.UseOIDCLogin(ctx, pkg.HashToken(request.State))
+	if err != nil {
+		log.Printf("Unknown or expired oidc state")
+		return presenter.TokenResponse{}, ErrOIDCLoginFailed
+	}
+
+	rawIDToken, err := s.oidc.Exchange(request.Code, login.CodeVerifier)
+	if err != nil {
+		log.Printf("Can not exchange oidc code: %s", err.Error())
+		return presenter.TokenResponse{}, ErrOIDCLoginFailed
+	}
+	identity, err := s.oidc.VerifyIDToken(rawIDToken, login.Nonce)
+	if err != nil {
+		log.Printf("Can not verify oidc id token: %s", err.Error())
+		return presenter.TokenResponse{}, ErrOIDCLoginFailed
+	}
+
+	user, err := s.oidcUser(ctx, identity)
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+	if user.Status == entity.UserStatusPending {
+		log.Printf("User %d is awaiting approval", user.Id)
+		return presenter.TokenResponse{}, ErrRegistrationPending
+	}
+	return s.startSession(ctx, user, client)
+}
+
+// oidcUser returns the user linked to identity, creating it on the first
+// login, with the role mapped from the identity groups
+func (s *UserService) oidcUser(ctx context.Context, identity pkg.OIDCIdentity) (entity.User, error) {
+	role := s.oidcRole(identity.Groups)
+
+	id, err := s.oidcRepo.TouchIdentity(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if id == 0 {
+		id, err = s.provisionOIDCUser(ctx, identity, role)
+		if err != nil {
+			return entity.User{}, err
+		}
+	} else {
+		current, err := s.repo.GetUserById(ctx, id)
+		if err != nil {
+			return entity.User{}, err
+		}
+		if current.Role != role {
+			if err := s.repo.SetUserRole(ctx, id, role); err != nil {
+				return entity.User{}, err
+			}
+		}
+	}
+
+	return s.repo.GetUserCredentials(ctx, id)
+}
+
+func (s *UserService) provisionOIDCUser(ctx context.Context, identity pkg.OIDCIdentity, role string) (int, error) {
+	// the account has no usable local password until one is set with a password reset
+	password, err := pkg.EncodePassword(pkg.GenerateRefreshToken())
+	if err != nil {
+		return 0, err
+	}
+	register := presenter.Register{Password: password}
+
+	// an email already used by a local account stays with it
+	email := normalizeEmail(identity.Email)
+	if email != "" && identity.EmailVerified {
+		if _, err := s.repo.GetUserByEmail(ctx, email); err != nil {
+			register.Email = email
+		}
+	}
+
+	username := oidcUsername(identity)
+	var id int
+	for attempt := 1; attempt <= oidcUsernameAttempts; attempt++ {
+		register.Username = username
+		if attempt > 1 {
+			register.Username = username + "-" + strconv.Itoa(attempt)
+		}
+		// a failed statement aborts the transaction, so every username gets its own
+		err = s.inTransaction(ctx, func(tx *UserService) error {
+			id, err = tx.repo.CreateUser(ctx, register, role, entity.UserStatusActive)
+			if err != nil {
+				log.Printf("Can not create user for %s identity %s: %s", identity.Issuer, identity.Subject, err.Error())
+				return errUsernameTaken
+			}
+			if register.Email != "" {
+				if err := tx.repo.VerifyEmail(ctx, id); err != nil {
+					return err
+				}
+			}
+			// fails if a concurrent first login of the same identity was faster
+			return tx.oidcRepo.CreateIdentity(ctx, id, identity.Issuer, identity.Subject)
+		})
+		if !errors.Is(err, errUsernameTaken) {
+			break
+		}
+	}
+	if errors.Is(err, errUsernameTaken) {
+		return 0, errors.New("Can not create user for single sign-on login")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Provision user %d as %s for %s identity %s", id, role, identity.Issuer, identity.Subject)
+	return id, nil
+}
+
+func (s *UserService) oidcRole(groups []string) string {
+	for _, mapping := range s.config.OIDC.RoleMapping {
+		for _, group := range groups {
+			if group == mapping.Group {
+				return mapping.Role
+			}
+		}
+	}
+	return s.config.OIDC.DefaultRole
+}
+
+func oidcUsername(identity pkg.OIDCIdentity) string {
+	username := strings.TrimSpace(identity.PreferredUsername)
+	if username == "" {
+		username, _, _ = strings.Cut(strings.TrimSpace(identity.Email), "@")
+	}
+	if len(username) < 2 {
+		username = "sso-" + identity.Subject
+	}
+	return username
+}
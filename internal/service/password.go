@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicyError lists every rule a new password breaks, so the user
+// can fix them all at once
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "Password does not meet the policy: " + strings.Join(e.Violations, ", ")
+}
+
+// Unwrap makes the policy error a validation error, answered with 422
+func (e *PasswordPolicyError) Unwrap() error {
+	return &entity.ValidationError{Message: e.Error()}
+}
+
+// LoadBreachedPasswords reads a list of known leaked passwords, one per line.
+// Lines starting with # are comments
+func LoadBreachedPasswords(path string) (map[string]bool, error) {
+	breached := make(map[string]bool)
+	if path == "" {
+		return breached, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Loaded %d breached passwords from %s", len(breached), path)
+	return breached, nil
+}
+
+func (c PasswordConfig) Check(password string) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if length < c.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", c.MinLength))
+	}
+	if length > c.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters long", c.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if c.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if c.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if c.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if c.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if c.Breached[strings.ToLower(password)] {
+		violations = append(violations, "is known from data breaches")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// hashNewPassword checks a password chosen by a user against the policy
+func (s *UserService) hashNewPassword(password string) (string, error) {
+	if err := s.config.Password.Check(password); err != nil {
+		return "", err
+	}
+
+	hash, err := pkg.EncodePassword(password)
+	if err != nil {
+		log.Printf("Can not encode password: %s", err.Error())
+		return "", errors.New("Can not encode password")
+	}
+	return hash, nil
+}
+
+// rehashPassword upgrades the stored hash after a successful login, when
+// the plain password is known. Failures are logged only, the old hash stays valid
+func (s *UserService) rehashPassword(ctx context.Context, id int, hash, password string) {
+	if !pkg.PasswordNeedsRehash(hash) {
+		return
+	}
+
+	newHash, err := pkg.EncodePassword(password)
+	if err == nil {
+		err = s.repo.UpdatePassword(ctx, id, newHash)
+	}
+	if err != nil {
+		log.Printf("Can not rehash password of user %d: %s", id, err.Error())
+		return
+	}
+	log.Printf("Rehash password of user %d", id)
+}
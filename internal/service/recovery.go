@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	ErrEmailVerificationSent = errors.New("Check your email to verify the account")
+	ErrEmailNotVerified      = errors.New("Email is not verified")
+)
+
+// ForgotPassword mails a password reset token. It succeeds for unknown
+// emails too, so the response does not reveal which emails are registered
+func (s *UserService) ForgotPassword(ctx context.Context, request presenter.ForgotPassword) error {
+	user, err := s.repo.GetUserByEmail(ctx, normalizeEmail(request.Email))
+	if err != nil {
+		log.Printf("Password reset requested for unknown email: %s", err.Error())
+		return nil
+	}
+
+	token, err := s.issueUserToken(ctx, user.Id, entity.TokenPurposePasswordReset, s.config.Account.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	err = s.mailer.Send(pkg.Mail{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello, %s!\r\n\r\n"+
+			"Use this token to set a new password via POST /api/auth/reset-password:\r\n\r\n%s\r\n\r\n"+
+			"The token expires in %s. If you did not request a password reset, ignore this email.\r\n",
+			user.Username, token, s.config.Account.PasswordResetTTL),
+	})
+	if err != nil {
+		log.Printf("Can not send password reset mail to user %d: %s", user.Id, err.Error())
+	}
+	return nil
+}
+
+func (s *UserService) ResetPassword(ctx context.Context, request presenter.ResetPassword) error {
+	pass, err := s.hashNewPassword(request.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.inTransaction(ctx, func(tx *UserService) error {
+		id, err := tx.userTokenRepo.UseUserToken(ctx, entity.TokenPurposePasswordReset, pkg.HashToken(request.Token))
+		if err != nil {
+			return err
+		}
+
+		user, err := tx.repo.PatchUser(ctx, id, 0, presenter.UserRequest{Password: &pass})
+		if err != nil {
+			return err
+		}
+
+		// the reset token was delivered to the user's mailbox, which proves ownership
+		if err := tx.repo.VerifyEmail(ctx, id); err != nil {
+			return err
+		}
+		if err := tx.failureRepo.ResetLoginFailures(ctx, entity.LoginFailureUsername, user.Username); err != nil {
+			return err
+		}
+		return tx.tokenRepo.RevokeUserTokens(ctx, id)
+	})
+}
+
+func (s *UserService) VerifyEmail(ctx context.Context, request presenter.VerifyEmail) error {
+	id, err := s.userTokenRepo.UseUserToken(ctx, entity.TokenPurposeEmailVerification, pkg.HashToken(request.Token))
+	if err != nil {
+		return err
+	}
+	return s.repo.VerifyEmail(ctx, id)
+}
+
+func (s *UserService) sendEmailVerification(ctx context.Context, id int, username, email string) error {
+	token, err := s.issueUserToken(ctx, id, entity.TokenPurposeEmailVerification, s.config.Account.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(pkg.Mail{
+		To:      email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\r\n\r\n"+
+			"Use this token to confirm your email via POST /api/auth/verify-email:\r\n\r\n%s\r\n\r\n"+
+			"The token expires in %s.\r\n",
+			username, token, s.config.Account.EmailVerificationTTL),
+	})
+}
+
+// issueUserToken replaces unused tokens of the same purpose with a new one
+func (s *UserService) issueUserToken(ctx context.Context, userId int, purpose string, ttl time.Duration) (string, error) {
+	token := pkg.GenerateRefreshToken()
+	err := s.inTransaction(ctx, func(tx *UserService) error {
+		if err := tx.userTokenRepo.DeleteUserTokens(ctx, userId, purpose); err != nil {
+			return err
+		}
+		return tx.userTokenRepo.CreateUserToken(ctx, userId, purpose, pkg.HashToken(token), time.Now().Add(ttl))
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/repository"
+)
+
+type RoleService struct {
+	repo repository.Role
+	uow  repository.UnitOfWork
+}
+
+func NewRoleService(repo repository.Role, uow repository.UnitOfWork) *RoleService {
+	return &RoleService{repo: repo, uow: uow}
+}
+
+func (s *RoleService) GetRole(ctx context.Context, id int) (presenter.RoleResponse, error) {
+	return s.repo.GetRole(ctx, id)
+}
+
+func (s *RoleService) GetRoles(ctx context.Context) ([]presenter.RoleResponse, error) {
+	return s.repo.GetRoles(ctx)
+}
+
+func (s *RoleService) CreateRole(ctx context.Context, request presenter.RoleRequest) (int, error) {
+	request.Permissions = uniquePermissions(request.Permissions)
+
+	var id int
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		id, err = repo.Role.CreateRole(ctx, request)
+		return err
+	})
+	return id, err
+}
+
+func (s *RoleService) PutRole(ctx context.Context, id int, request presenter.RoleRequest) (presenter.RoleResponse, error) {
+	request.Permissions = uniquePermissions(request.Permissions)
+
+	var role presenter.RoleResponse
+	err := s.uow.Do(ctx, func(repo *repository.Repository) error {
+		var err error
+		role, err = repo.Role.PutRole(ctx, id, request)
+		if err != nil {
+			return err
+		}
+		// access tokens carry the permissions of the role they were issued for
+		return repo.Token.RevokeRoleAccessTokens(ctx, id)
+	})
+	return role, err
+}
+
+func (s *RoleService) DeleteRole(ctx context.Context, id int) error {
+	return s.repo.DeleteRole(ctx, id)
+}
+
+func uniquePermissions(permissions []string) []string {
+	unique := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if !stringInSlice(unique, permission) {
+			unique = append(unique, permission)
+		}
+	}
+	return unique
+}
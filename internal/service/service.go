@@ -1,63 +1,159 @@
 package service
 
 import (
+	"context"
 	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/repository"
+	"filmLibraryVk/pkg"
+	"time"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
-
 type Actor interface {
-	GetActor(id int) (presenter.ActorResponse, error)
-	GetActors() ([]presenter.ActorResponse, error)
+	GetActor(ctx context.Context, id int) (presenter.ActorResponse, error)
+	GetActors(ctx context.Context) ([]presenter.ActorResponse, error)
+
+	CreateActor(ctx context.Context, request presenter.ActorRequest) (int, error)
 
-	CreateActor(request presenter.ActorRequest) (int, error)
+	PutActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error)
+	PatchActor(ctx context.Context, id, version int, request presenter.ActorRequest) (presenter.ActorResponse, error)
 
-	PutActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error)
-	PatchActor(id int, request presenter.ActorRequest) (presenter.ActorResponse, error)
+	DeleteActor(ctx context.Context, id, version int) error
 
-	DeleteActor(id int) error
+	BatchActors(ctx context.Context, atomic bool, operations []presenter.ActorBatchOperation) ([]entity.BatchResult, error)
 }
 
 type Film interface {
-	GetFilm(id int) (presenter.FilmResponse, error)
-	GetFilms(sortBy string) ([]presenter.FilmResponse, error)
+	GetFilm(ctx context.Context, id int) (presenter.FilmResponse, error)
+	GetFilms(ctx context.Context, sortBy string) ([]presenter.FilmResponse, error)
 
-	CreateFilm(request presenter.FilmRequest) (int, error)
+	CreateFilm(ctx context.Context, request presenter.FilmRequest) (int, error)
 
-	PutFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error)
-	PatchFilm(id int, request presenter.FilmRequest) (presenter.FilmResponse, error)
+	PutFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error)
+	PatchFilm(ctx context.Context, id, version int, request presenter.FilmRequest) (presenter.FilmResponse, error)
 
-	DeleteFilm(id int) error
+	DeleteFilm(ctx context.Context, id, version int) error
 
-	SearchFilmsBy(field, value string) ([]presenter.FilmResponse, error)
+	BatchFilms(ctx context.Context, atomic bool, operations []presenter.FilmBatchOperation) ([]entity.BatchResult, error)
+
+	SearchFilmsBy(ctx context.Context, field, value string) ([]presenter.FilmResponse, error)
 }
 
 type User interface {
-	GetUserById(id int) (presenter.UserResponse, error)
-	GetUsers() ([]presenter.UserResponse, error)
+	GetUserById(ctx context.Context, id int) (presenter.UserResponse, error)
+	GetUsers(ctx context.Context, status string) ([]presenter.UserResponse, error)
+
+	PutUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error)
+	PatchUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error)
+
+	DeleteUser(ctx context.Context, id, version int) error
+
+	ChangeUsername(ctx context.Context, id int, request presenter.UsernameChange, client presenter.Client) (presenter.UserResponse, error)
+	ChangePassword(ctx context.Context, id int, request presenter.PasswordChange, client presenter.Client) error
+	DeleteAccount(ctx context.Context, id int, request presenter.AccountDeletion, client presenter.Client) error
+
+	Login(ctx context.Context, login presenter.Login, client presenter.Client) (presenter.TokenResponse, error)
+
+	Register(ctx context.Context, register presenter.Register, client presenter.Client) (presenter.TokenResponse, error)
+	GetPendingUsers(ctx context.Context) ([]presenter.UserResponse, error)
+	ApproveUser(ctx context.Context, id int) error
+	RejectUser(ctx context.Context, id int) error
+	UnlockUser(ctx context.Context, id int) error
+	SuspendUser(ctx context.Context, actorId, id int, request presenter.SuspensionRequest) (presenter.UserResponse, error)
+	ReinstateUser(ctx context.Context, id int) (presenter.UserResponse, error)
+
+	ForgotPassword(ctx context.Context, request presenter.ForgotPassword) error
+	ResetPassword(ctx context.Context, request presenter.ResetPassword) error
+	VerifyEmail(ctx context.Context, request presenter.VerifyEmail) error
+
+	LoginTwoFactor(ctx context.Context, request presenter.TwoFactorLogin, client presenter.Client) (presenter.TokenResponse, error)
+	EnrollTwoFactorLogin(ctx context.Context, request presenter.TwoFactorEnrollmentRequest) (presenter.TwoFactorEnrollment, error)
+	GetTwoFactorStatus(ctx context.Context, userId int) (presenter.TwoFactorStatus, error)
+	EnrollTwoFactor(ctx context.Context, userId int) (presenter.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userId int, request presenter.TwoFactorCode, client presenter.Client) (presenter.RecoveryCodes, error)
+	DisableTwoFactor(ctx context.Context, userId int, request presenter.TwoFactorDisable, client presenter.Client) error
+	RegenerateRecoveryCodes(ctx context.Context, userId int, request presenter.TwoFactorCode, client presenter.Client) (presenter.RecoveryCodes, error)
+	ResetTwoFactor(ctx context.Context, userId int) error
+
+	StartOIDCLogin(ctx context.Context) (string, error)
+	LoginOIDC(ctx context.Context, request presenter.OIDCCallback, client presenter.Client) (presenter.TokenResponse, error)
+
+	Refresh(ctx context.Context, request presenter.RefreshRequest, client presenter.Client) (presenter.TokenResponse, error)
+	Logout(ctx context.Context, userId, sessionId int, jti string, expiresAt time.Time, request presenter.RefreshRequest) error
+
+	GetSessions(ctx context.Context, userId, currentSessionId int) ([]presenter.SessionResponse, error)
+	RevokeSession(ctx context.Context, userId, sessionId int) error
+	RevokeOtherSessions(ctx context.Context, userId, currentSessionId int) error
 
-	PutUser(id int, request presenter.UserRequest) (presenter.UserResponse, error)
-	PatchUser(id int, request presenter.UserRequest) (presenter.UserResponse, error)
+	IsTokenRevoked(ctx context.Context, jti string, userId, sessionId int, issuedAt time.Time) (bool, error)
+}
+
+type Role interface {
+	GetRole(ctx context.Context, id int) (presenter.RoleResponse, error)
+	GetRoles(ctx context.Context) ([]presenter.RoleResponse, error)
+
+	CreateRole(ctx context.Context, request presenter.RoleRequest) (int, error)
+
+	PutRole(ctx context.Context, id int, request presenter.RoleRequest) (presenter.RoleResponse, error)
 
-	DeleteUser(id int) error
+	DeleteRole(ctx context.Context, id int) error
+}
+
+type Invite interface {
+	GetInvites(ctx context.Context) ([]presenter.InviteResponse, error)
+	CreateInvite(ctx context.Context, createdBy int, request presenter.InviteRequest) (presenter.InviteResponse, error)
+	DeleteInvite(ctx context.Context, id int) error
+}
 
-	Login(login presenter.Login) (string, error)
+type ApiKey interface {
+	GetApiKeys(ctx context.Context) ([]presenter.ApiKeyResponse, error)
+	CreateApiKey(ctx context.Context, createdBy int, grantable []string, request presenter.ApiKeyRequest) (presenter.ApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, id int) error
+
+	AuthenticateApiKey(ctx context.Context, key string) (*pkg.Claims, error)
+}
+
+type Impersonation interface {
+	Impersonate(ctx context.Context, actorId, actorSessionId int, grantable []string, subjectId int,
+		request presenter.ImpersonationRequest) (presenter.ImpersonationResponse, error)
+}
+
+type Anonymous interface {
+	AuthenticateAnonymous(ctx context.Context) (*pkg.Claims, error)
+}
 
-	Register(register presenter.Register) (string, error)
+type Idempotency interface {
+	BeginIdempotent(ctx context.Context, owner, key, fingerprint string) (*entity.IdempotentRequest, error)
+	CompleteIdempotent(ctx context.Context, owner, key string, response entity.IdempotentRequest) error
+	ReleaseIdempotent(ctx context.Context, owner, key string) error
 }
 
 type Service struct {
 	Actor
 	Film
 	User
+	Role
+	Invite
+	ApiKey
+	Impersonation
+	Anonymous
+	Idempotency
 }
 
-func NewService(repo *repository.Repository) *Service {
+func NewService(repo *repository.Repository, mailer pkg.Mailer, oidc OIDCProvider, config Config) *Service {
 	return &Service{
-		Actor: NewActorService(repo.Actor),
-		Film:  NewFilmService(repo.Film),
-		User:  NewUserService(repo.User),
+		Actor: NewActorService(repo.Actor, repo.UnitOfWork),
+		Film:  NewFilmService(repo.Film, repo.UnitOfWork),
+		User: NewUserService(repo.User, repo.Role, repo.Invite, repo.LoginFailure, repo.UserToken,
+			repo.TwoFactor, repo.OIDC, repo.Token, repo.UnitOfWork, mailer, oidc, config),
+		Role:   NewRoleService(repo.Role, repo.UnitOfWork),
+		Invite: NewInviteService(repo.Invite, config.Registration.InviteTTL),
+		ApiKey: NewApiKeyService(repo.ApiKey),
+		Impersonation: NewImpersonationService(repo.Impersonation, repo.User, repo.Role,
+			config.Impersonation.TTL),
+		Anonymous:   NewAnonymousService(repo.Role, config.Anonymous.Role),
+		Idempotency: NewIdempotencyService(repo.Idempotency, config.Idempotency.TTL),
 	}
 }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"fmt"
+	"log"
+	"time"
+)
+
+// SuspendedError is returned when a suspended or banned user asks for tokens
+type SuspendedError struct {
+	Status string
+	Reason string
+	Until  *time.Time
+}
+
+func (e *SuspendedError) Error() string {
+	if e.Status == entity.UserStatusBanned {
+		return "Account is banned: " + e.Reason
+	}
+	if e.Until != nil {
+		return fmt.Sprintf("Account is suspended until %s: %s", e.Until.UTC().Format(time.RFC3339), e.Reason)
+	}
+	return "Account is suspended: " + e.Reason
+}
+
+// suspensionError returns a SuspendedError for a suspended or banned user, nil otherwise
+func suspensionError(user entity.User) error {
+	if user.Status != entity.UserStatusSuspended && user.Status != entity.UserStatusBanned {
+		return nil
+	}
+	log.Printf("User %d is %s", user.Id, user.Status)
+	return &SuspendedError{Status: user.Status, Reason: user.SuspensionReason, Until: user.SuspendedUntil}
+}
+
+// SuspendUser suspends or bans a user. All sessions of the user are revoked,
+// so a reinstated user has to log in again
+func (s *UserService) SuspendUser(ctx context.Context, actorId, id int, request presenter.SuspensionRequest) (presenter.UserResponse, error) {
+	if id == actorId {
+		return presenter.UserResponse{}, &entity.ForbiddenError{Message: "Can not suspend yourself"}
+	}
+	if request.Ban && request.ExpiresIn != 0 {
+		return presenter.UserResponse{}, &entity.ValidationError{Message: "A ban can not expire",
+			Fields: []entity.FieldError{{Field: "expiresIn", Message: "must be 0 for a ban"}}}
+	}
+
+	user, err := s.repo.GetUserCredentials(ctx, id)
+	if err != nil {
+		return presenter.UserResponse{}, err
+	}
+	if user.Status == entity.UserStatusPending {
+		return presenter.UserResponse{}, ErrRegistrationPending
+	}
+
+	status := entity.UserStatusSuspended
+	var until *time.Time
+	if request.Ban {
+		status = entity.UserStatusBanned
+	} else if request.ExpiresIn != 0 {
+		expiresAt := time.Now().Add(time.Duration(request.ExpiresIn) * time.Second)
+		until = &expiresAt
+	}
+
+	err = s.inTransaction(ctx, func(tx *UserService) error {
+		suspended, err := tx.repo.SuspendUser(ctx, id, status, request.Reason, until, actorId)
+		if err != nil {
+			return err
+		}
+		if !suspended {
+			return &entity.NotFoundError{Entity: "user"}
+		}
+		return tx.tokenRepo.RevokeUserTokens(ctx, id)
+	})
+	if err != nil {
+		return presenter.UserResponse{}, err
+	}
+
+	log.Printf("User %d set status of user %d to %s", actorId, id, status)
+	return s.repo.GetUserById(ctx, id)
+}
+
+func (s *UserService) ReinstateUser(ctx context.Context, id int) (presenter.UserResponse, error) {
+	reinstated, err := s.repo.ReinstateUser(ctx, id)
+	if err != nil {
+		return presenter.UserResponse{}, err
+	}
+	if !reinstated {
+		return presenter.UserResponse{}, &entity.ConflictError{Message: "User is not suspended"}
+	}
+	return s.repo.GetUserById(ctx, id)
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"filmLibraryVk/api/REST/presenter"
+	"filmLibraryVk/internal/model/entity"
+	"filmLibraryVk/pkg"
+	"log"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrTwoFactorInvalidCode = errors.New("Invalid two factor code")
+	ErrTwoFactorEnabled     = errors.New("Two factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("Two factor authentication is not enabled")
+	ErrTwoFactorNotStarted  = errors.New("Two factor enrollment is not started")
+	ErrTwoFactorMandatory   = errors.New("Two factor authentication is required for your role")
+)
+
+// TwoFactorRequiredError is returned by Login when the password was correct
+// but a second factor is needed. The challenge token is exchanged for tokens
+// with a TOTP or recovery code
+type TwoFactorRequiredError struct {
+	Challenge presenter.TwoFactorChallenge
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "Two factor authentication code is required"
+}
+
+func (s *UserService) LoginTwoFactor(ctx context.Context, request presenter.TwoFactorLogin, client presenter.Client) (presenter.TokenResponse, error) {
+	userId, err := s.userTokenRepo.GetUserToken(ctx, entity.TokenPurposeTwoFactor, pkg.HashToken(request.MfaToken))
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+
+	user, err := s.repo.GetUserCredentials(ctx, userId)
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+
+	login := presenter.Login{Username: user.Username}
+	if err := s.checkLoginThrottle(ctx, login, client); err != nil {
+		return presenter.TokenResponse{}, err
+	}
+
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+
+	enrolling := twoFactor.EnabledAt == nil
+	var valid bool
+	if enrolling {
+		if twoFactor.Secret == "" {
+			return presenter.TokenResponse{}, ErrTwoFactorNotStarted
+		}
+		valid, err = s.verifyTOTP(ctx, twoFactor, request.Code)
+	} else {
+		valid, err = s.verifyTwoFactorCode(ctx, twoFactor, request.Code)
+	}
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+	if !valid {
+		log.Printf("Invalid two factor code of user %d", userId)
+		if err := s.recordLoginFailure(ctx, login, client); err != nil {
+			return presenter.TokenResponse{}, err
+		}
+		return presenter.TokenResponse{}, ErrTwoFactorInvalidCode
+	}
+
+	var tokens presenter.TokenResponse
+	err = s.inTransaction(ctx, func(tx *UserService) error {
+		if _, err := tx.userTokenRepo.UseUserToken(ctx, entity.TokenPurposeTwoFactor, pkg.HashToken(request.MfaToken)); err != nil {
+			return err
+		}
+		if err := tx.failureRepo.ResetLoginFailures(ctx, entity.LoginFailureUsername, user.Username); err != nil {
+			return err
+		}
+
+		var recoveryCodes []string
+		if enrolling {
+			if err := tx.twoFactorRepo.EnableTwoFactor(ctx, userId); err != nil {
+				return err
+			}
+			if recoveryCodes, err = tx.issueRecoveryCodes(ctx, userId); err != nil {
+				return err
+			}
+		}
+
+		tokens, err = tx.startSession(ctx, user, client)
+		if err != nil {
+			return err
+		}
+		tokens.RecoveryCodes = recoveryCodes
+		return nil
+	})
+	return tokens, err
+}
+
+// EnrollTwoFactorLogin starts enrollment for a user who has to enroll
+// before completing the login
+func (s *UserService) EnrollTwoFactorLogin(ctx context.Context, request presenter.TwoFactorEnrollmentRequest) (presenter.TwoFactorEnrollment, error) {
+	userId, err := s.userTokenRepo.GetUserToken(ctx, entity.TokenPurposeTwoFactor, pkg.HashToken(request.MfaToken))
+	if err != nil {
+		return presenter.TwoFactorEnrollment{}, err
+	}
+	return s.EnrollTwoFactor(ctx, userId)
+}
+
+func (s *UserService) GetTwoFactorStatus(ctx context.Context, userId int) (presenter.TwoFactorStatus, error) {
+	user, err := s.repo.GetUserCredentials(ctx, userId)
+	if err != nil {
+		return presenter.TwoFactorStatus{}, err
+	}
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return presenter.TwoFactorStatus{}, err
+	}
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return presenter.TwoFactorStatus{}, err
+	}
+
+	status := presenter.TwoFactorStatus{Enabled: twoFactor.EnabledAt != nil, Required: required}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(ctx, userId); err != nil {
+			return presenter.TwoFactorStatus{}, err
+		}
+	}
+	return status, nil
+}
+
+// EnrollTwoFactor generates a new secret. It takes effect after a code
+// generated from it is confirmed
+func (s *UserService) EnrollTwoFactor(ctx context.Context, userId int) (presenter.TwoFactorEnrollment, error) {
+	user, err := s.repo.GetUserCredentials(ctx, userId)
+	if err != nil {
+		return presenter.TwoFactorEnrollment{}, err
+	}
+
+	secret := pkg.GenerateTOTPSecret()
+	saved, err := s.twoFactorRepo.SaveTwoFactorSecret(ctx, userId, secret)
+	if err != nil {
+		return presenter.TwoFactorEnrollment{}, err
+	}
+	if !saved {
+		return presenter.TwoFactorEnrollment{}, ErrTwoFactorEnabled
+	}
+
+	uri := pkg.TOTPURI(s.config.TwoFactor.Issuer, user.Username, secret)
+	qrCode, err := pkg.QRCodePNG(uri)
+	if err != nil {
+		log.Printf("Can not render QR code for user %d: %s", userId, err.Error())
+		return presenter.TwoFactorEnrollment{}, err
+	}
+	return presenter.TwoFactorEnrollment{Secret: secret, Uri: uri, QrCode: qrCode}, nil
+}
+
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, userId int, request presenter.TwoFactorCode, client presenter.Client) (presenter.RecoveryCodes, error) {
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return presenter.RecoveryCodes{}, err
+	}
+	if twoFactor.EnabledAt != nil {
+		return presenter.RecoveryCodes{}, ErrTwoFactorEnabled
+	}
+	if twoFactor.Secret == "" {
+		return presenter.RecoveryCodes{}, ErrTwoFactorNotStarted
+	}
+
+	err = s.reauthenticate(ctx, userId, client, func(entity.User) (bool, error) {
+		return s.verifyTOTP(ctx, twoFactor, request.Code)
+	}, ErrTwoFactorInvalidCode)
+	if err != nil {
+		return presenter.RecoveryCodes{}, err
+	}
+
+	var codes []string
+	err = s.inTransaction(ctx, func(tx *UserService) error {
+		if err := tx.twoFactorRepo.EnableTwoFactor(ctx, userId); err != nil {
+			return err
+		}
+		codes, err = tx.issueRecoveryCodes(ctx, userId)
+		return err
+	})
+	if err != nil {
+		return presenter.RecoveryCodes{}, err
+	}
+	return presenter.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+func (s *UserService) DisableTwoFactor(ctx context.Context, userId int, request presenter.TwoFactorDisable, client presenter.Client) error {
+	if err := s.confirmPassword(ctx, userId, request.CurrentPassword, client); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUserCredentials(ctx, userId)
+	if err != nil {
+		return err
+	}
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorMandatory
+	}
+
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if twoFactor.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	err = s.reauthenticate(ctx, userId, client, func(entity.User) (bool, error) {
+		return s.verifyTwoFactorCode(ctx, twoFactor, request.Code)
+	}, ErrTwoFactorInvalidCode)
+	if err != nil {
+		return err
+	}
+	return s.twoFactorRepo.DeleteTwoFactor(ctx, userId)
+}
+
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userId int, request presenter.TwoFactorCode, client presenter.Client) (presenter.RecoveryCodes, error) {
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return presenter.RecoveryCodes{}, err
+	}
+	if twoFactor.EnabledAt == nil {
+		return presenter.RecoveryCodes{}, ErrTwoFactorNotEnabled
+	}
+
+	err = s.reauthenticate(ctx, userId, client, func(entity.User) (bool, error) {
+		return s.verifyTwoFactorCode(ctx, twoFactor, request.Code)
+	}, ErrTwoFactorInvalidCode)
+	if err != nil {
+		return presenter.RecoveryCodes{}, err
+	}
+
+	codes, err := s.issueRecoveryCodes(ctx, userId)
+	if err != nil {
+		return presenter.RecoveryCodes{}, err
+	}
+	return presenter.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// ResetTwoFactor removes the second factor of a user who lost it. The user
+// has to enroll again on the next login if it is required for the role
+func (s *UserService) ResetTwoFactor(ctx context.Context, userId int) error {
+	if _, err := s.repo.GetUserCredentials(ctx, userId); err != nil {
+		return err
+	}
+	log.Printf("Reset two factor authentication of user %d", userId)
+	return s.twoFactorRepo.DeleteTwoFactor(ctx, userId)
+}
+
+// twoFactorChallenge returns a challenge if the user has to pass a second
+// factor before getting tokens
+func (s *UserService) twoFactorChallenge(ctx context.Context, user entity.User) (*TwoFactorRequiredError, error) {
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	enabled := twoFactor.EnabledAt != nil
+
+	required, err := s.twoFactorRequired(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled && !required {
+		return nil, nil
+	}
+
+	token, err := s.issueUserToken(ctx, user.Id, entity.TokenPurposeTwoFactor, s.config.TwoFactor.ChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorRequiredError{Challenge: presenter.TwoFactorChallenge{
+		MfaToken:           token,
+		EnrollmentRequired: !enabled,
+		ExpiresIn:          int(s.config.TwoFactor.ChallengeTTL / time.Second),
+	}}, nil
+}
+
+func (s *UserService) twoFactorRequired(ctx context.Context, user entity.User) (bool, error) {
+	if !s.config.TwoFactor.RequireForAdmin {
+		return false, nil
+	}
+	role, err := s.roleRepo.GetRole(ctx, user.RoleId)
+	if err != nil {
+		return false, err
+	}
+	return role.Role == "ADMIN", nil
+}
+
+// verifyTwoFactorCode accepts a TOTP code or an unused recovery code
+func (s *UserService) verifyTwoFactorCode(ctx context.Context, twoFactor entity.TwoFactor, code string) (bool, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if len(code) == pkg.TOTPDigits {
+		return s.verifyTOTP(ctx, twoFactor, code)
+	}
+	return s.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.UserId, pkg.HashToken(code))
+}
+
+// verifyTOTP rejects a code of an already used time step, so a code
+// seen by an attacker can not be replayed within its validity window
+func (s *UserService) verifyTOTP(ctx context.Context, twoFactor entity.TwoFactor, code string) (bool, error) {
+	step, valid := pkg.ValidateTOTP(twoFactor.Secret, strings.TrimSpace(code), time.Now(), s.config.TwoFactor.Skew)
+	if !valid {
+		return false, nil
+	}
+	return s.twoFactorRepo.UseTwoFactorStep(ctx, twoFactor.UserId, step)
+}
+
+func (s *UserService) issueRecoveryCodes(ctx context.Context, userId int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = pkg.GenerateRecoveryCode()
+		hashes[i] = pkg.HashToken(codes[i])
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
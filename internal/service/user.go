@@ -1,103 +1,457 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"filmLibraryVk/api/REST/presenter"
 	"filmLibraryVk/internal/model/entity"
 	"filmLibraryVk/internal/repository"
 	"filmLibraryVk/pkg"
 	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRegistrationClosed  = errors.New("Registration is closed")
+	ErrRegistrationPending = errors.New("Registration is awaiting approval")
 )
 
 type UserService struct {
-	repo repository.User
+	repo          repository.User
+	roleRepo      repository.Role
+	inviteRepo    repository.Invite
+	failureRepo   repository.LoginFailure
+	userTokenRepo repository.UserToken
+	twoFactorRepo repository.TwoFactor
+	oidcRepo      repository.OIDC
+	tokenRepo     repository.Token
+	uow           repository.UnitOfWork
+	mailer        pkg.Mailer
+	oidc          OIDCProvider
+	config        Config
 }
 
-func NewUserService(repo repository.User) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.User, roleRepo repository.Role, inviteRepo repository.Invite,
+	failureRepo repository.LoginFailure, userTokenRepo repository.UserToken, twoFactorRepo repository.TwoFactor,
+	oidcRepo repository.OIDC, tokenRepo repository.Token, uow repository.UnitOfWork, mailer pkg.Mailer,
+	oidc OIDCProvider, config Config) *UserService {
+	return &UserService{repo: repo, roleRepo: roleRepo, inviteRepo: inviteRepo, failureRepo: failureRepo,
+		userTokenRepo: userTokenRepo, twoFactorRepo: twoFactorRepo, oidcRepo: oidcRepo, tokenRepo: tokenRepo,
+		uow: uow, mailer: mailer, oidc: oidc, config: config}
 }
 
-func (s *UserService) GetUserById(id int) (presenter.UserResponse, error) {
-	return s.repo.GetUserById(id)
+// inTransaction runs fn with a copy of the service whose repositories share
+// one transaction. Nested calls join the running transaction
+func (s *UserService) inTransaction(ctx context.Context, fn func(tx *UserService) error) error {
+	return s.uow.Do(ctx, func(repo *repository.Repository) error {
+		tx := *s
+		tx.repo, tx.roleRepo, tx.inviteRepo, tx.failureRepo = repo.User, repo.Role, repo.Invite, repo.LoginFailure
+		tx.userTokenRepo, tx.twoFactorRepo, tx.oidcRepo = repo.UserToken, repo.TwoFactor, repo.OIDC
+		tx.tokenRepo, tx.uow = repo.Token, repo.UnitOfWork
+		return fn(&tx)
+	})
 }
 
-func (s *UserService) GetUsers() ([]presenter.UserResponse, error) {
-	return s.repo.GetUsers()
+func (s *UserService) GetUserById(ctx context.Context, id int) (presenter.UserResponse, error) {
+	return s.repo.GetUserById(ctx, id)
 }
 
-func (s *UserService) Login(login presenter.Login) (string, error) {
-	user, err := s.repo.GetUserByUsername(login.Username)
+// GetUsers returns the users in status, all users for an empty status
+func (s *UserService) GetUsers(ctx context.Context, status string) ([]presenter.UserResponse, error) {
+	switch status {
+	case "", entity.UserStatusActive, entity.UserStatusPending, entity.UserStatusSuspended, entity.UserStatusBanned:
+	default:
+		return nil, &entity.ValidationError{Message: "Unknown user status " + status}
+	}
+	return s.repo.GetUsers(ctx, status)
+}
 
-	if err != nil {
-		log.Printf("Invalid login or password")
-		return "", errors.New("Invalid login or password")
+func (s *UserService) Login(ctx context.Context, login presenter.Login, client presenter.Client) (presenter.TokenResponse, error) {
+	if err := s.checkLoginThrottle(ctx, login, client); err != nil {
+		return presenter.TokenResponse{}, err
 	}
 
-	err = pkg.ComparePasswords(user.Password, login.Password)
+	user, err := s.repo.GetUserByUsername(ctx, login.Username)
+
+	if err == nil {
+		err = pkg.ComparePasswords(user.Password, login.Password)
+	} else {
+		pkg.CompareDummyPassword(login.Password)
+	}
 
 	if err != nil {
 		log.Printf("Invalid login or password")
-		return "", errors.New("Invalid login or password")
+		if err := s.recordLoginFailure(ctx, login, client); err != nil {
+			return presenter.TokenResponse{}, err
+		}
+		return presenter.TokenResponse{}, errors.New("Invalid login or password")
 	}
 
-	jwt, err := pkg.GenerateJWT(entity.User{
-		Id:       user.Id,
-		Username: user.Username,
-		RoleId:   user.RoleId})
+	if err := s.failureRepo.ResetLoginFailures(ctx, entity.LoginFailureUsername, user.Username); err != nil {
+		return presenter.TokenResponse{}, err
+	}
+	s.rehashPassword(ctx, user.Id, user.Password, login.Password)
+
+	if user.Status == entity.UserStatusPending {
+		log.Printf("User %d is awaiting approval", user.Id)
+		return presenter.TokenResponse{}, ErrRegistrationPending
+	}
+	if err := suspensionError(user); err != nil {
+		return presenter.TokenResponse{}, err
+	}
+	if s.config.Registration.VerifyEmail && user.Email != "" && !user.EmailVerified {
+		log.Printf("User %d has not verified email", user.Id)
+		return presenter.TokenResponse{}, ErrEmailNotVerified
+	}
+
+	challenge, err := s.twoFactorChallenge(ctx, user)
 	if err != nil {
-		log.Printf("Can not create JWT token for user %d", user.Id)
+		return presenter.TokenResponse{}, err
+	}
+	if challenge != nil {
+		return presenter.TokenResponse{}, challenge
 	}
-	return jwt, nil
+
+	return s.startSession(ctx, user, client)
 }
 
-func (s *UserService) Register(register presenter.Register) (string, error) {
+func (s *UserService) Register(ctx context.Context, register presenter.Register, client presenter.Client) (presenter.TokenResponse, error) {
+	switch s.config.Registration.Mode {
+	case RegistrationClosed:
+		return presenter.TokenResponse{}, ErrRegistrationClosed
+	case RegistrationInvite:
+		if register.InviteCode == "" {
+			return presenter.TokenResponse{}, &entity.ValidationError{Message: "Invite code is required",
+				Fields: []entity.FieldError{{Field: "inviteCode", Message: "is required"}}}
+		}
+	}
+
+	register.Email = normalizeEmail(register.Email)
+	if s.config.Registration.VerifyEmail && register.Email == "" {
+		return presenter.TokenResponse{}, &entity.ValidationError{Message: "Email is required",
+			Fields: []entity.FieldError{{Field: "email", Message: "is required"}}}
+	}
+
 	var err error
-	register.Password, err = pkg.EncodePassword(register.Password)
+	register.Password, err = s.hashNewPassword(register.Password)
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+
+	status := entity.UserStatusActive
+	if s.config.Registration.Mode == RegistrationApproval {
+		status = entity.UserStatusPending
+	}
+
+	// the user is only kept together with the invite it used
+	var id int
+	err = s.inTransaction(ctx, func(tx *UserService) error {
+		id, err = tx.repo.CreateUser(ctx, register, s.config.Registration.DefaultRole, status)
+		if err != nil {
+			log.Printf("Can not create user %s: %s", register.Username, err.Error())
+			return err
+		}
+
+		if s.config.Registration.Mode == RegistrationInvite {
+			used, err := tx.inviteRepo.UseInvite(ctx, pkg.HashToken(register.InviteCode), id)
+			if err != nil {
+				return err
+			}
+			if !used {
+				return &entity.ValidationError{Message: "Invalid invite code",
+					Fields: []entity.FieldError{{Field: "inviteCode", Message: "is invalid or expired"}}}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
 
-	log.Printf("Pass: %s", register.Password)
+	if s.config.Registration.VerifyEmail {
+		if err := s.sendEmailVerification(ctx, id, register.Username, register.Email); err != nil {
+			log.Printf("Can not send verification mail to user %d: %s", id, err.Error())
+		}
+		return presenter.TokenResponse{}, ErrEmailVerificationSent
+	}
+	if status == entity.UserStatusPending {
+		return presenter.TokenResponse{}, ErrRegistrationPending
+	}
+
+	user, err := s.repo.GetUserCredentials(ctx, id)
 	if err != nil {
-		log.Printf("Can not encode password")
-		return "", nil
+		return presenter.TokenResponse{}, err
 	}
+	return s.startSession(ctx, user, client)
+}
 
-	id, err := s.repo.CreateUser(register)
+func (s *UserService) GetPendingUsers(ctx context.Context) ([]presenter.UserResponse, error) {
+	return s.repo.GetPendingUsers(ctx)
+}
+
+func (s *UserService) ApproveUser(ctx context.Context, id int) error {
+	approved, err := s.repo.ApproveUser(ctx, id)
 	if err != nil {
-		return "", errors.New("User with such username already exists")
+		return err
+	}
+	if !approved {
+		return &entity.NotFoundError{Entity: "user"}
 	}
+	return nil
+}
 
-	jwt, err := pkg.GenerateJWT(entity.User{
-		Id:       id,
-		Username: register.Username,
-		RoleId:   1})
+func (s *UserService) UnlockUser(ctx context.Context, id int) error {
+	user, err := s.repo.GetUserCredentials(ctx, id)
 	if err != nil {
-		log.Printf("Can not create JWT token for user %d", id)
-		return "", err
+		return err
 	}
-	return jwt, err
+	log.Printf("Unlock login of user %d", id)
+	return s.failureRepo.ResetLoginFailures(ctx, entity.LoginFailureUsername, user.Username)
 }
 
-func (s *UserService) PutUser(id int, request presenter.UserRequest) (presenter.UserResponse, error) {
-	pass, err := pkg.EncodePassword(*request.Password)
-	request.Password = &pass
+func (s *UserService) RejectUser(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeletePendingUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return &entity.NotFoundError{Entity: "user"}
+	}
+	return nil
+}
 
+func (s *UserService) Refresh(ctx context.Context, request presenter.RefreshRequest, client presenter.Client) (presenter.TokenResponse, error) {
+	token, err := s.tokenRepo.GetRefreshToken(ctx, pkg.HashToken(request.RefreshToken))
 	if err != nil {
-		return presenter.UserResponse{}, errors.New("Can not encode password")
+		log.Printf("Unknown refresh token")
+		return presenter.TokenResponse{}, errors.New("Invalid refresh token")
 	}
 
-	return s.repo.PutUser(id, request)
+	if token.SessionRevokedAt != nil {
+		log.Printf("Refresh token %d belongs to revoked session %d", token.Id, token.SessionId)
+		return presenter.TokenResponse{}, errors.New("Invalid refresh token")
+	}
+	if token.RevokedAt != nil {
+		log.Printf("Reuse of revoked refresh token %d, revoking all tokens of user %d", token.Id, token.UserId)
+		if err := s.tokenRepo.RevokeUserTokens(ctx, token.UserId); err != nil {
+			return presenter.TokenResponse{}, err
+		}
+		return presenter.TokenResponse{}, errors.New("Invalid refresh token")
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		return presenter.TokenResponse{}, errors.New("Refresh token has expired")
+	}
+
+	// the used token stays valid if no new one can be issued
+	var tokens presenter.TokenResponse
+	err = s.inTransaction(ctx, func(tx *UserService) error {
+		revoked, err := tx.tokenRepo.RevokeRefreshToken(ctx, token.Id)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return errors.New("Invalid refresh token")
+		}
+
+		user, err := tx.repo.GetUserCredentials(ctx, token.UserId)
+		if err != nil {
+			return errors.New("Invalid refresh token")
+		}
+		if err := suspensionError(user); err != nil {
+			return err
+		}
+		if err := tx.tokenRepo.TouchSession(ctx, token.SessionId, client); err != nil {
+			return err
+		}
+		tokens, err = tx.issueTokens(ctx, user, token.SessionId)
+		return err
+	})
+	return tokens, err
 }
-func (s *UserService) PatchUser(id int, request presenter.UserRequest) (presenter.UserResponse, error) {
+
+func (s *UserService) Logout(ctx context.Context, userId, sessionId int, jti string, expiresAt time.Time, request presenter.RefreshRequest) error {
+	if sessionId != 0 {
+		if _, err := s.tokenRepo.RevokeSession(ctx, userId, sessionId); err != nil {
+			return err
+		}
+	}
+	if request.RefreshToken != "" {
+		token, err := s.tokenRepo.GetRefreshToken(ctx, pkg.HashToken(request.RefreshToken))
+		if err == nil && token.UserId == userId {
+			if _, err := s.tokenRepo.RevokeRefreshToken(ctx, token.Id); err != nil {
+				return err
+			}
+		}
+	}
+	return s.tokenRepo.RevokeAccessToken(ctx, jti, expiresAt)
+}
+
+func (s *UserService) GetSessions(ctx context.Context, userId, currentSessionId int) ([]presenter.SessionResponse, error) {
+	sessions, err := s.tokenRepo.GetSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == currentSessionId
+	}
+	return sessions, nil
+}
+
+func (s *UserService) RevokeSession(ctx context.Context, userId, sessionId int) error {
+	revoked, err := s.tokenRepo.RevokeSession(ctx, userId, sessionId)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return &entity.NotFoundError{Entity: "session"}
+	}
+	return nil
+}
+
+func (s *UserService) RevokeOtherSessions(ctx context.Context, userId, currentSessionId int) error {
+	return s.tokenRepo.RevokeSessions(ctx, userId, currentSessionId)
+}
+
+func (s *UserService) IsTokenRevoked(ctx context.Context, jti string, userId, sessionId int, issuedAt time.Time) (bool, error) {
+	return s.tokenRepo.IsAccessTokenRevoked(ctx, jti, userId, sessionId, issuedAt)
+}
+
+func (s *UserService) startSession(ctx context.Context, user entity.User, client presenter.Client) (presenter.TokenResponse, error) {
+	if err := suspensionError(user); err != nil {
+		return presenter.TokenResponse{}, err
+	}
+	var tokens presenter.TokenResponse
+	err := s.inTransaction(ctx, func(tx *UserService) error {
+		sessionId, err := tx.tokenRepo.CreateSession(ctx, user.Id, client)
+		if err != nil {
+			log.Printf("Can not create session for user %d", user.Id)
+			return err
+		}
+		tokens, err = tx.issueTokens(ctx, user, sessionId)
+		return err
+	})
+	return tokens, err
+}
+
+func (s *UserService) issueTokens(ctx context.Context, user entity.User, sessionId int) (presenter.TokenResponse, error) {
+	permissions, err := s.roleRepo.GetRolePermissions(ctx, user.RoleId)
+	if err != nil {
+		return presenter.TokenResponse{}, err
+	}
+
+	jwt, err := pkg.GenerateJWT(entity.User{
+		Id:       user.Id,
+		Username: user.Username,
+		RoleId:   user.RoleId}, sessionId, permissions)
+	if err != nil {
+		log.Printf("Can not create JWT token for user %d", user.Id)
+		return presenter.TokenResponse{}, err
+	}
+
+	refreshToken := pkg.GenerateRefreshToken()
+	_, err = s.tokenRepo.CreateRefreshToken(ctx, user.Id, sessionId, pkg.HashToken(refreshToken),
+		time.Now().Add(pkg.RefreshTokenTTL()))
+	if err != nil {
+		log.Printf("Can not create refresh token for user %d", user.Id)
+		return presenter.TokenResponse{}, err
+	}
+
+	return presenter.TokenResponse{
+		AccessToken:  jwt,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(pkg.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+func (s *UserService) PutUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error) {
+	pass, err := s.hashNewPassword(*request.Password)
+	if err != nil {
+		return presenter.UserResponse{}, err
+	}
+	request.Password = &pass
+
+	var user presenter.UserResponse
+	err = s.inTransaction(ctx, func(tx *UserService) error {
+		user, err = tx.repo.PutUser(ctx, id, version, request)
+		if err != nil {
+			return err
+		}
+		return tx.tokenRepo.RevokeUserTokens(ctx, id)
+	})
+	return user, err
+}
+
+func (s *UserService) PatchUser(ctx context.Context, id, version int, request presenter.UserRequest) (presenter.UserResponse, error) {
 	if request.Password != nil {
-		pass, err := pkg.EncodePassword(*request.Password)
+		pass, err := s.hashNewPassword(*request.Password)
+		if err != nil {
+			return presenter.UserResponse{}, err
+		}
 		request.Password = &pass
+	}
+	var user presenter.UserResponse
+	err := s.inTransaction(ctx, func(tx *UserService) error {
+		var err error
+		user, err = tx.repo.PatchUser(ctx, id, version, request)
+		// access tokens carry the permissions of the role
+		if err != nil || request.Password == nil && request.Role == nil {
+			return err
+		}
+		return tx.tokenRepo.RevokeUserTokens(ctx, id)
+	})
+	return user, err
+}
 
-		if err != nil {
-			return presenter.UserResponse{}, errors.New("Can not encode password")
+func (s *UserService) DeleteUser(ctx context.Context, id, version int) error {
+	return s.repo.DeleteUser(ctx, id, version)
+}
+
+func (s *UserService) ChangeUsername(ctx context.Context, id int, request presenter.UsernameChange, client presenter.Client) (presenter.UserResponse, error) {
+	if err := s.confirmPassword(ctx, id, request.CurrentPassword, client); err != nil {
+		return presenter.UserResponse{}, err
+	}
+	return s.repo.PatchUser(ctx, id, 0, presenter.UserRequest{Username: &request.Username})
+}
+
+func (s *UserService) ChangePassword(ctx context.Context, id int, request presenter.PasswordChange, client presenter.Client) error {
+	if err := s.confirmPassword(ctx, id, request.CurrentPassword, client); err != nil {
+		return err
+	}
+
+	pass, err := s.hashNewPassword(request.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.inTransaction(ctx, func(tx *UserService) error {
+		if _, err := tx.repo.PatchUser(ctx, id, 0, presenter.UserRequest{Password: &pass}); err != nil {
+			return err
 		}
+		return tx.tokenRepo.RevokeUserTokens(ctx, id)
+	})
+}
+
+func (s *UserService) DeleteAccount(ctx context.Context, id int, request presenter.AccountDeletion, client presenter.Client) error {
+	if err := s.confirmPassword(ctx, id, request.CurrentPassword, client); err != nil {
+		return err
 	}
-	return s.repo.PatchUser(id, request)
+	return s.repo.DeleteUser(ctx, id, 0)
+}
+
+// confirmPassword checks the current password of a signed in user before a
+// sensitive change, wrong passwords count as failed logins
+func (s *UserService) confirmPassword(ctx context.Context, id int, password string, client presenter.Client) error {
+	return s.reauthenticate(ctx, id, client, func(user entity.User) (bool, error) {
+		if err := pkg.ComparePasswords(user.Password, password); err != nil {
+			log.Printf("Invalid current password for user %d", id)
+			return false, nil
+		}
+		return true, nil
+	}, errors.New("Invalid current password"))
 }
 
-func (s *UserService) DeleteUser(id int) error {
-	return s.repo.DeleteUser(id)
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
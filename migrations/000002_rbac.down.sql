@@ -0,0 +1,9 @@
+DROP TABLE role_permission;
+DROP TABLE permission;
+
+UPDATE _user SET role_id = (SELECT id FROM role WHERE role = 'USER')
+WHERE role_id IN (SELECT id FROM role WHERE role NOT IN ('ADMIN', 'USER'));
+
+DELETE FROM role WHERE role NOT IN ('ADMIN', 'USER');
+
+ALTER TABLE role DROP CONSTRAINT role_role_key;
@@ -0,0 +1,49 @@
+ALTER TABLE role ADD CONSTRAINT role_role_key UNIQUE (role);
+
+CREATE TABLE permission (
+    id SERIAL PRIMARY KEY,
+    permission TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE role_permission (
+    role_id INT NOT NULL REFERENCES role(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permission(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+INSERT INTO permission (permission) VALUES
+('film:read'),
+('film:write'),
+('film:delete'),
+('actor:read'),
+('actor:write'),
+('actor:delete'),
+('user:read'),
+('user:write'),
+('user:delete'),
+('role:read'),
+('role:write');
+
+INSERT INTO role (role) VALUES
+('EDITOR'),
+('MODERATOR');
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'ADMIN';
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'USER'
+  AND permission.permission IN ('film:read', 'actor:read', 'user:read');
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'EDITOR'
+  AND permission.permission IN ('film:read', 'film:write', 'actor:read', 'actor:write');
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'MODERATOR'
+  AND permission.permission IN ('film:read', 'film:write', 'film:delete',
+                                'actor:read', 'actor:write', 'actor:delete', 'user:read');
@@ -0,0 +1,3 @@
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'USER' AND permission.permission = 'user:read';
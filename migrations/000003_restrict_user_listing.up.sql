@@ -0,0 +1,3 @@
+DELETE FROM role_permission
+WHERE role_id = (SELECT id FROM role WHERE role = 'USER')
+  AND permission_id = (SELECT id FROM permission WHERE permission = 'user:read');
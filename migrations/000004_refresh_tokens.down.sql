@@ -0,0 +1,4 @@
+DROP TABLE revoked_token;
+DROP TABLE refresh_token;
+
+ALTER TABLE _user DROP COLUMN tokens_valid_after;
@@ -0,0 +1,17 @@
+ALTER TABLE _user ADD COLUMN tokens_valid_after TIMESTAMPTZ NOT NULL DEFAULT to_timestamp(0);
+
+CREATE TABLE refresh_token (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES _user(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX refresh_token_user_id_idx ON refresh_token (user_id);
+
+CREATE TABLE revoked_token (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
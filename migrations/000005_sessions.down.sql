@@ -0,0 +1,3 @@
+ALTER TABLE refresh_token DROP COLUMN session_id;
+
+DROP TABLE session;
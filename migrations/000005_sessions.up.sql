@@ -0,0 +1,19 @@
+CREATE TABLE session (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES _user(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX session_user_id_idx ON session (user_id);
+
+-- refresh tokens issued before sessions existed can not be attributed to a device
+DELETE FROM refresh_token;
+
+ALTER TABLE refresh_token ADD COLUMN session_id INT NOT NULL
+    REFERENCES session(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE INDEX refresh_token_session_id_idx ON refresh_token (session_id);
@@ -0,0 +1,3 @@
+DROP TABLE invite;
+
+ALTER TABLE _user DROP COLUMN status;
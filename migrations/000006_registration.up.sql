@@ -0,0 +1,12 @@
+ALTER TABLE _user ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CONSTRAINT _user_status_check CHECK (status IN ('active', 'pending'));
+
+CREATE TABLE invite (
+    id SERIAL PRIMARY KEY,
+    code_hash TEXT UNIQUE NOT NULL,
+    created_by INT REFERENCES _user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_by INT REFERENCES _user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    used_at TIMESTAMPTZ
+);
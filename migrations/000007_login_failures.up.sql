@@ -0,0 +1,8 @@
+CREATE TABLE login_failure (
+    kind TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (kind, subject)
+);
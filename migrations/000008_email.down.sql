@@ -0,0 +1,4 @@
+DROP TABLE user_token;
+
+ALTER TABLE _user DROP COLUMN email_verified_at;
+ALTER TABLE _user DROP COLUMN email;
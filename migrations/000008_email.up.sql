@@ -0,0 +1,14 @@
+ALTER TABLE _user ADD COLUMN email TEXT UNIQUE;
+ALTER TABLE _user ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+CREATE TABLE user_token (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES _user(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    token_hash TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX user_token_user_id_idx ON user_token (user_id);
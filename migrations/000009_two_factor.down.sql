@@ -0,0 +1,7 @@
+DELETE FROM user_token WHERE purpose = 'two_factor';
+ALTER TABLE user_token DROP CONSTRAINT user_token_purpose_check;
+ALTER TABLE user_token ADD CONSTRAINT user_token_purpose_check
+    CHECK (purpose IN ('password_reset', 'email_verification'));
+
+DROP TABLE recovery_code;
+DROP TABLE two_factor;
@@ -0,0 +1,19 @@
+CREATE TABLE two_factor (
+    user_id INT PRIMARY KEY REFERENCES _user(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    enabled_at TIMESTAMPTZ,
+    last_used_step BIGINT
+);
+
+CREATE TABLE recovery_code (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES _user(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    UNIQUE (user_id, code_hash)
+);
+
+ALTER TABLE user_token DROP CONSTRAINT user_token_purpose_check;
+ALTER TABLE user_token ADD CONSTRAINT user_token_purpose_check
+    CHECK (purpose IN ('password_reset', 'email_verification', 'two_factor'));
@@ -0,0 +1,4 @@
+DROP TABLE api_key_permission;
+DROP TABLE api_key;
+
+DELETE FROM permission WHERE permission IN ('apikey:read', 'apikey:write');
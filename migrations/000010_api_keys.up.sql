@@ -0,0 +1,26 @@
+CREATE TABLE api_key (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    created_by INT REFERENCES _user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE api_key_permission (
+    api_key_id INT NOT NULL REFERENCES api_key(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permission(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (api_key_id, permission_id)
+);
+
+INSERT INTO permission (permission) VALUES
+('apikey:read'),
+('apikey:write');
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'ADMIN'
+  AND permission.permission IN ('apikey:read', 'apikey:write');
@@ -0,0 +1,3 @@
+DELETE FROM permission WHERE permission = 'user:impersonate';
+
+DROP TABLE impersonation;
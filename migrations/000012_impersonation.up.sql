@@ -0,0 +1,16 @@
+CREATE TABLE impersonation (
+    id SERIAL PRIMARY KEY,
+    actor_id INT REFERENCES _user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    subject_id INT REFERENCES _user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO permission (permission) VALUES
+('user:impersonate');
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'ADMIN'
+  AND permission.permission = 'user:impersonate';
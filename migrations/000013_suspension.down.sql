@@ -0,0 +1,10 @@
+ALTER TABLE _user
+    DROP COLUMN suspension_reason,
+    DROP COLUMN suspended_until,
+    DROP COLUMN suspended_by,
+    DROP COLUMN suspended_at;
+
+UPDATE _user SET status = 'active' WHERE status IN ('suspended', 'banned');
+
+ALTER TABLE _user DROP CONSTRAINT _user_status_check;
+ALTER TABLE _user ADD CONSTRAINT _user_status_check CHECK (status IN ('active', 'pending'));
@@ -0,0 +1,9 @@
+ALTER TABLE _user DROP CONSTRAINT _user_status_check;
+ALTER TABLE _user ADD CONSTRAINT _user_status_check
+    CHECK (status IN ('active', 'pending', 'suspended', 'banned'));
+
+ALTER TABLE _user
+    ADD COLUMN suspension_reason TEXT,
+    ADD COLUMN suspended_until TIMESTAMPTZ,
+    ADD COLUMN suspended_by INT REFERENCES _user(id) ON UPDATE CASCADE ON DELETE SET NULL,
+    ADD COLUMN suspended_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+DELETE FROM role WHERE role = 'ANONYMOUS';
@@ -0,0 +1,7 @@
+INSERT INTO role (role) VALUES
+('ANONYMOUS');
+
+INSERT INTO role_permission (role_id, permission_id)
+SELECT role.id, permission.id FROM role, permission
+WHERE role.role = 'ANONYMOUS'
+  AND permission.permission IN ('film:read', 'actor:read');
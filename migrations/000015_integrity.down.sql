@@ -0,0 +1,9 @@
+ALTER TABLE actor DROP CONSTRAINT actor_sex_fkey;
+DROP TABLE sex;
+
+ALTER TABLE actor_film DROP CONSTRAINT actor_film_actor_id_film_id_key;
+
+ALTER TABLE film
+    DROP CONSTRAINT film_name_length_check,
+    DROP CONSTRAINT film_description_length_check,
+    DROP CONSTRAINT film_rating_check;
@@ -0,0 +1,35 @@
+ALTER TABLE film
+    ADD CONSTRAINT film_name_length_check CHECK (char_length(name) BETWEEN 1 AND 150),
+    ADD CONSTRAINT film_description_length_check CHECK (char_length(description) <= 1000),
+    ADD CONSTRAINT film_rating_check CHECK (rating BETWEEN 0 AND 10);
+
+DELETE FROM actor_film a USING actor_film b
+    WHERE a.id > b.id AND a.actor_id = b.actor_id AND a.film_id = b.film_id;
+ALTER TABLE actor_film ADD CONSTRAINT actor_film_actor_id_film_id_key UNIQUE (actor_id, film_id);
+
+CREATE TABLE sex (
+    sex TEXT PRIMARY KEY
+);
+
+INSERT INTO sex (sex) VALUES
+('male'),
+('female');
+
+-- map actors stored before sex was checked, anything else has to be fixed by hand
+UPDATE actor SET sex = CASE
+    WHEN lower(trim(sex)) IN ('male', 'm', 'man') THEN 'male'
+    WHEN lower(trim(sex)) IN ('female', 'f', 'woman') THEN 'female'
+    ELSE sex
+END;
+
+DO $$
+DECLARE
+    unknown TEXT;
+BEGIN
+    SELECT string_agg(DISTINCT sex, ', ') INTO unknown FROM actor WHERE sex NOT IN ('male', 'female');
+    IF unknown IS NOT NULL THEN
+        RAISE EXCEPTION 'actors have an unknown sex: %, set it to male or female and migrate again', unknown;
+    END IF;
+END $$;
+
+ALTER TABLE actor ADD CONSTRAINT actor_sex_fkey FOREIGN KEY (sex) REFERENCES sex(sex) ON UPDATE CASCADE;
@@ -0,0 +1,11 @@
+DROP TRIGGER actor_film_version ON actor_film;
+DROP FUNCTION bump_actor_film_version();
+
+DROP TRIGGER film_version ON film;
+DROP TRIGGER actor_version ON actor;
+DROP TRIGGER _user_version ON _user;
+DROP FUNCTION bump_version();
+
+ALTER TABLE film DROP COLUMN version;
+ALTER TABLE actor DROP COLUMN version;
+ALTER TABLE _user DROP COLUMN version;
@@ -0,0 +1,34 @@
+ALTER TABLE film ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE actor ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE _user ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- every update makes a new version, whichever query it comes from
+CREATE FUNCTION bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER film_version BEFORE UPDATE ON film FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER actor_version BEFORE UPDATE ON actor FOR EACH ROW EXECUTE FUNCTION bump_version();
+CREATE TRIGGER _user_version BEFORE UPDATE ON _user FOR EACH ROW EXECUTE FUNCTION bump_version();
+
+-- films list their actors and actors their films, so a link changes both
+CREATE FUNCTION bump_actor_film_version() RETURNS trigger AS $$
+DECLARE
+    link actor_film;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        link := OLD;
+    ELSE
+        link := NEW;
+    END IF;
+    UPDATE film SET version = version + 1 WHERE id = link.film_id;
+    UPDATE actor SET version = version + 1 WHERE id = link.actor_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER actor_film_version AFTER INSERT OR DELETE ON actor_film
+    FOR EACH ROW EXECUTE FUNCTION bump_actor_film_version();
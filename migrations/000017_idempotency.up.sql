@@ -0,0 +1,14 @@
+-- responses to requests made with an Idempotency-Key, replayed when the
+-- client retries. status is NULL while the first request is processed
+CREATE TABLE idempotent_request (
+    owner TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status INT,
+    content_type TEXT,
+    location TEXT,
+    body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (owner, idempotency_key)
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER _user_version ON _user;
+
+CREATE TRIGGER _user_version BEFORE UPDATE ON _user FOR EACH ROW EXECUTE FUNCTION bump_version();
@@ -0,0 +1,11 @@
+-- only changes of the user as the API shows it make a new version, not the
+-- bookkeeping of logins, password rehashes or token revocation
+DROP TRIGGER _user_version ON _user;
+
+CREATE TRIGGER _user_version BEFORE UPDATE ON _user FOR EACH ROW
+    WHEN ((OLD.username, OLD.role_id, OLD.status, OLD.email, OLD.suspension_reason,
+           OLD.suspended_until, OLD.suspended_by, OLD.suspended_at)
+          IS DISTINCT FROM
+          (NEW.username, NEW.role_id, NEW.status, NEW.email, NEW.suspension_reason,
+           NEW.suspended_until, NEW.suspended_by, NEW.suspended_at))
+    EXECUTE FUNCTION bump_version();
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+type AnonymousAuthenticator interface {
+	// AuthenticateAnonymous returns the claims of requests without credentials
+	AuthenticateAnonymous(ctx context.Context) (*Claims, error)
+}
+
+var (
+	anonymousAuthenticator AnonymousAuthenticator
+	anonymousLimiter       *RateLimiter
+)
+
+// SetAnonymousAccess enables requests without credentials on routes wrapped in
+// AnonymousReadPermission, limited per client IP by limiter
+func SetAnonymousAccess(authenticator AnonymousAuthenticator, limiter *RateLimiter) {
+	anonymousAuthenticator, anonymousLimiter = authenticator, limiter
+}
+
+// AnonymousReadPermission serves GET requests without a token or an api key
+// with the claims of the anonymous role. Everything else, writes included, is
+// handled by JWTAuthPermission
+func AnonymousReadPermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	authenticated := JWTAuthPermission(permission, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if anonymousAuthenticator == nil || !isAnonymousRead(r) {
+			authenticated(w, r)
+			return
+		}
+		log.Printf("Anonymous %s request on %s", r.Method, r.RequestURI)
+
+		if allowed, retryAfter := anonymousLimiter.Allow(remoteIp(r)); !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			WriteProblem(w, r, http.StatusTooManyRequests, "")
+			return
+		}
+
+		claims, err := anonymousAuthenticator.AuthenticateAnonymous(r.Context())
+		if err != nil {
+			log.Printf("Can not authenticate anonymous request: %s", err.Error())
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), claimsContextKey, claims))
+		// the client has to log in for what the anonymous role may not do
+		if err := ValidatePermissionJWT(w, r, permission); err != nil {
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func isAnonymousRead(r *http.Request) bool {
+	return (r.Method == "GET" || r.Method == "HEAD") &&
+		r.Header.Get("Authorization") == "" && r.Header.Get(ApiKeyHeader) == ""
+}
@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+const ApiKeyHeader = "X-API-Key"
+
+type ApiKeyAuthenticator interface {
+	// AuthenticateApiKey returns the claims of a valid key
+	AuthenticateApiKey(ctx context.Context, key string) (*Claims, error)
+}
+
+var apiKeyAuthenticator ApiKeyAuthenticator
+
+func SetApiKeyAuthenticator(authenticator ApiKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+func WithClaimsApiKey(r *http.Request) (*http.Request, error) {
+	if apiKeyAuthenticator == nil {
+		return r, errors.New("api keys are not enabled")
+	}
+	claims, err := apiKeyAuthenticator.AuthenticateApiKey(r.Context(), r.Header.Get(ApiKeyHeader))
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey, claims)), nil
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DeadlineConfig bounds the time a request may take, its database queries
+// included. GET and HEAD requests are reads, all others are writes
+type DeadlineConfig struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+func (c DeadlineConfig) Validate() error {
+	if c.Read <= 0 || c.Write <= 0 {
+		return errors.New("read and write deadlines must be positive")
+	}
+	// the server drops the connection after its write timeout
+	if c.Read >= serverTimeout || c.Write >= serverTimeout {
+		return errors.New("deadlines must be shorter than the server timeout " + serverTimeout.String())
+	}
+	return nil
+}
+
+// Deadline cancels the context of requests running past the deadline of
+// their operation, which aborts their queries, and answers 503
+func Deadline(config DeadlineConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := config.Write
+		if r.Method == "GET" || r.Method == "HEAD" {
+			timeout = config.Read
+		}
+		problem, _ := json.Marshal(newProblem(r, http.StatusServiceUnavailable, "Request timed out"))
+		http.TimeoutHandler(next, timeout, string(problem)).ServeHTTP(&timeoutWriter{w}, r)
+	})
+}
+
+// timeoutWriter marks the timeout answer of http.TimeoutHandler, written
+// without a content type, as a problem
+type timeoutWriter struct {
+	http.ResponseWriter
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/problem+json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,261 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedMediaType is returned for a body that is not sent as JSON
+var ErrUnsupportedMediaType = errors.New("Content-Type must be application/json")
+
+// BodyLimitConfig bounds the size of request bodies
+type BodyLimitConfig struct {
+	MaxBytes int64
+}
+
+func (c BodyLimitConfig) Validate() error {
+	if c.MaxBytes <= 0 {
+		return errors.New("max body size must be positive")
+	}
+	return nil
+}
+
+// LimitBody fails reads past the size limit of request bodies, such requests
+// are answered with 413
+func LimitBody(config BodyLimitConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, config.MaxBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DecodeJSON decodes the JSON body of r into v, see UnmarshalJSON. A body sent
+// with another Content-Type than application/json is refused, a body without
+// one is taken for JSON
+func DecodeJSON(r *http.Request, v any) error {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			return ErrUnsupportedMediaType
+		}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return UnmarshalJSON(body, v)
+}
+
+// UnmarshalJSON decodes a single JSON value into v. Members v has no field for
+// and values of the wrong type are refused with a validation error naming them
+// by their path, like operations[1].film.name
+func UnmarshalJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return malformed(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("Request body must contain a single JSON value")
+	}
+
+	var fields []entity.FieldError
+	checkValue("", value, reflect.TypeOf(v), reflect.StructField{}, &fields)
+	if len(fields) == 1 && fields[0].Field == "" {
+		return errors.New("Request body " + fields[0].Message)
+	}
+	if len(fields) != 0 {
+		return &entity.ValidationError{Message: "Invalid request", Fields: fields}
+	}
+	return json.Unmarshal(data, v)
+}
+
+func malformed(err error) error {
+	var syntax *json.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("Request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("Malformed JSON: unexpected end of body")
+	case errors.As(err, &syntax):
+		return fmt.Errorf("Malformed JSON at byte %d: %s", syntax.Offset, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return err
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	jsonNumberType  = reflect.TypeOf(json.Number(""))
+	byteSliceType   = reflect.TypeOf([]byte{})
+)
+
+// checkValue compares value, decoded with UseNumber, with the type of field
+// it is decoded into and adds a field error for every mismatch below path
+func checkValue(path string, value any, t reflect.Type, field reflect.StructField, fields *[]entity.FieldError) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	invalid := func(message string) {
+		*fields = append(*fields, entity.FieldError{Field: path, Message: message})
+	}
+
+	switch {
+	case t == timeType:
+		// dates are strings in the layout of the time tag, RFC 3339 by default
+		layout := field.Tag.Get("time")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		s, ok := value.(string)
+		if value == nil {
+			invalid("must not be null")
+		} else if _, err := time.Parse(layout, s); !ok || err != nil {
+			invalid("must be a date like " + layout)
+		}
+		return
+	case value == nil, t == rawMessageType, t == jsonNumberType, t.Kind() == reflect.Interface:
+		return
+	case t.Kind() != reflect.Struct && reflect.PointerTo(t).Implements(unmarshalerType):
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			invalid("must be an object")
+			return
+		}
+		checkObject(path, object, t, fields)
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			invalid("must be an object")
+			return
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			checkValue(join(path, key), object[key], t.Elem(), reflect.StructField{}, fields)
+		}
+	case reflect.Slice, reflect.Array:
+		if t == byteSliceType {
+			if _, ok := value.(string); !ok {
+				invalid("must be a string")
+			}
+			return
+		}
+		array, ok := value.([]any)
+		if !ok {
+			invalid("must be an array")
+			return
+		}
+		for i, element := range array {
+			checkValue(path+"["+strconv.Itoa(i)+"]", element, t.Elem(), field, fields)
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			invalid("must be a string")
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			invalid("must be a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := value.(json.Number)
+		if _, err := strconv.ParseInt(number.String(), 10, t.Bits()); !ok || err != nil {
+			invalid("must be an integer")
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, ok := value.(json.Number)
+		if _, err := strconv.ParseUint(number.String(), 10, t.Bits()); !ok || err != nil {
+			invalid("must be a non-negative integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			invalid("must be a number")
+		}
+	}
+}
+
+// checkObject checks the members of object in the order of the fields of t,
+// members t has no field for are listed last
+func checkObject(path string, object map[string]any, t reflect.Type, fields *[]entity.FieldError) {
+	known := map[string]bool{}
+	for _, field := range jsonFields(t) {
+		name := jsonFieldName(field)
+		known[name] = true
+		if value, ok := object[name]; ok {
+			checkValue(join(path, name), value, field.Type, field, fields)
+		}
+	}
+
+	var unknown []string
+	for name := range object {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		*fields = append(*fields, entity.FieldError{Field: join(path, name), Message: "is not a known field"})
+	}
+}
+
+// jsonFields lists the fields of t encoding/json decodes, those of embedded
+// structs included
+func jsonFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && embedded.Kind() == reflect.Struct && strings.Split(tag, ",")[0] == "" {
+			fields = append(fields, jsonFields(embedded)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,167 @@
+package pkg
+
+import (
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"github.com/go-playground/assert/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decodeFilm struct {
+	Name        *string    `json:"name"`
+	ReleaseDate *time.Time `json:"releaseDate" time:"2006-01-02"`
+	Rating      *int       `json:"rating"`
+	ActorsId    *[]int     `json:"actorsId"`
+}
+
+type decodeBatch struct {
+	Mode       string `json:"mode"`
+	Operations []struct {
+		Op   string     `json:"op"`
+		Film decodeFilm `json:"film"`
+	} `json:"operations"`
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedFields []entity.FieldError
+		expectedError  string
+	}{
+		{
+			name: "Ok",
+			body: `{"mode": "atomic", "operations": [{"op": "create", "film": {"name": "name", "rating": 5, "actorsId": [1]}}]}`,
+		},
+		{
+			name: "Unknown fields",
+			body: `{"mode": "atomic", "operations": [{"op": "create", "film": {"name": "name", "actorIds": [1]}}], "dryRun": true}`,
+			expectedFields: []entity.FieldError{
+				{Field: "operations[0].film.actorIds", Message: "is not a known field"},
+				{Field: "dryRun", Message: "is not a known field"},
+			},
+		},
+		{
+			name: "Wrong types",
+			body: `{"mode": 1, "operations": [{"op": "create"}, {"film": {"name": ["name"], "rating": 5.5, "actorsId": [1, "2"]}}]}`,
+			expectedFields: []entity.FieldError{
+				{Field: "mode", Message: "must be a string"},
+				{Field: "operations[1].film.name", Message: "must be a string"},
+				{Field: "operations[1].film.rating", Message: "must be an integer"},
+				{Field: "operations[1].film.actorsId[1]", Message: "must be an integer"},
+			},
+		},
+		{
+			name: "Null date",
+			body: `{"operations": [{"film": {"releaseDate": null}}]}`,
+			expectedFields: []entity.FieldError{
+				{Field: "operations[0].film.releaseDate", Message: "must not be null"},
+			},
+		},
+		{
+			name: "Invalid date",
+			body: `{"operations": [{"film": {"releaseDate": "12.10.2021"}}]}`,
+			expectedFields: []entity.FieldError{
+				{Field: "operations[0].film.releaseDate", Message: "must be a date like 2006-01-02"},
+			},
+		},
+		{
+			name:          "Not an object",
+			body:          `[]`,
+			expectedError: "Request body must be an object",
+		},
+		{
+			name:          "Malformed",
+			body:          `{"mode": "atomic",}`,
+			expectedError: "Malformed JSON at byte 19: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:          "Truncated",
+			body:          `{"mode": "atomic"`,
+			expectedError: "Malformed JSON: unexpected end of body",
+		},
+		{
+			name:          "Trailing value",
+			body:          `{"mode": "atomic"} {}`,
+			expectedError: "Request body must contain a single JSON value",
+		},
+		{
+			name:          "Empty",
+			body:          ``,
+			expectedError: "Request body is empty",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var batch decodeBatch
+			err := UnmarshalJSON([]byte(test.body), &batch)
+
+			var validation *entity.ValidationError
+			switch {
+			case test.expectedFields != nil:
+				assert.Equal(t, errors.As(err, &validation), true)
+				assert.Equal(t, validation.Fields, test.expectedFields)
+			case test.expectedError != "":
+				assert.Equal(t, errors.As(err, &validation), false)
+				assert.Equal(t, err.Error(), test.expectedError)
+			default:
+				assert.Equal(t, err, nil)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "Ok",
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"name": "name"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Without content type",
+			body:           `{"name": "name"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Form",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           `name=name`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Too large",
+			body:           `{"name": "` + strings.Repeat("n", 64) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := LimitBody(BodyLimitConfig{MaxBytes: 64}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var film decodeFilm
+				if err := DecodeJSON(r, &film); err != nil {
+					HandleError(w, r, err, http.StatusBadRequest)
+				}
+			}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/film", strings.NewReader(test.body))
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, w.Code, test.expectedStatus)
+		})
+	}
+}
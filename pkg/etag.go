@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrIfMatchRequired is returned for an unconditional write while If-Match is
+// required, see RequireIfMatch
+var ErrIfMatchRequired = errors.New("If-Match header is required")
+
+var ifMatchRequired bool
+
+// RequireIfMatch makes writes to versioned entities without If-Match fail,
+// so clients can not overwrite changes they have not seen
+func RequireIfMatch(required bool) {
+	ifMatchRequired = required
+}
+
+// ETag of an entity version
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatch returns the version a write is conditional on: 0 for any version,
+// without If-Match or with *, and -1, which no version has, for tags other
+// than a single strong ETag
+func IfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch header {
+	case "":
+		if ifMatchRequired {
+			return 0, ErrIfMatchRequired
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`))
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) {
+		return -1, nil
+	}
+	return version, nil
+}
+
+// NotModified sets the ETag of version and answers 304 if the client already
+// has it, as told by If-None-Match
+func NotModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	etag := ETag(version)
+	w.Header().Set("ETag", etag)
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatchRequired tells if writes must be conditional, see RequireIfMatch
+func IfMatchRequired() bool {
+	return ifMatchRequired
+}
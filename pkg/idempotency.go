@@ -0,0 +1,20 @@
+package pkg
+
+import (
+	"errors"
+	"net/http"
+)
+
+// maxIdempotencyKeyLength bounds keys sent by clients, a UUID is enough
+const maxIdempotencyKeyLength = 255
+
+var ErrInvalidIdempotencyKey = errors.New("Invalid Idempotency-Key")
+
+// IdempotencyKey returns the Idempotency-Key of r, empty if none is sent
+func IdempotencyKey(r *http.Request) (string, error) {
+	key := r.Header.Get("Idempotency-Key")
+	if len(key) > maxIdempotencyKeyLength || !printable(key) {
+		return "", ErrInvalidIdempotencyKey
+	}
+	return key, nil
+}
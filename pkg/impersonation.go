@@ -0,0 +1,42 @@
+package pkg
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// ImpersonationHeader marks responses to requests made with an impersonation
+// token, its value is the id of the acting admin
+const ImpersonationHeader = "X-Impersonated-By"
+
+var ErrImpersonationDenied = errors.New("Not allowed while impersonating a user")
+
+func IsImpersonated(r *http.Request) bool {
+	claims, err := GetClaims(r)
+	return err == nil && claims.ActorId != 0
+}
+
+// DenyImpersonation guards operations only the user may do, like changing
+// credentials, from impersonation tokens
+func DenyImpersonation(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if IsImpersonated(r) {
+			HandleError(w, r, ErrImpersonationDenied, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// auditImpersonation marks and logs every request made with an impersonation token
+func auditImpersonation(w http.ResponseWriter, r *http.Request) {
+	claims, err := GetClaims(r)
+	if err != nil || claims.ActorId == 0 {
+		return
+	}
+	w.Header().Set(ImpersonationHeader, strconv.Itoa(claims.ActorId))
+	log.Printf("Impersonation: user %d acting as user %d, token %s: %s %s",
+		claims.ActorId, claims.Id, claims.ID, r.Method, r.RequestURI)
+}
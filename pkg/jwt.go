@@ -1,30 +1,105 @@
 package pkg
 
 import (
+	"context"
 	"errors"
 	"filmLibraryVk/internal/model/entity"
-	"fmt"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 	"log"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 )
 
-var privateKey = []byte(os.Getenv("JWT_PRIVATE_KEY"))
+type contextKey string
 
-func GenerateJWT(user entity.User) (string, error) {
-	expiration, _ := strconv.Atoi(os.Getenv("JWT_EXPIRATION"))
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":   user.Id,
-		"role": user.RoleId,
-		"iat":  time.Now().Unix(),
-		"eat":  time.Now().Add(time.Second * time.Duration(expiration)).Unix(),
-	})
-	return token.SignedString(privateKey)
+const claimsContextKey contextKey = "claims"
+
+type Claims struct {
+	Id          int      `json:"id"`
+	Role        int      `json:"role"`
+	SessionId   int      `json:"sid"`
+	Permissions []string `json:"permissions"`
+	// set instead of Id when the request is authenticated with an api key
+	ApiKeyId int `json:"-"`
+	// the admin acting as user Id with an impersonation token
+	ActorId int `json:"actor,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenConfig sets the lifetime of access and refresh tokens
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+func (c TokenConfig) Validate() error {
+	if c.AccessTTL <= 0 || c.RefreshTTL <= 0 {
+		return errors.New("access and refresh token lifetimes must be positive")
+	}
+	return nil
+}
+
+var tokenConfig TokenConfig
+
+// SetTokenConfig sets the token lifetimes, read once at startup
+func SetTokenConfig(config TokenConfig) {
+	tokenConfig = config
+}
+
+// AccessTokenTTL is the lifetime of tokens issued by GenerateJWT
+func AccessTokenTTL() time.Duration {
+	return tokenConfig.AccessTTL
+}
+
+// RefreshTokenTTL is the lifetime of refresh tokens
+func RefreshTokenTTL() time.Duration {
+	return tokenConfig.RefreshTTL
+}
+
+func GenerateJWT(user entity.User, sessionId int, permissions []string) (string, error) {
+	if tokenConfig.AccessTTL <= 0 {
+		return "", errors.New("access token lifetime is not configured")
+	}
+	return signJWT(Claims{
+		Id:          user.Id,
+		Role:        user.RoleId,
+		SessionId:   sessionId,
+		Permissions: permissions,
+	}, tokenConfig.AccessTTL)
+}
+
+// GenerateImpersonationJWT issues a token acting as user for actorId. It is
+// bound to the session of the actor, so it ends when the actor logs out
+func GenerateImpersonationJWT(user entity.User, actorId, actorSessionId int, permissions []string,
+	ttl time.Duration) (string, error) {
+	return signJWT(Claims{
+		Id:          user.Id,
+		Role:        user.RoleId,
+		SessionId:   actorSessionId,
+		Permissions: permissions,
+		ActorId:     actorId,
+	}, ttl)
+}
+
+func signJWT(claims Claims, ttl time.Duration) (string, error) {
+	if signingKey == nil {
+		return "", errors.New("signing key is not configured")
+	}
+	method, err := signingMethod(signingKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        GenerateTokenId(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = signingKey.Kid
+	return token.SignedString(signingKey.PrivateKey)
 }
 
 func ValidateJWT(w http.ResponseWriter, r *http.Request) error {
@@ -32,44 +107,65 @@ func ValidateJWT(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	_, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return errors.New("invalid token provided")
+	}
+	if revocationList == nil {
 		return nil
 	}
-	return errors.New("invalid token provided")
-}
-
-func ValidateAdminRoleJWT(w http.ResponseWriter, r *http.Request) error {
-	token, err := getToken(w, r)
+	revoked, err := revocationList.IsTokenRevoked(r.Context(), claims.ID, claims.Id, claims.SessionId, claims.IssuedAt.Time)
 	if err != nil {
 		return err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	userRole := uint(claims["role"].(float64))
-	if ok && token.Valid && userRole == 1 {
+	if revoked {
+		return errors.New("token has been revoked")
+	}
+	return nil
+}
+
+// ValidatePermissionJWT checks the claims stored by the auth middleware, so
+// it works for both bearer tokens and api keys
+func ValidatePermissionJWT(w http.ResponseWriter, r *http.Request, permission string) error {
+	claims, err := GetClaims(r)
+	if err == nil && hasPermission(claims, permission) {
 		return nil
 	}
 	log.Printf("Forbidden")
-	return errors.New("invalid admin token provided")
+	return errors.New("token does not grant " + permission + " permission")
 }
 
-func ValidateUserRoleJWT(w http.ResponseWriter, r *http.Request) error {
+func WithClaimsJWT(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
 	token, err := getToken(w, r)
 	if err != nil {
-		return err
+		return r, err
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	userRole := uint(claims["role"].(float64))
-	if ok && token.Valid && userRole == 2 || userRole == 1 {
-		return nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return r, errors.New("invalid token provided")
 	}
-	log.Printf("Forbidden")
-	return errors.New("invalid author token provided")
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey, claims)), nil
+}
+
+func GetClaims(r *http.Request) (*Claims, error) {
+	claims, ok := r.Context().Value(claimsContextKey).(*Claims)
+	if !ok {
+		return nil, errors.New("request is not authenticated")
+	}
+	return claims, nil
+}
+
+func GetUserId(r *http.Request) (int, error) {
+	claims, err := GetClaims(r)
+	if err != nil {
+		return 0, err
+	}
+	return claims.Id, nil
 }
 
 func MockValidateJWT(w http.ResponseWriter, r *http.Request) error {
 	tokenString := getTokenFromRequest(w, r)
-	if tokenString == "ADMIN" || tokenString == "USER" {
+	if tokenString == "ADMIN" || tokenString == "USER" || tokenString == "IMPERSONATED" {
 		return nil
 	}
 	return errors.New("invalid token provided")
@@ -86,22 +182,60 @@ func MockValidateAdminRoleJWT(w http.ResponseWriter, r *http.Request) error {
 
 func MockValidateUserRoleJWT(w http.ResponseWriter, r *http.Request) error {
 	tokenString := getTokenFromRequest(w, r)
-	if tokenString == "USER" || tokenString == "ADMIN" {
+	if tokenString == "USER" || tokenString == "ADMIN" || tokenString == "IMPERSONATED" {
 		return nil
 	}
 	log.Printf("Forbidden")
 	return errors.New("invalid author token provided")
 }
 
-func getToken(w http.ResponseWriter, r *http.Request) (*jwt.Token, error) {
+func MockValidatePermissionJWT(w http.ResponseWriter, r *http.Request, permission string) error {
 	tokenString := getTokenFromRequest(w, r)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	if tokenString == "ADMIN" {
+		return nil
+	}
+	if (tokenString == "USER" || tokenString == "IMPERSONATED") && (permission == entity.PermissionFilmRead ||
+		permission == entity.PermissionActorRead) {
+		return nil
+	}
+	log.Printf("Forbidden")
+	return errors.New("token does not grant " + permission + " permission")
+}
+
+func MockWithClaimsJWT(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		ID:        "mock",
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	switch getTokenFromRequest(w, r) {
+	case "ADMIN":
+		claims.Id, claims.Role, claims.SessionId = 1, 1, 1
+	case "USER":
+		claims.Id, claims.Role, claims.SessionId = 2, 2, 2
+	case "IMPERSONATED":
+		// ADMIN acting as USER
+		claims.Id, claims.Role, claims.SessionId, claims.ActorId = 2, 2, 1, 1
+	default:
+		return r, errors.New("invalid token provided")
+	}
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey, claims)), nil
+}
+
+func hasPermission(claims *Claims, permission string) bool {
+	for _, p := range claims.Permissions {
+		if p == permission {
+			return true
 		}
+	}
+	return false
+}
 
-		return privateKey, nil
-	})
+func getToken(w http.ResponseWriter, r *http.Request) (*jwt.Token, error) {
+	tokenString := getTokenFromRequest(w, r)
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired())
 	return token, err
 }
 
@@ -113,15 +247,3 @@ func getTokenFromRequest(w http.ResponseWriter, r *http.Request) string {
 	}
 	return ""
 }
-
-func EncodePassword(password string) (string, error) {
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(passwordHash), nil
-}
-
-func ComparePasswords(hashPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password))
-}
@@ -5,19 +5,47 @@ import (
 	"net/http"
 )
 
-func JWTAuthAdmin(next http.HandlerFunc) http.HandlerFunc {
+// JWTAuthPermission accepts a bearer token or an api key in the X-API-Key header
+func JWTAuthPermission(permission string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("%s request on %s", r.Method, r.RequestURI)
 
+		if r.Header.Get(ApiKeyHeader) != "" {
+			ApiKeyAuthPermission(permission, next)(w, r)
+			return
+		}
+
 		err := ValidateJWT(w, r)
 		if err != nil {
 			log.Printf("Invalid JWT token")
-			http.Error(w, "Invalid JWT token", http.StatusUnauthorized)
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
 			return
 		}
-		error := ValidateAdminRoleJWT(w, r)
+		r, err = WithClaimsJWT(w, r)
+		if err != nil {
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
+			return
+		}
+		auditImpersonation(w, r)
+		error := ValidatePermissionJWT(w, r, permission)
 		if error != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func ApiKeyAuthPermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, err := WithClaimsApiKey(r)
+		if err != nil {
+			log.Printf("Invalid API key: %s", err.Error())
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		if err := ValidatePermissionJWT(w, r, permission); err != nil {
+			WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -31,14 +59,15 @@ func JWTAuthUser(next http.HandlerFunc) http.HandlerFunc {
 		err := ValidateJWT(w, r)
 		if err != nil {
 			log.Printf("Invalid JWT token")
-			http.Error(w, "Invalid JWT token", http.StatusUnauthorized)
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
 			return
 		}
-		error := ValidateUserRoleJWT(w, r)
-		if error != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		r, err = WithClaimsJWT(w, r)
+		if err != nil {
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
 			return
 		}
+		auditImpersonation(w, r)
 		next.ServeHTTP(w, r)
 	}
 }
@@ -49,12 +78,14 @@ func MockJWTAuthAdmin(next http.HandlerFunc) http.HandlerFunc {
 		err := MockValidateJWT(w, r)
 		if err != nil {
 			log.Printf("Invalid JWT token")
-			http.Error(w, "Invalid JWT token", http.StatusUnauthorized)
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
 			return
 		}
+		r, _ = MockWithClaimsJWT(w, r)
+		auditImpersonation(w, r)
 		error := MockValidateAdminRoleJWT(w, r)
 		if error != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -67,14 +98,36 @@ func MockJWTAuthUser(next http.HandlerFunc) http.HandlerFunc {
 		err := MockValidateJWT(w, r)
 		if err != nil {
 			log.Printf("Invalid JWT token")
-			http.Error(w, "Invalid JWT token", http.StatusUnauthorized)
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
 			return
 		}
+		r, _ = MockWithClaimsJWT(w, r)
+		auditImpersonation(w, r)
 		error := MockValidateUserRoleJWT(w, r)
 		if error != nil {
-			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			WriteProblem(w, r, http.StatusForbidden, "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+func MockJWTAuthPermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s request on %s", r.Method, r.RequestURI)
+		err := MockValidateJWT(w, r)
+		if err != nil {
+			log.Printf("Invalid JWT token")
+			WriteProblem(w, r, http.StatusUnauthorized, "Invalid JWT token")
+			return
+		}
+		r, _ = MockWithClaimsJWT(w, r)
+		auditImpersonation(w, r)
+		error := MockValidatePermissionJWT(w, r, permission)
+		if error != nil {
+			WriteProblem(w, r, http.StatusForbidden, "")
 			return
 		}
 		next.ServeHTTP(w, r)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"sort"
+)
+
+// KeyConfig locates a key in PEM files. The private key file can be named by
+// an environment variable instead, so the key is kept out of the config
+type KeyConfig struct {
+	Kid               string `mapstructure:"kid"`
+	PrivateKeyFile    string `mapstructure:"private_key_file"`
+	PrivateKeyFileEnv string `mapstructure:"private_key_file_env"`
+	PublicKeyFile     string `mapstructure:"public_key_file"`
+}
+
+type SigningKey struct {
+	Kid        string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var signingKey *SigningKey
+var verificationKeys = make(map[string]SigningKey)
+
+func LoadKeys(signingKid string, configs []KeyConfig) error {
+	keys := make([]SigningKey, 0, len(configs))
+	for _, config := range configs {
+		key, err := loadKey(config)
+		if err != nil {
+			return fmt.Errorf("can not load key %q: %w", config.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	return SetKeys(signingKid, keys)
+}
+
+func SetKeys(signingKid string, keys []SigningKey) error {
+	verification := make(map[string]SigningKey, len(keys))
+	var signing *SigningKey
+
+	for i, key := range keys {
+		if key.Kid == "" {
+			return errors.New("key without kid")
+		}
+		if _, ok := verification[key.Kid]; ok {
+			return errors.New("duplicate kid " + key.Kid)
+		}
+		if _, err := signingMethod(key.PublicKey); err != nil {
+			return err
+		}
+		verification[key.Kid] = key
+		if key.Kid == signingKid {
+			signing = &keys[i]
+		}
+	}
+
+	if signing == nil {
+		return errors.New("signing key " + signingKid + " is not configured")
+	}
+	if signing.PrivateKey == nil {
+		return errors.New("signing key " + signingKid + " has no private key")
+	}
+
+	signingKey = signing
+	verificationKeys = verification
+	return nil
+}
+
+func JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(verificationKeys))}
+	for _, key := range verificationKeys {
+		switch publicKey := key.PublicKey.(type) {
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "OKP",
+				Kid: key.Kid,
+				Use: "sig",
+				Alg: jwt.SigningMethodEdDSA.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JSONWebKey{
+				Kty: "RSA",
+				Kid: key.Kid,
+				Use: "sig",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
+
+func signingMethod(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch publicKey.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", publicKey)
+	}
+}
+
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	method, err := signingMethod(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.PublicKey, nil
+}
+
+func loadKey(config KeyConfig) (SigningKey, error) {
+	key := SigningKey{Kid: config.Kid}
+	if config.PrivateKeyFileEnv != "" {
+		config.PrivateKeyFile = os.Getenv(config.PrivateKeyFileEnv)
+		if config.PrivateKeyFile == "" {
+			return SigningKey{}, errors.New(config.PrivateKeyFileEnv + " is not set")
+		}
+	}
+
+	switch {
+	case config.PrivateKeyFile != "":
+		block, err := readPEM(config.PrivateKeyFile)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		privateKey, err := parsePrivateKey(block)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		key.PrivateKey = privateKey
+		key.PublicKey = privateKey.Public()
+	case config.PublicKeyFile != "":
+		block, err := readPEM(config.PublicKeyFile)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		key.PublicKey = publicKey
+	default:
+		return SigningKey{}, errors.New("neither private_key_file, private_key_file_env nor public_key_file is set")
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(path + " is not a PEM file")
+	}
+	return block, nil
+}
+
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
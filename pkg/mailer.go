@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+type MailerConfig struct {
+	Driver       string
+	From         string
+	OutboxDir    string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+func NewMailer(config MailerConfig) (Mailer, error) {
+	from, err := netmail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail sender %q: %w", config.From, err)
+	}
+	switch config.Driver {
+	case "smtp":
+		if config.SMTPHost == "" {
+			return nil, errors.New("smtp host is not set")
+		}
+		return &SMTPMailer{
+			Addr:     net.JoinHostPort(config.SMTPHost, strconv.Itoa(config.SMTPPort)),
+			Host:     config.SMTPHost,
+			From:     config.From,
+			Sender:   from.Address,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+		}, nil
+	case "outbox":
+		if err := os.MkdirAll(config.OutboxDir, 0o750); err != nil {
+			return nil, err
+		}
+		return &OutboxMailer{Dir: config.OutboxDir, From: config.From}, nil
+	default:
+		return nil, errors.New("unknown mail driver " + config.Driver)
+	}
+}
+
+// SMTPMailer sends mail through an SMTP relay, authenticating when a username is set
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	From     string
+	Sender   string
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.Sender, []string{mail.To}, message(m.From, mail))
+}
+
+// OutboxMailer writes every mail to a file in Dir instead of sending it.
+// Meant for local development and tests
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *OutboxMailer) Send(mail Mail) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), randomToken(6))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, message(m.From, mail), 0o640); err != nil {
+		return err
+	}
+	log.Printf("Mail to %s written to %s", mail.To, path)
+	return nil
+}
+
+func message(from string, mail Mail) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(mail.Body)
+	return buf.Bytes()
+}
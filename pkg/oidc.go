@@ -0,0 +1,391 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDCConfig describes this application as a client registered at the
+// identity provider. ClientSecret is empty for a public client
+type OIDCConfig struct {
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	GroupsClaim  string
+}
+
+// OIDCIdentity is the user described by a validated ID token
+type OIDCIdentity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+	Groups            []string
+}
+
+type oidcDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JwksURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+const (
+	// signing keys are fetched again for an unknown kid at most this often
+	oidcKeysRefreshInterval = time.Minute
+	// tolerated clock difference to the identity provider
+	oidcLeeway = time.Minute
+	// upper bound of provider responses
+	oidcMaxResponseSize = 1 << 20
+)
+
+// OIDCClient logs users in with the authorization code flow and PKCE. The
+// provider configuration is discovered on first use, so the application
+// starts while the provider is unreachable
+type OIDCClient struct {
+	config     OIDCConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func NewOIDCClient(config OIDCConfig) (*OIDCClient, error) {
+	if config.Issuer == "" || config.ClientId == "" || config.RedirectURL == "" {
+		return nil, errors.New("oidc issuer, client id and redirect url must be set")
+	}
+	if !containsString(config.Scopes, "openid") {
+		return nil, errors.New("oidc scopes must include openid")
+	}
+	return &OIDCClient{config: config, httpClient: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func GenerateCodeVerifier() string {
+	return randomToken(32)
+}
+
+// CodeChallenge derives the S256 PKCE challenge of a verifier (RFC 7636)
+func CodeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// AuthCodeURL returns the provider page the user is redirected to
+func (c *OIDCClient) AuthCodeURL(state, nonce, codeVerifier string) (string, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.config.ClientId)
+	query.Set("redirect_uri", c.config.RedirectURL)
+	query.Set("scope", strings.Join(c.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the raw ID token
+func (c *OIDCClient) Exchange(code, codeVerifier string) (string, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", c.config.ClientId)
+
+	req, err := http.NewRequest("POST", discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.config.ClientId), url.QueryEscape(c.config.ClientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("can not decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IdToken == "" {
+		return "", errors.New("token response has no id_token")
+	}
+	return body.IdToken, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, lifetime and nonce
+// of an ID token (OpenID Connect Core 3.1.3.7)
+func (c *OIDCClient) VerifyIDToken(rawIDToken, nonce string) (OIDCIdentity, error) {
+	discovery, err := c.discover()
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, c.idTokenKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(),
+			jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(c.config.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcLeeway))
+	if err != nil {
+		return OIDCIdentity{}, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if azp, ok := claims["azp"].(string); ok && azp != c.config.ClientId {
+		return OIDCIdentity{}, errors.New("id token was issued to " + azp)
+	}
+	tokenNonce, _ := claims["nonce"].(string)
+	if tokenNonce == "" || subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return OIDCIdentity{}, errors.New("id token nonce does not match")
+	}
+
+	identity := OIDCIdentity{Issuer: discovery.Issuer}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Subject == "" {
+		return OIDCIdentity{}, errors.New("id token has no subject")
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified = claimBool(claims["email_verified"])
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	identity.Name, _ = claims["name"].(string)
+	if c.config.GroupsClaim != "" {
+		identity.Groups = claimStrings(claims[c.config.GroupsClaim])
+	}
+	return identity, nil
+}
+
+func (c *OIDCClient) discover() (*oidcDiscovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	err := c.getJSON(strings.TrimSuffix(c.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("can not discover oidc provider: %w", err)
+	}
+	if discovery.Issuer != c.config.Issuer {
+		return nil, fmt.Errorf("discovered issuer %q does not match %q", discovery.Issuer, c.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, errors.New("oidc provider configuration is incomplete")
+	}
+	if len(discovery.CodeChallengeMethods) > 0 && !containsString(discovery.CodeChallengeMethods, "S256") {
+		return nil, errors.New("oidc provider does not support S256 code challenges")
+	}
+
+	c.discovery = &discovery
+	return c.discovery, nil
+}
+
+func (c *OIDCClient) idTokenKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key, nil
+	}
+	// the provider may have rotated its keys
+	if time.Since(c.keysFetchedAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	c.keysFetchedAt = time.Now()
+
+	var set JSONWebKeySet
+	if err := c.getJSON(c.discovery.JwksURI, &set); err != nil {
+		return nil, fmt.Errorf("can not fetch oidc signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJSONWebKey(jwk)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	c.keys = keys
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// lookupKey accepts a token without kid when the provider has a single key
+func (c *OIDCClient) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func (c *OIDCClient) getJSON(url string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(v)
+}
+
+func parseJSONWebKey(jwk JSONWebKey) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent is too large")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, errors.New("unsupported curve " + jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("ec point is not on the curve")
+		}
+		return key, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("unsupported okp key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errors.New("unsupported key type " + jwk.Kty)
+	}
+}
+
+// claimBool accepts "true" as well, some providers send email_verified as a string
+func claimBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// claimStrings reads a claim holding a list of strings or a single string
+func claimStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,392 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	fakeClientId     = "film-library"
+	fakeClientSecret = "client-secret"
+	fakeRedirectURL  = "http://localhost:8080/api/auth/oidc/callback"
+)
+
+type fakeGrant struct {
+	challenge string
+	nonce     string
+}
+
+// fakeOIDCProvider is an in-process identity provider. Its authorize
+// endpoint logs the user in without asking and redirects back with a code
+type fakeOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+	// changes the claims of issued ID tokens
+	claims func(claims jwt.MapClaims)
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &fakeOIDCProvider{key: key, kid: "idp-1", grants: make(map[string]fakeGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/authorize", provider.authorize)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/jwks", provider.jwks)
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func (p *fakeOIDCProvider) client(t *testing.T) *OIDCClient {
+	client, err := NewOIDCClient(OIDCConfig{
+		Issuer:       p.server.URL,
+		ClientId:     fakeClientId,
+		ClientSecret: fakeClientSecret,
+		RedirectURL:  fakeRedirectURL,
+		Scopes:       []string{"openid", "profile", "email"},
+		GroupsClaim:  "groups",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func (p *fakeOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                           p.server.URL,
+		"authorization_endpoint":           p.server.URL + "/authorize",
+		"token_endpoint":                   p.server.URL + "/token",
+		"jwks_uri":                         p.server.URL + "/jwks",
+		"code_challenge_methods_supported": []string{"S256"},
+	})
+}
+
+func (p *fakeOIDCProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != fakeClientId || query.Get("redirect_uri") != fakeRedirectURL ||
+		query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomToken(16)
+	p.mu.Lock()
+	p.grants[code] = fakeGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	p.mu.Unlock()
+
+	http.Redirect(w, r, fakeRedirectURL+"?code="+code+"&state="+url.QueryEscape(query.Get("state")), http.StatusFound)
+}
+
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	clientId, secret, ok := r.BasicAuth()
+	if !ok || clientId != fakeClientId || secret != fakeClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	grant, ok := p.grants[r.PostFormValue("code")]
+	delete(p.grants, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != fakeRedirectURL ||
+		CodeChallenge(r.PostFormValue("code_verifier")) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.server.URL,
+		"aud":                fakeClientId,
+		"sub":                "248289761001",
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Minute).Unix(),
+		"nonce":              grant.nonce,
+		"email":              "jane@example.com",
+		"email_verified":     true,
+		"preferred_username": "jane",
+		"groups":             []string{"staff", "film-admins"},
+	}
+	if p.claims != nil {
+		p.claims(claims)
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     p.sign(claims, p.kid, p.key),
+	})
+}
+
+func (p *fakeOIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(JSONWebKeySet{Keys: []JSONWebKey{{
+		Kty: "RSA",
+		Kid: p.kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+func (p *fakeOIDCProvider) sign(claims jwt.MapClaims, kid string, key *rsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// login runs the browser part of the flow and returns the code and state
+// of the redirect back to the application
+func (p *fakeOIDCProvider) login(t *testing.T, authURL string) (string, string) {
+	noRedirect := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("provider did not redirect back, status %d", resp.StatusCode)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOIDCClient_login(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	client := provider.client(t)
+
+	verifier := GenerateCodeVerifier()
+	authURL, err := client.AuthCodeURL("state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, _ := url.Parse(authURL)
+	assert.Equal(t, query.Query().Get("code_challenge"), CodeChallenge(verifier))
+	assert.Equal(t, query.Query().Get("scope"), "openid profile email")
+
+	code, state := provider.login(t, authURL)
+	assert.Equal(t, state, "state-1")
+
+	rawIDToken, err := client.Exchange(code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := client.VerifyIDToken(rawIDToken, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, identity, OIDCIdentity{
+		Issuer:            provider.server.URL,
+		Subject:           "248289761001",
+		Email:             "jane@example.com",
+		EmailVerified:     true,
+		PreferredUsername: "jane",
+		Groups:            []string{"staff", "film-admins"},
+	})
+
+	// codes are single use
+	_, err = client.Exchange(code, verifier)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestOIDCClient_exchangeWrongVerifier(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	client := provider.client(t)
+
+	authURL, err := client.AuthCodeURL("state", "nonce", GenerateCodeVerifier())
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := provider.login(t, authURL)
+
+	_, err = client.Exchange(code, GenerateCodeVerifier())
+	assert.Equal(t, err.Error(), "token endpoint returned 400: invalid_grant ")
+}
+
+func TestOIDCClient_verifyIDToken(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   provider.server.URL,
+			"aud":   fakeClientId,
+			"sub":   "248289761001",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	tests := []struct {
+		name          string
+		claims        func(claims jwt.MapClaims)
+		kid           string
+		key           *rsa.PrivateKey
+		expectedError string
+	}{
+		{
+			name:   "Ok",
+			claims: func(claims jwt.MapClaims) {},
+		},
+		{
+			name:          "Nonce mismatch",
+			claims:        func(claims jwt.MapClaims) { claims["nonce"] = "other" },
+			expectedError: "id token nonce does not match",
+		},
+		{
+			name:          "No nonce",
+			claims:        func(claims jwt.MapClaims) { delete(claims, "nonce") },
+			expectedError: "id token nonce does not match",
+		},
+		{
+			name:          "Other audience",
+			claims:        func(claims jwt.MapClaims) { claims["aud"] = "other-client" },
+			expectedError: "invalid id token: token has invalid claims: token has invalid audience",
+		},
+		{
+			name: "Other authorized party",
+			claims: func(claims jwt.MapClaims) {
+				claims["aud"] = []string{fakeClientId, "other-client"}
+				claims["azp"] = "other-client"
+			},
+			expectedError: "id token was issued to other-client",
+		},
+		{
+			name:          "Other issuer",
+			claims:        func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			expectedError: "invalid id token: token has invalid claims: token has invalid issuer",
+		},
+		{
+			name:          "Expired",
+			claims:        func(claims jwt.MapClaims) { claims["exp"] = now.Add(-time.Hour).Unix() },
+			expectedError: "invalid id token: token has invalid claims: token is expired",
+		},
+		{
+			name:          "No subject",
+			claims:        func(claims jwt.MapClaims) { delete(claims, "sub") },
+			expectedError: "id token has no subject",
+		},
+		{
+			name:          "Forged signature",
+			claims:        func(claims jwt.MapClaims) {},
+			key:           otherKey,
+			expectedError: "invalid id token: token signature is invalid: crypto/rsa: verification error",
+		},
+		{
+			name:          "Unknown kid",
+			claims:        func(claims jwt.MapClaims) {},
+			kid:           "idp-unknown",
+			expectedError: "invalid id token: token is unverifiable: error while executing keyfunc: unknown kid \"idp-unknown\"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := provider.client(t)
+
+			claims := validClaims()
+			test.claims(claims)
+			kid, key := provider.kid, provider.key
+			if test.kid != "" {
+				kid = test.kid
+			}
+			if test.key != nil {
+				key = test.key
+			}
+
+			identity, err := client.VerifyIDToken(provider.sign(claims, kid, key), "nonce")
+			if test.expectedError == "" {
+				assert.Equal(t, err, nil)
+				assert.Equal(t, identity.Subject, "248289761001")
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			assert.Equal(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestOIDCClient_keyRotation(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	client := provider.client(t)
+
+	claims := jwt.MapClaims{
+		"iss":   provider.server.URL,
+		"aud":   fakeClientId,
+		"sub":   "248289761001",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": "nonce",
+	}
+	_, err := client.VerifyIDToken(provider.sign(claims, provider.kid, provider.key), "nonce")
+	assert.Equal(t, err, nil)
+
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.key, provider.kid = rotated, "idp-2"
+
+	// keys were fetched just now, an unknown kid does not fetch them again yet
+	_, err = client.VerifyIDToken(provider.sign(claims, provider.kid, provider.key), "nonce")
+	assert.NotEqual(t, err, nil)
+
+	client.keysFetchedAt = time.Now().Add(-oidcKeysRefreshInterval)
+	_, err = client.VerifyIDToken(provider.sign(claims, provider.kid, provider.key), "nonce")
+	assert.Equal(t, err, nil)
+}
+
+func TestOIDCClient_discoveryIssuerMismatch(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+
+	client, err := NewOIDCClient(OIDCConfig{
+		Issuer:      provider.server.URL + "/",
+		ClientId:    fakeClientId,
+		RedirectURL: fakeRedirectURL,
+		Scopes:      []string{"openid"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.AuthCodeURL("state", "nonce", GenerateCodeVerifier())
+	assert.Equal(t, err.Error(), "discovered issuer \""+provider.server.URL+"\" does not match \""+
+		provider.server.URL+"/\"")
+}
@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrPasswordMismatch    = errors.New("password does not match")
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash, password string) error
+	// NeedsRehash reports whether the hash was made by another algorithm
+	// or with other parameters than the hasher would use now
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher encodes hashes in the PHC string format
+// $argon2id$v=19$m=<memory KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+// so parameters can be raised without invalidating existing hashes
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idHasher uses the parameters recommended by OWASP
+func DefaultArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 19 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Iterations,
+		h.Parallelism, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Compare(hash, password string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory || params.Iterations != h.Iterations ||
+		params.Parallelism != h.Parallelism || uint32(len(salt)) != h.SaltLength || uint32(len(key)) != h.KeyLength
+}
+
+func decodeArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	var params Argon2idHasher
+	var version int
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher verifies hashes created before the switch to argon2id
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Compare(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+var passwordHasher PasswordHasher = DefaultArgon2idHasher()
+
+var legacyHasher PasswordHasher = &BcryptHasher{Cost: bcrypt.DefaultCost}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// SetPasswordHasher sets the hasher of new passwords. Hashes of other
+// formats are still accepted and reported by PasswordNeedsRehash
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+	dummyHashOnce = sync.Once{}
+}
+
+func EncodePassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+func ComparePasswords(hashPassword, password string) error {
+	if strings.HasPrefix(hashPassword, "$2") {
+		return legacyHasher.Compare(hashPassword, password)
+	}
+	return passwordHasher.Compare(hashPassword, password)
+}
+
+// CompareDummyPassword compares password with a fixed hash of the current
+// hasher. Checking a login of an unknown user with it takes as long as
+// checking a wrong password, so the response time does not tell which
+// usernames exist
+func CompareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		hash, err := passwordHasher.Hash("dummy password")
+		if err != nil {
+			panic(err)
+		}
+		dummyHash = hash
+	})
+	passwordHasher.Compare(dummyHash, password)
+}
+
+func PasswordNeedsRehash(hashPassword string) bool {
+	return passwordHasher.NeedsRehash(hashPassword)
+}
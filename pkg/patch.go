@@ -0,0 +1,387 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MergePatch is a JSON Merge Patch, RFC 7396
+	MergePatch = "application/merge-patch+json"
+	// JSONPatch is a JSON Patch, RFC 6902
+	JSONPatch = "application/json-patch+json"
+)
+
+// AcceptPatch lists the patch formats of PATCH endpoints, for the Accept-Patch header
+const AcceptPatch = "application/json, " + MergePatch + ", " + JSONPatch
+
+// PatchType is the media type of a PATCH body, application/json if none is given
+func PatchType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "application/json"
+	}
+	return mediaType
+}
+
+// ApplyPatch applies patch, of patchType MergePatch or JSONPatch, to the json
+// document doc. A malformed patch is an error, one that can not be applied to
+// doc, like a failed test or a path that does not exist, a ConflictError
+func ApplyPatch(patchType string, doc, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	patchValue, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+
+	switch patchType {
+	case MergePatch:
+		target = mergePatch(target, patchValue)
+	case JSONPatch:
+		target, err = jsonPatch(target, patchValue)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported patch type %s", patchType)
+	}
+	return json.Marshal(target)
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after json value")
+	}
+	return value, nil
+}
+
+// mergePatch merges objects member by member, null removes a member and any
+// other value replaces the target
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+type patchOperation struct {
+	Op   string  `json:"op"`
+	Path *string `json:"path"`
+	From *string `json:"from"`
+	// null is a value, unlike a missing member
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatch applies the operations in order, any failing one fails the patch
+func jsonPatch(target, patch any) (any, error) {
+	raw, _ := json.Marshal(patch)
+	var operations []patchOperation
+	if err := json.Unmarshal(raw, &operations); err != nil {
+		return nil, errors.New("invalid patch: must be an array of operations")
+	}
+
+	for i, operation := range operations {
+		if operation.Path == nil {
+			return nil, fmt.Errorf("invalid patch: operation %d has no path", i)
+		}
+		path, err := parsePointer(*operation.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid patch: operation %d: %w", i, err)
+		}
+
+		var value any
+		switch operation.Op {
+		case "add", "replace", "test":
+			if len(operation.Value) == 0 {
+				return nil, fmt.Errorf("invalid patch: %s operation %d has no value", operation.Op, i)
+			}
+			if value, err = decodeJSON(operation.Value); err != nil {
+				return nil, fmt.Errorf("invalid patch: operation %d: %w", i, err)
+			}
+		case "move", "copy":
+			if operation.From == nil {
+				return nil, fmt.Errorf("invalid patch: %s operation %d has no from", operation.Op, i)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("invalid patch: unknown operation %q", operation.Op)
+		}
+
+		switch operation.Op {
+		case "add":
+			target, err = addValue(target, path, value)
+		case "remove":
+			target, _, err = removeValue(target, path)
+		case "replace":
+			if target, _, err = removeValue(target, path); err == nil {
+				target, err = addValue(target, path, value)
+			}
+		case "move", "copy":
+			from, fromErr := parsePointer(*operation.From)
+			if fromErr != nil {
+				return nil, fmt.Errorf("invalid patch: operation %d: %w", i, fromErr)
+			}
+			if operation.Op == "move" {
+				if isPrefix(from, path) && len(from) < len(path) {
+					return nil, fmt.Errorf("invalid patch: operation %d moves a value into itself", i)
+				}
+				target, value, err = removeValue(target, from)
+			} else {
+				value, err = getValue(target, from)
+				// a copy must not share maps or slices with its source
+				if err == nil {
+					value, err = deepCopy(value)
+				}
+			}
+			if err == nil {
+				target, err = addValue(target, path, value)
+			}
+		case "test":
+			var current any
+			if current, err = getValue(target, path); err == nil && !equalJSON(current, value) {
+				err = fmt.Errorf("test of %s failed", *operation.Path)
+			}
+		}
+		if err != nil {
+			return nil, &entity.ConflictError{Message: fmt.Sprintf("Can not apply patch operation %d: %s", i, err.Error())}
+		}
+	}
+	return target, nil
+}
+
+// parsePointer splits a JSON Pointer, RFC 6901, into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q does not start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pointerString(path []string) string {
+	if len(path) == 0 {
+		return "the document"
+	}
+	return "/" + strings.Join(path, "/")
+}
+
+func getValue(target any, path []string) (any, error) {
+	current := target
+	for i, token := range path {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", pointerString(path[:i+1]))
+			}
+			current = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pointerString(path[:i+1]), err)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%s does not exist", pointerString(path[:i+1]))
+		}
+	}
+	return current, nil
+}
+
+// addValue sets the member at path or inserts into the array at path, where
+// - appends, and returns the new target
+func addValue(target any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getValue(target, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[token] = value
+		return target, nil
+	case []any:
+		index := len(node)
+		if token != "-" {
+			if index, err = arrayIndex(token, len(node)); err != nil {
+				return nil, fmt.Errorf("%s: %w", pointerString(path), err)
+			}
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return setValue(target, path[:len(path)-1], node)
+	default:
+		return nil, fmt.Errorf("%s is not an object or array", pointerString(path[:len(path)-1]))
+	}
+}
+
+// removeValue removes the value at path and returns the new target and the
+// removed value
+func removeValue(target any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, target, nil
+	}
+	parent, err := getValue(target, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	token := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]any:
+		value, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s does not exist", pointerString(path))
+		}
+		delete(node, token)
+		return target, value, nil
+	case []any:
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", pointerString(path), err)
+		}
+		value := node[index]
+		node = append(node[:index:index], node[index+1:]...)
+		target, err = setValue(target, path[:len(path)-1], node)
+		return target, value, err
+	default:
+		return nil, nil, fmt.Errorf("%s does not exist", pointerString(path))
+	}
+}
+
+// setValue replaces the value at an existing path, arrays change on insert
+// and remove so they are stored back into their parent
+func setValue(target any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getValue(target, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[token] = value
+	case []any:
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	}
+	return target, nil
+}
+
+// arrayIndex parses an array index token, which may be at most max
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("array index %d is out of bounds", index)
+	}
+	return index, nil
+}
+
+func deepCopy(value any) (any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(raw)
+}
+
+// equalJSON compares json values, numbers by value, so 1 equals 1.0
+func equalJSON(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equalJSON(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalJSON(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"github.com/go-playground/assert/v2"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name             string
+		patchType        string
+		doc              string
+		patch            string
+		expectedDoc      string
+		expectedConflict bool
+		expectedError    bool
+	}{
+		{
+			name:        "Merge patch",
+			patchType:   MergePatch,
+			doc:         `{"name":"name","description":"description","rating":5,"actorsId":[1,2]}`,
+			patch:       `{"description":null,"rating":7,"actorsId":[3]}`,
+			expectedDoc: `{"actorsId":[3],"name":"name","rating":7}`,
+		},
+		{
+			name:        "Merge patch of nested objects",
+			patchType:   MergePatch,
+			doc:         `{"a":{"b":"c","d":"e"}}`,
+			patch:       `{"a":{"d":null,"f":"g"}}`,
+			expectedDoc: `{"a":{"b":"c","f":"g"}}`,
+		},
+		{
+			name:        "Merge patch replacing the document",
+			patchType:   MergePatch,
+			doc:         `{"a":"b"}`,
+			patch:       `["c"]`,
+			expectedDoc: `["c"]`,
+		},
+		{
+			name:          "Malformed merge patch",
+			patchType:     MergePatch,
+			doc:           `{"a":"b"}`,
+			patch:         `{"a":`,
+			expectedError: true,
+		},
+		{
+			name:      "JSON patch",
+			patchType: JSONPatch,
+			doc:       `{"name":"name","description":"description","rating":5,"actorsId":[1,2]}`,
+			patch: `[
+				{"op":"test","path":"/rating","value":5.0},
+				{"op":"replace","path":"/description","value":null},
+				{"op":"add","path":"/actorsId/-","value":3},
+				{"op":"add","path":"/actorsId/0","value":0},
+				{"op":"remove","path":"/actorsId/1"},
+				{"op":"copy","from":"/name","path":"/title"},
+				{"op":"move","from":"/title","path":"/a~1b"}
+			]`,
+			expectedDoc: `{"a/b":"name","actorsId":[0,2,3],"description":null,"name":"name","rating":5}`,
+		},
+		{
+			name:             "Failed test",
+			patchType:        JSONPatch,
+			doc:              `{"rating":5}`,
+			patch:            `[{"op":"test","path":"/rating","value":6},{"op":"replace","path":"/rating","value":7}]`,
+			expectedConflict: true,
+		},
+		{
+			name:             "Replace of missing member",
+			patchType:        JSONPatch,
+			doc:              `{"rating":5}`,
+			patch:            `[{"op":"replace","path":"/name","value":"name"}]`,
+			expectedConflict: true,
+		},
+		{
+			name:             "Array index out of bounds",
+			patchType:        JSONPatch,
+			doc:              `{"actorsId":[1]}`,
+			patch:            `[{"op":"add","path":"/actorsId/2","value":2}]`,
+			expectedConflict: true,
+		},
+		{
+			name:          "Unknown operation",
+			patchType:     JSONPatch,
+			doc:           `{"rating":5}`,
+			patch:         `[{"op":"increment","path":"/rating"}]`,
+			expectedError: true,
+		},
+		{
+			name:          "Add without value",
+			patchType:     JSONPatch,
+			doc:           `{"rating":5}`,
+			patch:         `[{"op":"add","path":"/rating"}]`,
+			expectedError: true,
+		},
+		{
+			name:          "Move into itself",
+			patchType:     JSONPatch,
+			doc:           `{"a":{"b":1}}`,
+			patch:         `[{"op":"move","from":"/a","path":"/a/c"}]`,
+			expectedError: true,
+		},
+		{
+			name:          "Not an array of operations",
+			patchType:     JSONPatch,
+			doc:           `{"rating":5}`,
+			patch:         `{"op":"remove","path":"/rating"}`,
+			expectedError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := ApplyPatch(test.patchType, []byte(test.doc), []byte(test.patch))
+
+			var conflict *entity.ConflictError
+			assert.Equal(t, errors.As(err, &conflict), test.expectedConflict)
+			assert.Equal(t, err != nil && conflict == nil, test.expectedError)
+			assert.Equal(t, string(doc), test.expectedDoc)
+		})
+	}
+}
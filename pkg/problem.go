@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"log"
+	"net/http"
+)
+
+// Problem is an error response as of RFC 7807. Type is about:blank, so Title
+// is the text of Status and Detail says what went wrong. Constraint names the
+// database constraint a conflicting or invalid write violated
+type Problem struct {
+	Type       string              `json:"type" example:"about:blank"`
+	Title      string              `json:"title" example:"Unprocessable Entity"`
+	Status     int                 `json:"status" example:"422"`
+	Detail     string              `json:"detail,omitempty" example:"Invalid request"`
+	Instance   string              `json:"instance,omitempty" example:"/api/film"`
+	RequestId  string              `json:"requestId,omitempty"`
+	Errors     []entity.FieldError `json:"errors,omitempty"`
+	Constraint string              `json:"constraint,omitempty" example:"film_rating_check"`
+}
+
+func newProblem(r *http.Request, status int, detail string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestId: RequestIdFromContext(r.Context()),
+	}
+}
+
+// WriteProblem answers with a problem of status
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblem(w, newProblem(r, status, detail))
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// HandleError answers with a problem with the status of domain errors, see
+// ErrorStatus, and with status for other errors. Fields of validation errors
+// are listed in errors and violated constraints are named. Internal errors are
+// only logged
+func HandleError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	status = ErrorStatus(err, status)
+	problem := newProblem(r, status, err.Error())
+	if problem.RequestId != "" {
+		log.Printf("Error in request %s: %s", problem.RequestId, err.Error())
+	} else {
+		log.Printf("Error: %s", err.Error())
+	}
+
+	var (
+		validation *entity.ValidationError
+		conflict   *entity.ConflictError
+	)
+	if errors.As(err, &validation) {
+		problem.Detail = validation.Message
+		problem.Errors = validation.Fields
+		problem.Constraint = validation.Constraint
+	}
+	if errors.As(err, &conflict) {
+		problem.Constraint = conflict.Constraint
+	}
+	if status == http.StatusInternalServerError {
+		problem.Detail = ""
+	}
+	writeProblem(w, problem)
+}
+
+// WriteJSON answers with v encoded as json
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
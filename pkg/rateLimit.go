@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter allows limit requests per key in each period, counted in fixed windows
+type RateLimiter struct {
+	limit  int
+	period time.Duration
+
+	mu       sync.Mutex
+	windows  map[string]*rateWindow
+	prunedAt time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, period time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, period: period, windows: make(map[string]*rateWindow)}
+}
+
+// Allow counts a request of key. Over the limit it returns false and the time
+// until the next window starts
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.prunedAt) >= l.period {
+		for k, window := range l.windows {
+			if now.Sub(window.start) >= l.period {
+				delete(l.windows, k)
+			}
+		}
+		l.prunedAt = now
+	}
+
+	window, ok := l.windows[key]
+	if !ok || now.Sub(window.start) >= l.period {
+		window = &rateWindow{start: now}
+		l.windows[key] = window
+	}
+	if window.count >= l.limit {
+		return false, window.start.Add(l.period).Sub(now)
+	}
+	window.count++
+	return true, 0
+}
+
+func remoteIp(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
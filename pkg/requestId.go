@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+)
+
+const requestIdContextKey contextKey = "requestId"
+
+// maxRequestIdLength bounds request ids sent by clients, longer ones are replaced
+const maxRequestIdLength = 128
+
+// RequestId tags requests with the X-Request-Id of the client, or a new one,
+// and echoes it in the response so errors can be matched with the logs
+func RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" || len(id) > maxRequestIdLength || !printable(id) {
+			id = randomToken(12)
+		}
+		w.Header().Set("X-Request-Id", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIdContextKey, id)))
+	})
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdContextKey).(string)
+	return id
+}
+
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
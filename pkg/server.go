@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// serverTimeout is the read and write timeout of connections
+const serverTimeout = 10 * time.Second
+
 type Server struct {
 	httpServer *http.Server
 }
@@ -14,8 +17,8 @@ func (s *Server) Run(port string, handler http.Handler) error {
 	s.httpServer = &http.Server{
 		Addr:         ":" + port,
 		Handler:      handler,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		ReadTimeout:  serverTimeout,
+		WriteTimeout: serverTimeout,
 	}
 
 	return s.httpServer.ListenAndServe()
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+type RevocationList interface {
+	IsTokenRevoked(ctx context.Context, jti string, userId, sessionId int, issuedAt time.Time) (bool, error)
+}
+
+var revocationList RevocationList
+
+func SetRevocationList(list RevocationList) {
+	revocationList = list
+}
+
+func GenerateTokenId() string {
+	return randomToken(16)
+}
+
+func GenerateRefreshToken() string {
+	return randomToken(32)
+}
+
+func GenerateInviteCode() string {
+	return randomToken(18)
+}
+
+// GenerateOIDCState returns a random state or nonce of an oidc login
+func GenerateOIDCState() string {
+	return randomToken(32)
+}
+
+// ApiKeyPrefix marks api keys so leaked ones are easy to find by secret scanners
+const ApiKeyPrefix = "flk_"
+
+func GenerateApiKey() string {
+	return ApiKeyPrefix + randomToken(32)
+}
+
+// GenerateRecoveryCode returns a two factor recovery code like 3f9a1-c07de
+func GenerateRecoveryCode() string {
+	buf := make([]byte, 5)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	code := hex.EncodeToString(buf)
+	return code[:5] + "-" + code[5:]
+}
+
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomToken(size int) string {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
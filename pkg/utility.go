@@ -1,15 +1,47 @@
 package pkg
 
 import (
-	"log"
+	"errors"
+	"filmLibraryVk/internal/model/entity"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
-func HandleError(w http.ResponseWriter, err error, status int) {
-	log.Printf("Error: %s", err.Error())
-	http.Error(w, err.Error(), status)
+// ErrorStatus maps the domain errors of entity to their status, other errors
+// to status
+func ErrorStatus(err error, status int) int {
+	var (
+		notFound    *entity.NotFoundError
+		conflict    *entity.ConflictError
+		validation  *entity.ValidationError
+		forbidden   *entity.ForbiddenError
+		unavailable *entity.UnavailableError
+		modified    *entity.PreconditionFailedError
+		internal    *entity.InternalError
+		tooLarge    *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &conflict):
+		return http.StatusConflict
+	case errors.As(err, &validation):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &forbidden):
+		return http.StatusForbidden
+	case errors.As(err, &unavailable):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &modified):
+		return http.StatusPreconditionFailed
+	case errors.As(err, &internal):
+		return http.StatusInternalServerError
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	}
+	return status
 }
 
 func GetPathId(w http.ResponseWriter, r *http.Request, prefix string) (int, error) {
@@ -17,7 +49,7 @@ func GetPathId(w http.ResponseWriter, r *http.Request, prefix string) (int, erro
 	id, err := strconv.Atoi(idString)
 
 	if err != nil {
-		HandleError(w, err, http.StatusBadRequest)
+		HandleError(w, r, err, http.StatusBadRequest)
 		return 0, err
 	}
 	return id, nil
@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"errors"
+	"filmLibraryVk/internal/model/entity"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// InvalidRequest describes a failed validation of a request field by field,
+// named as in json
+func InvalidRequest(err error) error {
+	invalid := &entity.ValidationError{Message: "Invalid request"}
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return invalid
+	}
+	for _, field := range fieldErrors {
+		invalid.Fields = append(invalid.Fields, entity.FieldError{
+			Field:   jsonName(field.Field()),
+			Message: fieldMessage(field),
+		})
+	}
+	return invalid
+}
+
+func fieldMessage(field validator.FieldError) string {
+	switch field.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "oneof":
+		return "must be one of " + field.Param()
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return bound(field, " characters")
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if field.Tag() == "min" && field.Param() == "1" {
+			return "must not be empty"
+		}
+		return strings.Replace(bound(field, " items"), "must be", "must have", 1)
+	}
+	return bound(field, "")
+}
+
+func bound(field validator.FieldError, unit string) string {
+	switch field.Tag() {
+	case "min", "gte":
+		return "must be at least " + field.Param() + unit
+	case "max", "lte":
+		return "must be at most " + field.Param() + unit
+	}
+	return "is invalid"
+}
+
+func jsonName(field string) string {
+	r, size := utf8.DecodeRuneInString(field)
+	return string(unicode.ToLower(r)) + field[size:]
+}
+
+// InvalidField describes a request with one invalid field
+func InvalidField(field, message string) error {
+	return &entity.ValidationError{Message: "Invalid request",
+		Fields: []entity.FieldError{{Field: field, Message: message}}}
+}
+
+// RequiredFields describes a request missing the fields named, nil if none is
+func RequiredFields(fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	invalid := &entity.ValidationError{Message: "Invalid request"}
+	for _, field := range fields {
+		invalid.Fields = append(invalid.Fields, entity.FieldError{Field: field, Message: "is required"})
+	}
+	return invalid
+}